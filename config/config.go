@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -12,27 +14,69 @@ const (
 	EnvProduction  = "PROD"
 )
 
+const (
+	RedditPollModeOff      = "off"
+	RedditPollModePrimary  = "primary"
+	RedditPollModeFailover = "failover"
+)
+
 type AppConfig struct {
-	KeycloakClientID           string
-	KeycloakClientSecret       string
-	KeycloakRealm              string
-	KeycloakURL                string
-	AppBaseURL                 string
-	PostgresURL                string
-	SMTPHost                   string
-	SMTPPort                   string
-	SMTPFrom                   string
-	SMTPUsername               string
-	SMTPPassword               string
-	PostPollIntervalMs         int
-	AppEnv                     string // EnvDevelopment or EnvProduction
-	LogLevel                   slog.Level
-	EnableArcticShift          bool
-	SearchAPIURL               string
-	OpenAIAPIKey               string
-	OpenAIModel                string
-	WeeklySmartGenerationLimit int
-	GlobalGenerationLimit      int
+	KeycloakClientID            string
+	KeycloakClientSecret        string
+	KeycloakRealm               string
+	KeycloakURL                 string
+	AppBaseURL                  string
+	PostgresURL                 string
+	SMTPHost                    string
+	SMTPPort                    string
+	SMTPFrom                    string
+	SMTPUsername                string
+	SMTPPassword                string
+	PostPollIntervalMs          int
+	CommentPollIntervalMs       int
+	MaxCatchUpMinutes           int
+	MaxBackfillHours            int
+	MatchWorkers                int
+	MatchQueueSize              int
+	MatchWriteBatchSize         int
+	ParentCacheSize             int
+	ResolveParentBody           bool
+	RecentItemsHours            int
+	DuplicateWindowHours        int
+	AppEnv                      string // EnvDevelopment or EnvProduction
+	LogLevel                    slog.Level
+	EnableArcticShift           bool
+	RedditPollMode              string // RedditPollModeOff, RedditPollModePrimary or RedditPollModeFailover
+	RedditClientID              string
+	RedditClientSecret          string
+	RedditUserAgent             string
+	RedditPollIntervalMs        int
+	RedditFailoverLagSeconds    int
+	EnableHackerNews            bool
+	HackerNewsPollIntervalMs    int
+	EnableMastodon              bool
+	MastodonInstances           []string
+	MastodonHashtags            []string
+	MastodonPollIntervalMs      int
+	EnableBluesky               bool
+	BlueskyJetstreamURL         string
+	EnableGitHub                bool
+	GitHubToken                 string
+	GitHubRepos                 []string
+	GitHubPollIntervalMs        int
+	EnableStackExchange         bool
+	StackExchangeSites          []string
+	StackExchangeKey            string
+	StackExchangePollIntervalMs int
+	EnableFeeds                 bool
+	FeedPollIntervalMs          int
+	SearchAPIURL                string
+	OpenAIAPIKey                string
+	OpenAIModel                 string
+	WeeklySmartGenerationLimit  int
+	GlobalGenerationLimit       int
+	IngestDailyItemLimit        int
+	AdminEmails                 []string
 }
 
 var Config AppConfig
@@ -53,12 +97,50 @@ func LoadConfig() {
 	cfg.SMTPUsername = loadRequired("SMTP_USERNAME")
 	cfg.SMTPPassword = loadRequired("SMTP_PASSWORD")
 	cfg.PostPollIntervalMs = parseIntEnv(loadOptional("POST_POLL_INTERVAL_MS", "3000"))
+	cfg.CommentPollIntervalMs = parseIntEnv(loadOptional("COMMENT_POLL_INTERVAL_MS", strconv.Itoa(cfg.PostPollIntervalMs)))
+	cfg.MaxCatchUpMinutes = parseIntEnv(loadOptional("POLL_MAX_CATCHUP_MINUTES", "60"))
+	cfg.MaxBackfillHours = parseIntEnv(loadOptional("POLL_MAX_BACKFILL_HOURS", "24"))
+	cfg.MatchWorkers = parseIntEnv(loadOptional("MATCH_WORKERS", strconv.Itoa(runtime.NumCPU())))
+	cfg.MatchQueueSize = parseIntEnv(loadOptional("MATCH_QUEUE_SIZE", "64"))
+	cfg.MatchWriteBatchSize = parseIntEnv(loadOptional("MATCH_WRITE_BATCH_SIZE", "500"))
+	cfg.ParentCacheSize = parseIntEnv(loadOptional("PARENT_CACHE_SIZE", "50000"))
+	cfg.ResolveParentBody = parseBoolEnv(loadOptional("RESOLVE_PARENT_BODY", "false"))
+	cfg.RecentItemsHours = parseIntEnv(loadOptional("RECENT_ITEMS_HOURS", "6"))
+	cfg.DuplicateWindowHours = parseIntEnv(loadOptional("DUPLICATE_WINDOW_HOURS", "24"))
 	cfg.EnableArcticShift = parseBoolEnv(loadOptional("ENABLE_ARCTICSHIFT_POLLING", "true"))
+	cfg.RedditPollMode = parseRedditPollMode(loadOptional("REDDIT_POLL_MODE", RedditPollModeOff))
+	if cfg.RedditPollMode != RedditPollModeOff {
+		cfg.RedditClientID = loadRequired("REDDIT_CLIENT_ID")
+		cfg.RedditClientSecret = loadRequired("REDDIT_CLIENT_SECRET")
+	}
+	cfg.RedditUserAgent = loadOptional("REDDIT_USER_AGENT", "web:feedgrep:v1.0")
+	cfg.RedditPollIntervalMs = parseIntEnv(loadOptional("REDDIT_POLL_INTERVAL_MS", "5000"))
+	cfg.RedditFailoverLagSeconds = parseIntEnv(loadOptional("REDDIT_FAILOVER_LAG_SECONDS", "900"))
+	cfg.EnableHackerNews = parseBoolEnv(loadOptional("ENABLE_HACKERNEWS_POLLING", "false"))
+	cfg.HackerNewsPollIntervalMs = parseIntEnv(loadOptional("HACKERNEWS_POLL_INTERVAL_MS", "30000"))
+	cfg.EnableMastodon = parseBoolEnv(loadOptional("ENABLE_MASTODON_POLLING", "false"))
+	cfg.MastodonInstances = parseListEnv(loadOptional("MASTODON_INSTANCES", "mastodon.social"))
+	cfg.MastodonHashtags = parseListEnv(os.Getenv("MASTODON_HASHTAGS"))
+	cfg.MastodonPollIntervalMs = parseIntEnv(loadOptional("MASTODON_POLL_INTERVAL_MS", "15000"))
+	cfg.EnableBluesky = parseBoolEnv(loadOptional("ENABLE_BLUESKY_STREAMING", "false"))
+	cfg.BlueskyJetstreamURL = loadOptional("BLUESKY_JETSTREAM_URL", "wss://jetstream2.us-east.bsky.network/subscribe")
+	cfg.EnableGitHub = parseBoolEnv(loadOptional("ENABLE_GITHUB_POLLING", "false"))
+	cfg.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	cfg.GitHubRepos = parseListEnv(os.Getenv("GITHUB_REPOS"))
+	cfg.GitHubPollIntervalMs = parseIntEnv(loadOptional("GITHUB_POLL_INTERVAL_MS", "60000"))
+	cfg.EnableStackExchange = parseBoolEnv(loadOptional("ENABLE_STACKEXCHANGE_POLLING", "false"))
+	cfg.StackExchangeSites = parseListEnv(loadOptional("STACKEXCHANGE_SITES", "stackoverflow"))
+	cfg.StackExchangeKey = os.Getenv("STACKEXCHANGE_KEY")
+	cfg.StackExchangePollIntervalMs = parseIntEnv(loadOptional("STACKEXCHANGE_POLL_INTERVAL_MS", "300000"))
+	cfg.EnableFeeds = parseBoolEnv(loadOptional("ENABLE_FEED_POLLING", "true"))
+	cfg.FeedPollIntervalMs = parseIntEnv(loadOptional("FEED_POLL_INTERVAL_MS", "300000"))
 	cfg.SearchAPIURL = loadRequired("SEARCH_API_URL")
 	cfg.OpenAIAPIKey = loadRequired("OPENAI_API_KEY")
 	cfg.OpenAIModel = loadOptional("OPENAI_MODEL", "gpt-5.4")
 	cfg.WeeklySmartGenerationLimit = parseIntEnv(loadOptional("WEEKLY_SMART_FILTER_GENERATION_LIMIT", "3"))
 	cfg.GlobalGenerationLimit = parseIntEnv(loadOptional("GLOBAL_SMART_FILTER_GENERATION_LIMIT", "200"))
+	cfg.IngestDailyItemLimit = parseIntEnv(loadOptional("INGEST_DAILY_ITEM_LIMIT", "10000"))
+	cfg.AdminEmails = parseListEnv(os.Getenv("ADMIN_EMAILS"))
 
 	lvlString := loadOptional("LOG_LEVEL", "INFO")
 	var err error
@@ -88,6 +170,17 @@ func parseIntEnv(str string) int {
 	return value
 }
 
+func parseRedditPollMode(str string) string {
+	mode := strings.ToLower(str)
+	switch mode {
+	case RedditPollModeOff, RedditPollModePrimary, RedditPollModeFailover:
+		return mode
+	}
+	slog.Error("Invalid REDDIT_POLL_MODE", "mode", str)
+	os.Exit(1)
+	return ""
+}
+
 func parseBoolEnv(str string) bool {
 	lowerStr := strings.ToLower(str)
 	if lowerStr == "true" || lowerStr == "1" || lowerStr == "yes" {
@@ -96,6 +189,17 @@ func parseBoolEnv(str string) bool {
 	return false
 }
 
+func parseListEnv(str string) []string {
+	var values []string
+	for _, value := range strings.Split(str, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func loadRequired(key string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -116,3 +220,12 @@ func loadOptional(key, defaultValue string) string {
 func (c AppConfig) IsProduction() bool {
 	return Config.AppEnv == EnvProduction
 }
+
+func (c AppConfig) IsAdmin(email string) bool {
+	for _, admin := range c.AdminEmails {
+		if strings.EqualFold(admin, strings.TrimSpace(email)) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 const (
 	RateIDSmartFilterGeneration       = "smart_filter_generation"
 	RateIDSmartFilterGenerationGlobal = "smart_filter_generation_global"
+	RateIDIngestItems                 = "ingest_items"
 )
 
 type RateLimitPolicy struct {
@@ -40,6 +41,12 @@ func buildRateLimits() map[string]RateLimitPolicy {
 			Window:    RateLimitWindowMonthly,
 			WindowKey: MonthlyWindowKey,
 		},
+		RateIDIngestItems: {
+			RateID:    RateIDIngestItems,
+			Limit:     Config.IngestDailyItemLimit,
+			Window:    RateLimitWindowDaily,
+			WindowKey: DailyWindowKey,
+		},
 	}
 }
 
@@ -27,6 +27,29 @@ type RateLimitCounter struct {
 	UpdatedAt time.Time `db:"updated_at"`
 }
 
+type SourceCursor struct {
+	Source    string    `db:"source"`
+	Cursor    int64     `db:"cursor"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type Feed struct {
+	ID                  int        `db:"id"`
+	UserID              uuid.UUID  `db:"user_id"`
+	URL                 string     `db:"url"`
+	Title               string     `db:"title"`
+	Active              bool       `db:"active"`
+	ETag                string     `db:"etag"`
+	LastModified        string     `db:"last_modified"`
+	Cursor              int64      `db:"cursor"`
+	LastFetchedAt       *time.Time `db:"last_fetched_at"`
+	LastSuccessAt       *time.Time `db:"last_success_at"`
+	LastError           string     `db:"last_error"`
+	ConsecutiveFailures int        `db:"consecutive_failures"`
+	CreatedAt           time.Time  `db:"created_at"`
+	UpdatedAt           time.Time  `db:"updated_at"`
+}
+
 type AuthActionToken struct {
 	ID        uuid.UUID  `db:"id"`
 	UserID    string     `db:"user_id"`
@@ -54,13 +77,43 @@ type KeywordFilters struct {
 	Reddit   *RedditFilters   `json:"reddit,omitempty"`
 	Language *LanguageFilters `json:"language,omitempty"`
 	Smart    *SmartFilter     `json:"smart,omitempty"`
+
+	// Engagement defers Reddit post matches until the post crosses the threshold.
+	Engagement *EngagementThreshold `json:"engagement,omitempty"`
+}
+
+// EngagementThreshold is met when the post reaches either the minimum score or the minimum
+// number of comments within the given number of hours. Zero minimums are ignored. It only applies
+// to Reddit posts.
+type EngagementThreshold struct {
+	MinScore    int `json:"min_score,omitempty"`
+	MinComments int `json:"min_comments,omitempty"`
+	WithinHours int `json:"within_hours,omitempty"`
+}
+
+func (t EngagementThreshold) Met(score, comments int) bool {
+	return (t.MinScore > 0 && score >= t.MinScore) || (t.MinComments > 0 && comments >= t.MinComments)
 }
 
 type RedditFilters struct {
 	Subreddits        []string `json:"subreddits,omitempty"`         // only match in these subreddits (empty = all)
 	ExcludeSubreddits []string `json:"exclude_subreddits,omitempty"` // never match in these subreddits
+
+	// Post filters apply to Reddit posts, and to comments through the post they belong to. A comment
+	// whose post could not be resolved does not match while any post filter is set.
+	ExcludeNSFW    bool     `json:"exclude_nsfw,omitempty"`    // never match posts marked over 18
+	Flairs         []string `json:"flairs,omitempty"`          // only match posts with one of these link flairs (empty = all)
+	ExcludeFlairs  []string `json:"exclude_flairs,omitempty"`  // never match posts with these link flairs
+	Domains        []string `json:"domains,omitempty"`         // only match link posts to these domains (empty = all)
+	ExcludeDomains []string `json:"exclude_domains,omitempty"` // never match link posts to these domains
+	PostType       string   `json:"post_type,omitempty"`       // RedditPostTypeSelf or RedditPostTypeLink (empty = both)
 }
 
+const (
+	RedditPostTypeSelf = "self"
+	RedditPostTypeLink = "link"
+)
+
 type LanguageFilters struct {
 	Languages        []string `json:"languages,omitempty"`         // only match in these detected languages (empty = all)
 	ExcludeLanguages []string `json:"exclude_languages,omitempty"` // never match in these detected languages
@@ -79,6 +132,8 @@ type SmartFilter struct {
 type SmartScope struct {
 	Language   SmartScopeList `json:"language,omitempty"`
 	Subreddits SmartScopeList `json:"subreddits,omitempty"`
+	Instances  SmartScopeList `json:"instances,omitempty"`
+	Tags       SmartScopeList `json:"tags,omitempty"`
 }
 
 type SmartScopeList struct {
@@ -98,11 +153,32 @@ type SmartSignal struct {
 	Condition SmartCondition `json:"condition"`
 }
 
+// SmartCondition is decided by its first non-empty branch, in the order any, all, none, near,
+// countAtLeast and finally anyPhrase together with regex.
 type SmartCondition struct {
-	Any       []SmartCondition `json:"any,omitempty"`
-	All       []SmartCondition `json:"all,omitempty"`
-	AnyPhrase []string         `json:"anyPhrase,omitempty"`
-	Regex     []string         `json:"regex,omitempty"`
+	Any  []SmartCondition `json:"any,omitempty"`
+	All  []SmartCondition `json:"all,omitempty"`
+	None []SmartCondition `json:"none,omitempty"` // matches when none of the conditions match
+
+	Near         *SmartNear         `json:"near,omitempty"`
+	CountAtLeast *SmartCountAtLeast `json:"countAtLeast,omitempty"`
+
+	AnyPhrase []string `json:"anyPhrase,omitempty"`
+	Regex     []string `json:"regex,omitempty"`
+}
+
+// SmartNear matches when every term occurs in the same field with at most Distance other words
+// between them, in the listed order when Ordered is set. Terms may be multi-word phrases.
+type SmartNear struct {
+	Terms    []string `json:"terms"`
+	Distance int      `json:"distance"`
+	Ordered  bool     `json:"ordered,omitempty"`
+}
+
+// SmartCountAtLeast matches when at least Count of the phrases occur.
+type SmartCountAtLeast struct {
+	Count   int      `json:"count"`
+	Phrases []string `json:"phrases"`
 }
 
 type SmartThresholds struct {
@@ -128,6 +204,66 @@ type Match struct {
 	NotifiedAt *time.Time      `db:"notified_at"`
 	SeenAt     *time.Time      `db:"seen_at"`
 	CreatedAt  time.Time       `db:"created_at"`
+
+	// Fingerprint is the SimHash of the matched text, used to collapse near-duplicates. It is nil
+	// for texts too short to compare.
+	Fingerprint     *int64          `db:"fingerprint"`
+	AlsoPostedInRaw json.RawMessage `db:"also_posted_in"`
+	AlsoPostedIn    []MatchLocation `db:"-"`
+	// Location is where the matched item was posted. Only its permalink is stored, to tell the
+	// matches of one item apart from near-duplicates posted elsewhere.
+	Location          MatchLocation `db:"-"`
+	LocationPermalink string        `db:"location_permalink"`
+}
+
+// MatchLocation is a place a matched item was posted in.
+type MatchLocation struct {
+	Source    enums.Source `json:"source"`
+	Subreddit string       `json:"subreddit,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Permalink string       `json:"permalink"`
+}
+
+func (m *Match) ParseAlsoPostedIn() ([]MatchLocation, error) {
+	var locations []MatchLocation
+	if len(m.AlsoPostedInRaw) == 0 {
+		return locations, nil
+	}
+	err := json.Unmarshal(m.AlsoPostedInRaw, &locations)
+	return locations, err
+}
+
+// PendingMatch is a match that waits for its post to cross the keyword's engagement threshold
+// before it is promoted into matches.
+type PendingMatch struct {
+	ID          int64           `db:"id"`
+	UserID      uuid.UUID       `db:"user_id"`
+	KeywordID   int             `db:"keyword_id"`
+	Source      enums.Source    `db:"source"`
+	Hash        string          `db:"hash"`
+	PostID      string          `db:"post_id"`
+	DataRaw     json.RawMessage `db:"data"`
+	MinScore    int             `db:"min_score"`
+	MinComments int             `db:"min_comments"`
+	ExpiresAt   time.Time       `db:"expires_at"`
+	NextCheckAt time.Time       `db:"next_check_at"`
+	CreatedAt   time.Time       `db:"created_at"`
+	// Fingerprint is the fingerprint the match is stored with once it is promoted.
+	Fingerprint *int64 `db:"fingerprint"`
+}
+
+// RecentItem is a fetched item kept for a few hours so new and edited keywords can be matched
+// against recent content. Details holds the rest of the item as JSON.
+type RecentItem struct {
+	ID         int64           `db:"id"`
+	Source     enums.Source    `db:"source"`
+	Kind       string          `db:"kind"`
+	ItemID     string          `db:"item_id"`
+	OwnerID    *uuid.UUID      `db:"owner_id"`
+	Title      string          `db:"title"`
+	Body       string          `db:"body"`
+	DetailsRaw json.RawMessage `db:"details"`
+	CreatedAt  time.Time       `db:"created_at"`
 }
 
 func NewMatch(userID uuid.UUID, keywordID int, source enums.Source, hash string, data any) (Match, error) {
@@ -153,4 +289,101 @@ type RedditData struct {
 	Body      string `json:"body"`
 	Permalink string `json:"permalink"`
 	IsComment bool   `json:"is_comment"`
+
+	// ParentTitle and ParentBody describe the post a comment was made on, when it could be resolved.
+	ParentTitle string `json:"parent_title,omitempty"`
+	ParentBody  string `json:"parent_body,omitempty"`
+
+	// Post metadata, captured when the post was fetched. Not set for comments.
+	Score       int    `json:"score,omitempty"`
+	NumComments int    `json:"num_comments,omitempty"`
+	Flair       string `json:"flair,omitempty"`
+	AuthorFlair string `json:"author_flair,omitempty"`
+	NSFW        bool   `json:"nsfw,omitempty"`
+	IsSelf      bool   `json:"is_self,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+}
+
+type HackerNewsData struct {
+	Keyword    string `json:"keyword"`
+	ItemID     int64  `json:"item_id"`
+	StoryID    int64  `json:"story_id"`
+	StoryTitle string `json:"story_title"`
+	Author     string `json:"author"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Points     int    `json:"points"`
+	URL        string `json:"url"`
+	Permalink  string `json:"permalink"`
+	IsComment  bool   `json:"is_comment"`
+}
+
+type MastodonData struct {
+	Keyword        string `json:"keyword"`
+	StatusID       string `json:"status_id"`
+	Instance       string `json:"instance"`
+	Account        string `json:"account"`
+	ContentWarning string `json:"content_warning,omitempty"`
+	Body           string `json:"body"`
+	Permalink      string `json:"permalink"`
+}
+
+type BlueskyData struct {
+	Keyword   string `json:"keyword"`
+	DID       string `json:"did"`
+	RKey      string `json:"rkey"`
+	Body      string `json:"body"`
+	Permalink string `json:"permalink"`
+	IsReply   bool   `json:"is_reply"`
+}
+
+type GitHubData struct {
+	Keyword   string   `json:"keyword"`
+	Repo      string   `json:"repo"`
+	Number    int      `json:"number"`
+	Type      string   `json:"type"` // issue, pull_request or discussion
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels"`
+	State     string   `json:"state"`
+	Permalink string   `json:"permalink"`
+	IsComment bool     `json:"is_comment"`
+}
+
+type StackExchangeData struct {
+	Keyword    string   `json:"keyword"`
+	Site       string   `json:"site"`
+	QuestionID int64    `json:"question_id"`
+	AnswerID   int64    `json:"answer_id,omitempty"`
+	Title      string   `json:"title"`
+	Body       string   `json:"body"`
+	Author     string   `json:"author"`
+	Tags       []string `json:"tags"`
+	Score      int      `json:"score"`
+	Permalink  string   `json:"permalink"`
+	IsAnswer   bool     `json:"is_answer"`
+}
+
+type IngestData struct {
+	Keyword   string         `json:"keyword"`
+	Source    string         `json:"source"`
+	ItemID    string         `json:"item_id"`
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Author    string         `json:"author"`
+	Permalink string         `json:"permalink"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+type FeedData struct {
+	Keyword   string `json:"keyword"`
+	FeedID    int    `json:"feed_id"`
+	FeedTitle string `json:"feed_title"`
+	Author    string `json:"author"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Permalink string `json:"permalink"`
+	IsComment bool   `json:"is_comment"`
 }
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE source_cursors (
+    source TEXT PRIMARY KEY,
+    cursor BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE source_cursors;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE feeds (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT true,
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    cursor BIGINT NOT NULL DEFAULT 0,
+    last_fetched_at TIMESTAMPTZ,
+    last_success_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_feeds_user_id ON feeds(user_id);
+CREATE UNIQUE INDEX idx_feeds_user_url ON feeds(user_id, url);
+
+-- +goose Down
+DROP TABLE feeds;
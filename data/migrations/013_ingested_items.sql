@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE ingested_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, source, external_id)
+);
+
+CREATE INDEX idx_ingested_items_created_at ON ingested_items(created_at);
+
+-- +goose Down
+DROP TABLE ingested_items;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE pending_matches (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    keyword_id INT NOT NULL REFERENCES keywords(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    post_id TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}'::jsonb,
+    min_score INT NOT NULL DEFAULT 0,
+    min_comments INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    next_check_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_pending_matches_hash ON pending_matches(hash);
+CREATE INDEX idx_pending_matches_next_check_at ON pending_matches(next_check_at);
+CREATE INDEX idx_pending_matches_expires_at ON pending_matches(expires_at);
+
+-- +goose Down
+DROP TABLE pending_matches;
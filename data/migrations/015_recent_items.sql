@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE recent_items (
+    id BIGSERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    item_id TEXT NOT NULL,
+    owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_recent_items_item ON recent_items(source, kind, item_id, COALESCE(owner_id, '00000000-0000-0000-0000-000000000000'::uuid));
+CREATE INDEX idx_recent_items_created_at ON recent_items(created_at);
+CREATE INDEX idx_recent_items_text_trgm ON recent_items USING gin (lower(title || E'\n' || body) gin_trgm_ops);
+
+-- +goose Down
+DROP TABLE recent_items;
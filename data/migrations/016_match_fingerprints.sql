@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE matches ADD COLUMN fingerprint BIGINT;
+ALTER TABLE matches ADD COLUMN also_posted_in JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+CREATE INDEX idx_matches_user_created_at ON matches(user_id, created_at) WHERE fingerprint IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_matches_user_created_at;
+ALTER TABLE matches DROP COLUMN also_posted_in;
+ALTER TABLE matches DROP COLUMN fingerprint;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE matches ADD COLUMN notify_claimed_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE matches DROP COLUMN notify_claimed_at;
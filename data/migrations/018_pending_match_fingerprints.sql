@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE pending_matches ADD COLUMN fingerprint BIGINT;
+
+-- +goose Down
+ALTER TABLE pending_matches DROP COLUMN fingerprint;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE matches ADD COLUMN location_permalink TEXT NOT NULL DEFAULT '';
+
+UPDATE matches
+SET location_permalink = data->>'permalink'
+WHERE fingerprint IS NOT NULL AND data->>'permalink' IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE matches DROP COLUMN location_permalink;
@@ -30,6 +30,16 @@ type MatchWithKeyword struct {
 	Keyword string `db:"keyword"`
 }
 
+// MatchFingerprint is the part of a recent match needed to find near-duplicates of new matches.
+type MatchFingerprint struct {
+	ID          int       `db:"id"`
+	UserID      uuid.UUID `db:"user_id"`
+	KeywordID   int       `db:"keyword_id"`
+	Fingerprint int64     `db:"fingerprint"`
+	Permalink   string    `db:"location_permalink"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
 type MatchedSubredditSummary struct {
 	Subreddit     string    `db:"subreddit"`
 	LastMatchedAt time.Time `db:"last_matched_at"`
@@ -0,0 +1,152 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/kova98/feedgrep.api/data"
+)
+
+const feedColumns = `id, user_id, url, title, active, etag, last_modified, cursor, last_fetched_at, last_success_at,
+		       last_error, consecutive_failures, created_at, updated_at`
+
+type FeedRepo struct {
+	db *sqlx.DB
+}
+
+func NewFeedRepo(db *sqlx.DB) *FeedRepo {
+	return &FeedRepo{db: db}
+}
+
+func (r *FeedRepo) CreateFeed(f data.Feed) (int, error) {
+	query := `
+		INSERT INTO feeds (user_id, url, active)
+		VALUES ($1, $2, true)
+		ON CONFLICT (user_id, url) DO UPDATE
+		    SET active = true,
+		        updated_at = now()
+		RETURNING id`
+
+	var id int
+	if err := r.db.Get(&id, query, f.UserID, f.URL); err != nil {
+		return 0, fmt.Errorf("create feed: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *FeedRepo) CountFeedsByUserID(userID uuid.UUID) (int, error) {
+	var count int
+	if err := r.db.Get(&count, `SELECT COUNT(*) FROM feeds WHERE user_id = $1`, userID); err != nil {
+		return 0, fmt.Errorf("count feeds: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *FeedRepo) GetFeedsByUserID(userID uuid.UUID) ([]data.Feed, error) {
+	var feeds []data.Feed
+	query := `
+		SELECT ` + feedColumns + `
+		FROM feeds
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	if err := r.db.Select(&feeds, query, userID); err != nil {
+		return nil, fmt.Errorf("get feeds by user id: %w", err)
+	}
+
+	return feeds, nil
+}
+
+func (r *FeedRepo) GetFeedByID(id int, userID uuid.UUID) (*data.Feed, error) {
+	var feed data.Feed
+	query := `
+		SELECT ` + feedColumns + `
+		FROM feeds
+		WHERE id = $1 AND user_id = $2`
+
+	err := r.db.Get(&feed, query, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get feed by id: %w", err)
+	}
+
+	return &feed, nil
+}
+
+func (r *FeedRepo) GetActiveFeeds() ([]data.Feed, error) {
+	var feeds []data.Feed
+	query := `
+		SELECT ` + feedColumns + `
+		FROM feeds
+		WHERE active = true
+		ORDER BY last_fetched_at ASC NULLS FIRST`
+
+	if err := r.db.Select(&feeds, query); err != nil {
+		return nil, fmt.Errorf("get active feeds: %w", err)
+	}
+
+	return feeds, nil
+}
+
+// UpdateFeed changes the user-editable fields. Changing the URL resets the fetch state.
+func (r *FeedRepo) UpdateFeed(f data.Feed) (bool, error) {
+	query := `
+		UPDATE feeds
+		SET active = $3,
+		    etag = CASE WHEN url = $4 THEN etag ELSE '' END,
+		    last_modified = CASE WHEN url = $4 THEN last_modified ELSE '' END,
+		    cursor = CASE WHEN url = $4 THEN cursor ELSE 0 END,
+		    last_error = CASE WHEN url = $4 THEN last_error ELSE '' END,
+		    consecutive_failures = CASE WHEN url = $4 THEN consecutive_failures ELSE 0 END,
+		    url = $4,
+		    updated_at = now()
+		WHERE id = $1 AND user_id = $2`
+
+	result, err := r.db.Exec(query, f.ID, f.UserID, f.Active, f.URL)
+	if err != nil {
+		return false, fmt.Errorf("update feed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected for feed update: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UpdateFetchState stores the conditional GET validators, cursor and health of a feed after a fetch.
+func (r *FeedRepo) UpdateFetchState(f data.Feed) error {
+	query := `
+		UPDATE feeds
+		SET title = :title,
+		    etag = :etag,
+		    last_modified = :last_modified,
+		    cursor = :cursor,
+		    last_fetched_at = :last_fetched_at,
+		    last_success_at = :last_success_at,
+		    last_error = :last_error,
+		    consecutive_failures = :consecutive_failures
+		WHERE id = :id`
+
+	if _, err := r.db.NamedExec(query, f); err != nil {
+		return fmt.Errorf("update feed fetch state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FeedRepo) DeleteFeed(id int, userID uuid.UUID) error {
+	if _, err := r.db.Exec(`DELETE FROM feeds WHERE id = $1 AND user_id = $2`, id, userID); err != nil {
+		return fmt.Errorf("delete feed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repos
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IngestRepo struct {
+	db *sqlx.DB
+}
+
+func NewIngestRepo(db *sqlx.DB) *IngestRepo {
+	return &IngestRepo{db: db}
+}
+
+// GetIngestedItems returns the external IDs of a source that were already ingested.
+func (r *IngestRepo) GetIngestedItems(userID uuid.UUID, source string, externalIDs []string) ([]string, error) {
+	query := `
+		SELECT external_id
+		FROM ingested_items
+		WHERE user_id = $1 AND source = $2 AND external_id = ANY($3)`
+
+	var ingested []string
+	if err := r.db.Select(&ingested, query, userID, source, pq.Array(externalIDs)); err != nil {
+		return nil, fmt.Errorf("get ingested items: %w", err)
+	}
+
+	return ingested, nil
+}
+
+// InsertItems records the external IDs of a source as ingested.
+func (r *IngestRepo) InsertItems(userID uuid.UUID, source string, externalIDs []string) error {
+	query := `
+		INSERT INTO ingested_items (user_id, source, external_id)
+		SELECT $1, $2, unnest($3::text[])
+		ON CONFLICT (user_id, source, external_id) DO NOTHING`
+
+	if _, err := r.db.Exec(query, userID, source, pq.Array(externalIDs)); err != nil {
+		return fmt.Errorf("insert ingested items: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AdvisoryLockRepo struct {
+	db *sqlx.DB
+}
+
+func NewAdvisoryLockRepo(db *sqlx.DB) *AdvisoryLockRepo {
+	return &AdvisoryLockRepo{db: db}
+}
+
+// AdvisoryLock is a session-level Postgres advisory lock. The lock lives as long as the
+// dedicated connection holding it, so Postgres releases it if the process dies.
+type AdvisoryLock struct {
+	conn *sqlx.Conn
+	key  int64
+}
+
+// TryLock attempts to take the advisory lock identified by key without blocking.
+// It returns nil when the lock is held by another session.
+func (r *AdvisoryLockRepo) TryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open lock connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock($1)`, key); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("try advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Held verifies that the session still holds the lock.
+func (l *AdvisoryLock) Held(ctx context.Context) error {
+	var held bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_locks
+			WHERE locktype = 'advisory'
+			  AND pid = pg_backend_pid()
+			  AND granted
+			  AND objsubid = 1
+			  AND ((classid::bigint << 32) | objid::bigint) = $1
+		)`
+	if err := l.conn.GetContext(ctx, &held, query, l.key); err != nil {
+		return fmt.Errorf("check advisory lock: %w", err)
+	}
+	if !held {
+		return fmt.Errorf("check advisory lock: lock %d no longer held", l.key)
+	}
+	return nil
+}
+
+// Release unlocks and closes the connection. Closing the connection releases the lock
+// even when the unlock fails.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	_, unlockErr := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	closeErr := l.conn.Close()
+	if unlockErr != nil {
+		return fmt.Errorf("release advisory lock: %w", unlockErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("close lock connection: %w", closeErr)
+	}
+	return nil
+}
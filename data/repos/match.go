@@ -1,15 +1,21 @@
 package repos
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/kova98/feedgrep.api/data"
 	"github.com/kova98/feedgrep.api/enums"
+	"github.com/lib/pq"
 )
 
+// maxAlsoPostedIn caps how many other locations are recorded on a single match.
+const maxAlsoPostedIn = 20
+
 type MatchRepo struct {
 	db *sqlx.DB
 }
@@ -18,40 +24,142 @@ func NewMatchRepo(db *sqlx.DB) *MatchRepo {
 	return &MatchRepo{db}
 }
 
-func (r *MatchRepo) CreateMatches(matches []data.Match) error {
+// CreateMatches stores the matches, skipping ones that were already stored, and returns how many
+// were created.
+func (r *MatchRepo) CreateMatches(matches []data.Match) (int64, error) {
 	if len(matches) == 0 {
-		return nil
+		return 0, nil
+	}
+
+	rows, err := matchRows(matches)
+	if err != nil {
+		return 0, err
 	}
 
 	query := `
-		INSERT INTO matches (user_id, keyword_id, source, hash, data, created_at, seen_at)
-		VALUES (:user_id, :keyword_id, :source, :hash, :data, now(), NULL)
+		INSERT INTO matches (user_id, keyword_id, source, hash, data, fingerprint, also_posted_in, location_permalink, created_at, seen_at)
+		VALUES (:user_id, :keyword_id, :source, :hash, :data, :fingerprint, :also_posted_in, :location_permalink, now(), NULL)
 		ON CONFLICT (hash) DO NOTHING`
 
-	_, err := r.db.NamedExec(query, matches)
+	res, err := r.db.NamedExec(query, rows)
 	if err != nil {
-		return fmt.Errorf("create matches: %w", err)
+		return 0, fmt.Errorf("create matches: %w", err)
 	}
 
-	return nil
+	created, _ := res.RowsAffected()
+	return created, nil
 }
 
-func (r *MatchRepo) GetUnnotifiedMatches() ([]data.Match, error) {
+// matchRows returns copies of the matches prepared for storage, with AlsoPostedIn encoded and
+// the permalink of their location set.
+func matchRows(matches []data.Match) ([]data.Match, error) {
+	rows := make([]data.Match, len(matches))
+	for i, match := range matches {
+		alsoPostedIn := match.AlsoPostedIn
+		if alsoPostedIn == nil {
+			alsoPostedIn = []data.MatchLocation{}
+		}
+		raw, err := json.Marshal(alsoPostedIn)
+		if err != nil {
+			return nil, fmt.Errorf("marshal also posted in: %w", err)
+		}
+		match.AlsoPostedInRaw = raw
+		match.LocationPermalink = match.Location.Permalink
+		rows[i] = match
+	}
+	return rows, nil
+}
+
+// ClaimUnnotifiedMatches claims the matches that have not been notified yet and returns them
+// oldest first. Claimed matches are skipped by other callers until they are notified, released
+// or the claim is older than lease, so a match is never sent twice.
+func (r *MatchRepo) ClaimUnnotifiedMatches(lease time.Duration) ([]data.Match, error) {
 	var matches []data.Match
 	query := `
-		SELECT id, user_id, keyword_id, source, hash, notified_at, seen_at, data, created_at
-		FROM matches
-		WHERE notified_at IS NULL
-		ORDER BY created_at ASC`
+		UPDATE matches
+		SET notify_claimed_at = now()
+		WHERE id IN (
+			SELECT id
+			FROM matches
+			WHERE notified_at IS NULL
+			  AND (notify_claimed_at IS NULL OR notify_claimed_at < now() - $1 * interval '1 second')
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, keyword_id, source, hash, notified_at, seen_at, data, also_posted_in, created_at`
 
-	err := r.db.Select(&matches, query)
+	err := r.db.Select(&matches, query, lease.Seconds())
 	if err != nil {
-		return nil, fmt.Errorf("get unnotified matches: %w", err)
+		return nil, fmt.Errorf("claim unnotified matches: %w", err)
 	}
+	slices.SortFunc(matches, func(a, b data.Match) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
 
 	return matches, nil
 }
 
+// ReleaseNotifyClaims releases the claims on matches that could not be notified, so they are
+// claimed again on the next run.
+func (r *MatchRepo) ReleaseNotifyClaims(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := r.db.Exec(`UPDATE matches SET notify_claimed_at = NULL WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("release notify claims: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecentFingerprints returns the fingerprinted matches of the users created after since. Matches
+// stored without a location or keyword are left out, since they cannot be told apart from the item
+// being matched.
+func (r *MatchRepo) GetRecentFingerprints(userIDs []uuid.UUID, since time.Time) ([]data.MatchFingerprint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var fingerprints []data.MatchFingerprint
+	query := `
+		SELECT id, user_id, keyword_id, fingerprint, location_permalink, created_at
+		FROM matches
+		WHERE user_id = ANY($1) AND created_at > $2 AND fingerprint IS NOT NULL
+		  AND keyword_id IS NOT NULL AND location_permalink <> ''`
+
+	if err := r.db.Select(&fingerprints, query, pq.Array(userIDs), since); err != nil {
+		return nil, fmt.Errorf("get recent fingerprints: %w", err)
+	}
+
+	return fingerprints, nil
+}
+
+// AddAlsoPostedIn records another location of a match, unless it is already recorded or the
+// match has reached the limit.
+func (r *MatchRepo) AddAlsoPostedIn(id int, location data.MatchLocation) error {
+	raw, err := json.Marshal([]data.MatchLocation{location})
+	if err != nil {
+		return fmt.Errorf("marshal also posted in: %w", err)
+	}
+	permalink, err := json.Marshal([]map[string]string{{"permalink": location.Permalink}})
+	if err != nil {
+		return fmt.Errorf("marshal also posted in: %w", err)
+	}
+
+	query := `
+		UPDATE matches
+		SET also_posted_in = also_posted_in || $2::jsonb
+		WHERE id = $1
+		  AND NOT also_posted_in @> $3::jsonb
+		  AND jsonb_array_length(also_posted_in) < $4`
+
+	if _, err := r.db.Exec(query, id, raw, permalink, maxAlsoPostedIn); err != nil {
+		return fmt.Errorf("add also posted in: %w", err)
+	}
+
+	return nil
+}
+
 func (r *MatchRepo) MarkNotified(ids []int64, notifiedAt time.Time) error {
 	if len(ids) == 0 {
 		return nil
@@ -74,7 +182,7 @@ func (r *MatchRepo) MarkNotified(ids []int64, notifiedAt time.Time) error {
 func (r *MatchRepo) GetMatchesByUserID(userID uuid.UUID, limit, offset int) ([]data.MatchWithKeyword, int, error) {
 	var matches []data.MatchWithKeyword
 	query := `
-		SELECT m.id, m.user_id, m.keyword_id, m.source, m.hash, m.notified_at, m.seen_at, m.data, m.created_at,
+		SELECT m.id, m.user_id, m.keyword_id, m.source, m.hash, m.notified_at, m.seen_at, m.data, m.also_posted_in, m.created_at,
 		       k.keyword
 		FROM matches m
 		LEFT JOIN keywords k ON k.id = m.keyword_id
@@ -100,7 +208,7 @@ func (r *MatchRepo) GetMatchesByUserID(userID uuid.UUID, limit, offset int) ([]d
 func (r *MatchRepo) GetMatchesByKeyword(userID uuid.UUID, keywordID, limit int, unseenOnly bool) ([]data.MatchWithKeyword, error) {
 	var matches []data.MatchWithKeyword
 	query := `
-		SELECT m.id, m.user_id, m.keyword_id, m.source, m.hash, m.notified_at, m.seen_at, m.data, m.created_at,
+		SELECT m.id, m.user_id, m.keyword_id, m.source, m.hash, m.notified_at, m.seen_at, m.data, m.also_posted_in, m.created_at,
 		       k.keyword
 		FROM matches m
 		LEFT JOIN keywords k ON k.id = m.keyword_id
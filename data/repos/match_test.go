@@ -0,0 +1,88 @@
+package repos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchRepoDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewMatchRepo(db)
+
+	createMatch := func(t *testing.T, userID uuid.UUID, keywordID int, permalink string) data.Match {
+		t.Helper()
+		match, err := data.NewMatch(userID, keywordID, enums.SourceArcticShift, uuid.NewString(), data.RedditData{Keyword: "golang", Permalink: permalink})
+		require.NoError(t, err)
+		fingerprint := int64(42)
+		match.Fingerprint = &fingerprint
+		match.Location = data.MatchLocation{Source: enums.SourceArcticShift, Permalink: permalink}
+		created, err := repo.CreateMatches([]data.Match{match})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), created)
+		require.NoError(t, db.Get(&match.ID, `SELECT id FROM matches WHERE hash = $1`, match.Hash))
+		return match
+	}
+	alsoPostedIn := func(t *testing.T, id int) []data.MatchLocation {
+		t.Helper()
+		var match data.Match
+		require.NoError(t, db.Get(&match, `SELECT id, also_posted_in FROM matches WHERE id = $1`, id))
+		locations, err := match.ParseAlsoPostedIn()
+		require.NoError(t, err)
+		return locations
+	}
+
+	t.Run("it returns the recent fingerprints of the users", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		match := createMatch(t, userID, keywordID, "/r/golang/comments/a/")
+		otherID, otherKeywordID := newTestKeyword(t, db)
+		createMatch(t, otherID, otherKeywordID, "/r/golang/comments/b/")
+
+		fingerprints, err := repo.GetRecentFingerprints([]uuid.UUID{userID}, time.Now().Add(-time.Hour))
+
+		require.NoError(t, err)
+		require.Len(t, fingerprints, 1)
+		assert.Equal(t, match.ID, fingerprints[0].ID)
+		assert.Equal(t, int64(42), fingerprints[0].Fingerprint)
+		assert.Equal(t, keywordID, fingerprints[0].KeywordID)
+		assert.Equal(t, "/r/golang/comments/a/", fingerprints[0].Permalink)
+	})
+
+	t.Run("it leaves out matches stored without a location", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		createMatch(t, userID, keywordID, "")
+
+		fingerprints, err := repo.GetRecentFingerprints([]uuid.UUID{userID}, time.Now().Add(-time.Hour))
+
+		require.NoError(t, err)
+		assert.Empty(t, fingerprints)
+	})
+
+	t.Run("it records each other location once", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		match := createMatch(t, userID, keywordID, "/r/golang/comments/a/")
+		location := data.MatchLocation{Source: enums.SourceArcticShift, Subreddit: "programming", Permalink: "/r/programming/comments/a/"}
+
+		require.NoError(t, repo.AddAlsoPostedIn(match.ID, location))
+		require.NoError(t, repo.AddAlsoPostedIn(match.ID, location))
+
+		assert.Equal(t, []data.MatchLocation{location}, alsoPostedIn(t, match.ID))
+	})
+
+	t.Run("it stops recording locations at the limit", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		match := createMatch(t, userID, keywordID, "/r/golang/comments/a/")
+
+		for range maxAlsoPostedIn + 1 {
+			location := data.MatchLocation{Source: enums.SourceArcticShift, Permalink: uuid.NewString()}
+			require.NoError(t, repo.AddAlsoPostedIn(match.ID, location))
+		}
+
+		assert.Len(t, alsoPostedIn(t, match.ID), maxAlsoPostedIn)
+	})
+}
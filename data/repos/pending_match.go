@@ -0,0 +1,111 @@
+package repos
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/lib/pq"
+)
+
+type PendingMatchRepo struct {
+	db *sqlx.DB
+}
+
+func NewPendingMatchRepo(db *sqlx.DB) *PendingMatchRepo {
+	return &PendingMatchRepo{db: db}
+}
+
+func (r *PendingMatchRepo) CreatePendingMatches(pending []data.PendingMatch) error {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO pending_matches (user_id, keyword_id, source, hash, post_id, data, min_score, min_comments, expires_at, next_check_at, fingerprint)
+		VALUES (:user_id, :keyword_id, :source, :hash, :post_id, :data, :min_score, :min_comments, :expires_at, :next_check_at, :fingerprint)
+		ON CONFLICT (hash) DO NOTHING`
+
+	if _, err := r.db.NamedExec(query, pending); err != nil {
+		return fmt.Errorf("create pending matches: %w", err)
+	}
+
+	return nil
+}
+
+// GetDuePendingMatches returns pending matches that have not expired and are due for a re-check.
+func (r *PendingMatchRepo) GetDuePendingMatches(now time.Time, limit int) ([]data.PendingMatch, error) {
+	var pending []data.PendingMatch
+	query := `
+		SELECT id, user_id, keyword_id, source, hash, post_id, data, min_score, min_comments, expires_at, next_check_at, created_at, fingerprint
+		FROM pending_matches
+		WHERE next_check_at <= $1 AND expires_at > $1
+		ORDER BY next_check_at ASC
+		LIMIT $2`
+
+	if err := r.db.Select(&pending, query, now, limit); err != nil {
+		return nil, fmt.Errorf("get due pending matches: %w", err)
+	}
+
+	return pending, nil
+}
+
+// RescheduleChecks moves the next check of the pending matches to next.
+func (r *PendingMatchRepo) RescheduleChecks(ids []int64, next time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `UPDATE pending_matches SET next_check_at = $1 WHERE id = ANY($2)`
+	if _, err := r.db.Exec(query, next, pq.Array(ids)); err != nil {
+		return fmt.Errorf("reschedule pending matches: %w", err)
+	}
+
+	return nil
+}
+
+// PromotePendingMatches stores the matches and removes the pending matches they were promoted from.
+func (r *PendingMatchRepo) PromotePendingMatches(matches []data.Match, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("promote pending matches: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO matches (user_id, keyword_id, source, hash, data, fingerprint, also_posted_in, location_permalink, created_at, seen_at)
+		VALUES (:user_id, :keyword_id, :source, :hash, :data, :fingerprint, :also_posted_in, :location_permalink, now(), NULL)
+		ON CONFLICT (hash) DO NOTHING`
+	if len(matches) > 0 {
+		rows, err := matchRows(matches)
+		if err != nil {
+			return fmt.Errorf("promote pending matches: %w", err)
+		}
+		if _, err := tx.NamedExec(query, rows); err != nil {
+			return fmt.Errorf("promote pending matches: insert: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM pending_matches WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("promote pending matches: delete: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("promote pending matches: commit: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredPendingMatches removes pending matches that never reached their threshold.
+func (r *PendingMatchRepo) DeleteExpiredPendingMatches(now time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM pending_matches WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired pending matches: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted, nil
+}
@@ -0,0 +1,142 @@
+package repos
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB connects to the database in TEST_DATABASE_URL and migrates it. Tests that need a
+// database are skipped when it is not set.
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, goose.SetDialect("postgres"))
+	require.NoError(t, goose.Up(db.DB, "../migrations"))
+	return db
+}
+
+// newTestKeyword creates a user with a keyword and returns their ids.
+func newTestKeyword(t *testing.T, db *sqlx.DB) (uuid.UUID, int) {
+	t.Helper()
+	userID := uuid.New()
+	_, err := db.Exec(`INSERT INTO users (id, email) VALUES ($1, $2)`, userID, userID.String()+"@example.com")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+
+	var keywordID int
+	require.NoError(t, db.Get(&keywordID, `INSERT INTO keywords (user_id, keyword) VALUES ($1, 'golang') RETURNING id`, userID))
+	return userID, keywordID
+}
+
+func TestPendingMatchRepo(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPendingMatchRepo(db)
+	now := time.Now().Truncate(time.Second)
+	fingerprint := int64(42)
+
+	newPending := func(userID uuid.UUID, keywordID int, hash string, nextCheckAt, expiresAt time.Time) data.PendingMatch {
+		return data.PendingMatch{
+			UserID:      userID,
+			KeywordID:   keywordID,
+			Source:      enums.SourceArcticShift,
+			Hash:        hash,
+			PostID:      hash,
+			DataRaw:     []byte(`{"keyword":"golang"}`),
+			MinScore:    100,
+			ExpiresAt:   expiresAt,
+			NextCheckAt: nextCheckAt,
+			Fingerprint: &fingerprint,
+		}
+	}
+
+	t.Run("it returns the due pending matches that have not expired", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		due := uuid.NewString()
+		require.NoError(t, repo.CreatePendingMatches([]data.PendingMatch{
+			newPending(userID, keywordID, due, now.Add(-time.Minute), now.Add(time.Hour)),
+			newPending(userID, keywordID, uuid.NewString(), now.Add(time.Minute), now.Add(time.Hour)),
+			newPending(userID, keywordID, uuid.NewString(), now.Add(-time.Minute), now.Add(-time.Second)),
+		}))
+
+		pending, err := repo.GetDuePendingMatches(now, 1000)
+
+		require.NoError(t, err)
+		hashes := make([]string, 0)
+		for _, pm := range pending {
+			if pm.UserID == userID {
+				hashes = append(hashes, pm.Hash)
+				assert.Equal(t, fingerprint, *pm.Fingerprint)
+			}
+		}
+		assert.Equal(t, []string{due}, hashes)
+	})
+
+	t.Run("it ignores a pending match that was already stored", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		pm := newPending(userID, keywordID, uuid.NewString(), now.Add(-time.Minute), now.Add(time.Hour))
+
+		require.NoError(t, repo.CreatePendingMatches([]data.PendingMatch{pm}))
+		require.NoError(t, repo.CreatePendingMatches([]data.PendingMatch{pm}))
+
+		var count int
+		require.NoError(t, db.Get(&count, `SELECT count(*) FROM pending_matches WHERE hash = $1`, pm.Hash))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("it promotes pending matches into matches", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		pm := newPending(userID, keywordID, uuid.NewString(), now.Add(-time.Minute), now.Add(time.Hour))
+		require.NoError(t, repo.CreatePendingMatches([]data.PendingMatch{pm}))
+		require.NoError(t, db.Get(&pm.ID, `SELECT id FROM pending_matches WHERE hash = $1`, pm.Hash))
+		match, err := data.NewMatch(userID, keywordID, pm.Source, pm.Hash, data.RedditData{Keyword: "golang"})
+		require.NoError(t, err)
+		match.Fingerprint = pm.Fingerprint
+
+		require.NoError(t, repo.PromotePendingMatches([]data.Match{match}, []int64{pm.ID}))
+
+		var stored data.Match
+		require.NoError(t, db.Get(&stored, `SELECT id, user_id, hash, fingerprint FROM matches WHERE hash = $1`, pm.Hash))
+		assert.Equal(t, fingerprint, *stored.Fingerprint)
+		var remaining int
+		require.NoError(t, db.Get(&remaining, `SELECT count(*) FROM pending_matches WHERE id = $1`, pm.ID))
+		assert.Zero(t, remaining)
+	})
+
+	t.Run("it reschedules and expires pending matches", func(t *testing.T) {
+		userID, keywordID := newTestKeyword(t, db)
+		waiting := newPending(userID, keywordID, uuid.NewString(), now.Add(-time.Minute), now.Add(time.Hour))
+		expired := newPending(userID, keywordID, uuid.NewString(), now.Add(-time.Minute), now.Add(-time.Minute))
+		require.NoError(t, repo.CreatePendingMatches([]data.PendingMatch{waiting, expired}))
+		require.NoError(t, db.Get(&waiting.ID, `SELECT id FROM pending_matches WHERE hash = $1`, waiting.Hash))
+
+		require.NoError(t, repo.RescheduleChecks([]int64{waiting.ID}, now.Add(time.Hour)))
+		deleted, err := repo.DeleteExpiredPendingMatches(now)
+
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(1))
+		var nextCheckAt time.Time
+		require.NoError(t, db.Get(&nextCheckAt, `SELECT next_check_at FROM pending_matches WHERE id = $1`, waiting.ID))
+		assert.True(t, nextCheckAt.Equal(now.Add(time.Hour)))
+		var remaining int
+		require.NoError(t, db.Get(&remaining, `SELECT count(*) FROM pending_matches WHERE hash = $1`, expired.Hash))
+		assert.Zero(t, remaining)
+	})
+}
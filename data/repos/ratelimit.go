@@ -58,3 +58,67 @@ func (r *RateLimitRepo) IncrementWithinLimit(userID uuid.UUID, rateID, windowKey
 
 	return count, true, nil
 }
+
+// RateIncrement is an amount to add to a single rate limit counter.
+type RateIncrement struct {
+	RateID string
+	N      int
+}
+
+// IncrementAllWithinLimit adds every increment to its counter unless one of them would take its
+// counter over limit, in which case none are applied and the rate ID of that counter is returned.
+func (r *RateLimitRepo) IncrementAllWithinLimit(userID uuid.UUID, windowKey string, increments []RateIncrement, limit int) (string, error) {
+	for _, inc := range increments {
+		if inc.N > limit {
+			return inc.RateID, nil
+		}
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return "", fmt.Errorf("increment rate limit counters: begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO rate_limits (user_id, rate_id, window_key, count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, now(), now())
+		ON CONFLICT (user_id, rate_id, window_key)
+		DO UPDATE
+		SET count = rate_limits.count + $4,
+		    updated_at = now()
+		WHERE rate_limits.count + $4 <= $5
+		RETURNING count`
+
+	for _, inc := range increments {
+		var count int
+		err := tx.Get(&count, query, userID, inc.RateID, windowKey, inc.N, limit)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return inc.RateID, nil
+			}
+			return "", fmt.Errorf("increment rate limit counter: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("increment rate limit counters: commit: %w", err)
+	}
+	return "", nil
+}
+
+// RefundAll takes increments that were applied by IncrementAllWithinLimit back off their counters.
+func (r *RateLimitRepo) RefundAll(userID uuid.UUID, windowKey string, increments []RateIncrement) error {
+	query := `
+		UPDATE rate_limits
+		SET count = GREATEST(count - $4, 0),
+		    updated_at = now()
+		WHERE user_id = $1 AND rate_id = $2 AND window_key = $3`
+
+	for _, inc := range increments {
+		if _, err := r.db.Exec(query, userID, inc.RateID, windowKey, inc.N); err != nil {
+			return fmt.Errorf("refund rate limit counter: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repos
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/lib/pq"
+)
+
+// recentItemInsertSize keeps a single insert well below the Postgres bind parameter limit.
+const recentItemInsertSize = 1000
+
+type RecentItemRepo struct {
+	db *sqlx.DB
+}
+
+func NewRecentItemRepo(db *sqlx.DB) *RecentItemRepo {
+	return &RecentItemRepo{db: db}
+}
+
+// CreateRecentItems stores the items, ignoring ones that are already in the buffer.
+func (r *RecentItemRepo) CreateRecentItems(items []data.RecentItem) error {
+	query := `
+		INSERT INTO recent_items (source, kind, item_id, owner_id, title, body, details)
+		VALUES (:source, :kind, :item_id, :owner_id, :title, :body, :details)
+		ON CONFLICT DO NOTHING`
+
+	for start := 0; start < len(items); start += recentItemInsertSize {
+		end := min(start+recentItemInsertSize, len(items))
+		if _, err := r.db.NamedExec(query, items[start:end]); err != nil {
+			return fmt.Errorf("create recent items: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetRecentItems returns the newest items stored after since that are visible to the user. When
+// phrases is not empty, only items whose title or body contains one of them are returned.
+func (r *RecentItemRepo) GetRecentItems(since time.Time, userID uuid.UUID, phrases []string, limit int) ([]data.RecentItem, error) {
+	patterns := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		patterns = append(patterns, "%"+escapeLike(strings.ToLower(phrase))+"%")
+	}
+
+	var items []data.RecentItem
+	query := `
+		SELECT id, source, kind, item_id, owner_id, title, body, details, created_at
+		FROM recent_items
+		WHERE created_at > $1
+			AND (owner_id IS NULL OR owner_id = $2)
+			AND (cardinality($3::text[]) = 0 OR lower(title || E'\n' || body) LIKE ANY($3))
+		ORDER BY created_at DESC
+		LIMIT $4`
+
+	if err := r.db.Select(&items, query, since, userID, pq.Array(patterns), limit); err != nil {
+		return nil, fmt.Errorf("get recent items: %w", err)
+	}
+
+	return items, nil
+}
+
+// DeleteRecentItemsBefore removes the items stored before cutoff.
+func (r *RecentItemRepo) DeleteRecentItemsBefore(cutoff time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM recent_items WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("delete recent items: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,52 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kova98/feedgrep.api/data"
+)
+
+type SourceCursorRepo struct {
+	db *sqlx.DB
+}
+
+func NewSourceCursorRepo(db *sqlx.DB) *SourceCursorRepo {
+	return &SourceCursorRepo{db: db}
+}
+
+func (r *SourceCursorRepo) GetCursor(source string) (*data.SourceCursor, error) {
+	var cursor data.SourceCursor
+	query := `
+		SELECT source, cursor, updated_at
+		FROM source_cursors
+		WHERE source = $1`
+
+	err := r.db.Get(&cursor, query, source)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get source cursor: %w", err)
+	}
+
+	return &cursor, nil
+}
+
+func (r *SourceCursorRepo) SaveCursor(source string, cursor int64) error {
+	query := `
+		INSERT INTO source_cursors (source, cursor, updated_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (source)
+		DO UPDATE
+		SET cursor = EXCLUDED.cursor,
+		    updated_at = now()`
+
+	if _, err := r.db.Exec(query, source, cursor); err != nil {
+		return fmt.Errorf("save source cursor: %w", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,46 @@
 
 SELECT pg_catalog.set_config('search_path', '', false);
 
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
+CREATE TABLE public.auth_action_tokens (
+    id uuid NOT NULL,
+    user_id text NOT NULL,
+    email text NOT NULL,
+    action text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE TABLE public.feeds (
+    id integer NOT NULL,
+    user_id uuid NOT NULL,
+    url text NOT NULL,
+    title text DEFAULT ''::text NOT NULL,
+    active boolean DEFAULT true NOT NULL,
+    etag text DEFAULT ''::text NOT NULL,
+    last_modified text DEFAULT ''::text NOT NULL,
+    cursor bigint DEFAULT 0 NOT NULL,
+    last_fetched_at timestamp with time zone,
+    last_success_at timestamp with time zone,
+    last_error text DEFAULT ''::text NOT NULL,
+    consecutive_failures integer DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE SEQUENCE public.feeds_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.feeds_id_seq OWNED BY public.feeds.id;
+
 CREATE TABLE public.goose_db_version (
     id integer NOT NULL,
     version_id bigint NOT NULL,
@@ -19,6 +59,13 @@ ALTER TABLE public.goose_db_version ALTER COLUMN id ADD GENERATED BY DEFAULT AS
     CACHE 1
 );
 
+CREATE TABLE public.ingested_items (
+    user_id uuid NOT NULL,
+    source text NOT NULL,
+    external_id text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
 CREATE TABLE public.keywords (
     id integer NOT NULL,
     user_id uuid NOT NULL,
@@ -49,7 +96,11 @@ CREATE TABLE public.matches (
     notified_at timestamp with time zone,
     data jsonb DEFAULT '{}'::jsonb NOT NULL,
     created_at timestamp with time zone DEFAULT now() NOT NULL,
-    seen_at timestamp with time zone
+    seen_at timestamp with time zone,
+    fingerprint bigint,
+    also_posted_in jsonb DEFAULT '[]'::jsonb NOT NULL,
+    notify_claimed_at timestamp with time zone,
+    location_permalink text DEFAULT ''::text NOT NULL
 );
 
 CREATE SEQUENCE public.matches_id_seq
@@ -61,6 +112,31 @@ CREATE SEQUENCE public.matches_id_seq
 
 ALTER SEQUENCE public.matches_id_seq OWNED BY public.matches.id;
 
+CREATE TABLE public.pending_matches (
+    id bigint NOT NULL,
+    user_id uuid NOT NULL,
+    keyword_id integer NOT NULL,
+    source text NOT NULL,
+    hash text NOT NULL,
+    post_id text NOT NULL,
+    data jsonb DEFAULT '{}'::jsonb NOT NULL,
+    min_score integer DEFAULT 0 NOT NULL,
+    min_comments integer DEFAULT 0 NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    next_check_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    fingerprint bigint
+);
+
+CREATE SEQUENCE public.pending_matches_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.pending_matches_id_seq OWNED BY public.pending_matches.id;
+
 CREATE TABLE public.rate_limits (
     user_id uuid NOT NULL,
     rate_id text NOT NULL,
@@ -70,6 +146,33 @@ CREATE TABLE public.rate_limits (
     updated_at timestamp with time zone DEFAULT now() NOT NULL
 );
 
+CREATE TABLE public.recent_items (
+    id bigint NOT NULL,
+    source text NOT NULL,
+    kind text NOT NULL,
+    item_id text NOT NULL,
+    owner_id uuid,
+    title text DEFAULT ''::text NOT NULL,
+    body text DEFAULT ''::text NOT NULL,
+    details jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE SEQUENCE public.recent_items_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.recent_items_id_seq OWNED BY public.recent_items.id;
+
+CREATE TABLE public.source_cursors (
+    source text NOT NULL,
+    cursor bigint NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
 CREATE TABLE public.users (
     id uuid NOT NULL,
     email text NOT NULL,
@@ -79,28 +182,63 @@ CREATE TABLE public.users (
     updated_at timestamp with time zone DEFAULT now() NOT NULL
 );
 
+ALTER TABLE ONLY public.feeds ALTER COLUMN id SET DEFAULT nextval('public.feeds_id_seq'::regclass);
+
 ALTER TABLE ONLY public.keywords ALTER COLUMN id SET DEFAULT nextval('public.keywords_id_seq'::regclass);
 
 ALTER TABLE ONLY public.matches ALTER COLUMN id SET DEFAULT nextval('public.matches_id_seq'::regclass);
 
+ALTER TABLE ONLY public.pending_matches ALTER COLUMN id SET DEFAULT nextval('public.pending_matches_id_seq'::regclass);
+
+ALTER TABLE ONLY public.recent_items ALTER COLUMN id SET DEFAULT nextval('public.recent_items_id_seq'::regclass);
+
+ALTER TABLE ONLY public.auth_action_tokens
+    ADD CONSTRAINT auth_action_tokens_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.auth_action_tokens
+    ADD CONSTRAINT auth_action_tokens_token_hash_key UNIQUE (token_hash);
+
+ALTER TABLE ONLY public.feeds
+    ADD CONSTRAINT feeds_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY public.goose_db_version
     ADD CONSTRAINT goose_db_version_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY public.ingested_items
+    ADD CONSTRAINT ingested_items_pkey PRIMARY KEY (user_id, source, external_id);
+
 ALTER TABLE ONLY public.keywords
     ADD CONSTRAINT keywords_pkey PRIMARY KEY (id);
 
 ALTER TABLE ONLY public.matches
     ADD CONSTRAINT matches_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY public.pending_matches
+    ADD CONSTRAINT pending_matches_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY public.rate_limits
     ADD CONSTRAINT rate_limits_pkey PRIMARY KEY (user_id, rate_id, window_key);
 
+ALTER TABLE ONLY public.recent_items
+    ADD CONSTRAINT recent_items_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.source_cursors
+    ADD CONSTRAINT source_cursors_pkey PRIMARY KEY (source);
+
 ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_email_key UNIQUE (email);
 
 ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+CREATE INDEX auth_action_tokens_lookup_idx ON public.auth_action_tokens USING btree (action, token_hash) WHERE (used_at IS NULL);
+
+CREATE INDEX idx_feeds_user_id ON public.feeds USING btree (user_id);
+
+CREATE UNIQUE INDEX idx_feeds_user_url ON public.feeds USING btree (user_id, url);
+
+CREATE INDEX idx_ingested_items_created_at ON public.ingested_items USING btree (created_at);
+
 CREATE INDEX idx_keywords_keyword_lower ON public.keywords USING btree (lower(keyword));
 
 CREATE INDEX idx_keywords_user_id ON public.keywords USING btree (user_id);
@@ -113,10 +251,30 @@ CREATE UNIQUE INDEX idx_matches_hash ON public.matches USING btree (hash);
 
 CREATE INDEX idx_matches_source ON public.matches USING btree (source);
 
+CREATE INDEX idx_matches_user_created_at ON public.matches USING btree (user_id, created_at) WHERE (fingerprint IS NOT NULL);
+
 CREATE INDEX idx_matches_user_notified_at ON public.matches USING btree (user_id, notified_at);
 
+CREATE INDEX idx_pending_matches_expires_at ON public.pending_matches USING btree (expires_at);
+
+CREATE UNIQUE INDEX idx_pending_matches_hash ON public.pending_matches USING btree (hash);
+
+CREATE INDEX idx_pending_matches_next_check_at ON public.pending_matches USING btree (next_check_at);
+
 CREATE INDEX idx_rate_limits_lookup ON public.rate_limits USING btree (user_id, rate_id, window_key);
 
+CREATE INDEX idx_recent_items_created_at ON public.recent_items USING btree (created_at);
+
+CREATE UNIQUE INDEX idx_recent_items_item ON public.recent_items USING btree (source, kind, item_id, COALESCE(owner_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
+CREATE INDEX idx_recent_items_text_trgm ON public.recent_items USING gin (lower(((title || E'\n'::text) || body)) public.gin_trgm_ops);
+
+ALTER TABLE ONLY public.feeds
+    ADD CONSTRAINT feeds_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.ingested_items
+    ADD CONSTRAINT ingested_items_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY public.keywords
     ADD CONSTRAINT keywords_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
@@ -126,8 +284,17 @@ ALTER TABLE ONLY public.matches
 ALTER TABLE ONLY public.matches
     ADD CONSTRAINT matches_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY public.pending_matches
+    ADD CONSTRAINT pending_matches_keyword_id_fkey FOREIGN KEY (keyword_id) REFERENCES public.keywords(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY public.pending_matches
+    ADD CONSTRAINT pending_matches_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY public.rate_limits
     ADD CONSTRAINT rate_limits_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY public.recent_items
+    ADD CONSTRAINT recent_items_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
 \unrestrict yQx7eKF69kgOlsd3shlthPaf1kDYYgSYp7D0b1XmFrUcLudecBSBDSj6oSwt1IS
 
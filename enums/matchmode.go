@@ -16,4 +16,9 @@ const (
 	// MatchModeSmart applies a deterministic smart configuration made of
 	// candidate conditions, weighted signals, and an acceptance threshold.
 	MatchModeSmart MatchMode = "smart"
+
+	// MatchModeQuery parses the keyword as a boolean query of words, quoted phrases and
+	// wildcards combined with AND, OR, NOT and parentheses, optionally restricted to a field.
+	// For example, `title:crm AND (recommend* OR "looking for") NOT hiring`.
+	MatchModeQuery MatchMode = "query"
 )
@@ -3,6 +3,13 @@ package enums
 type Source string
 
 const (
-	SourceReddit      Source = "reddit"
-	SourceArcticShift Source = "arcticshift"
+	SourceReddit        Source = "reddit"
+	SourceArcticShift   Source = "arcticshift"
+	SourceHackerNews    Source = "hackernews"
+	SourceFeed          Source = "feed"
+	SourceMastodon      Source = "mastodon"
+	SourceBluesky       Source = "bluesky"
+	SourceGitHub        Source = "github"
+	SourceStackExchange Source = "stackexchange"
+	SourceIngest        Source = "ingest"
 )
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/sources"
+)
+
+type DiagnosticsHandler struct {
+	arcticShift *sources.ArcticShiftPoller
+}
+
+func NewDiagnosticsHandler(arcticShift *sources.ArcticShiftPoller) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		arcticShift: arcticShift,
+	}
+}
+
+func (h *DiagnosticsHandler) GetDiagnostics(w http.ResponseWriter, r *http.Request) Result {
+	return Ok(models.DiagnosticsResponse{
+		PollLoops: h.arcticShift.Diagnostics(),
+	})
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/sources"
+)
+
+const (
+	maxFeedsPerUser = 50
+
+	feedStatusPending = "pending"
+	feedStatusHealthy = "healthy"
+	feedStatusFailing = "failing"
+)
+
+type FeedHandler struct {
+	repo *repos.FeedRepo
+}
+
+func NewFeedHandler(repo *repos.FeedRepo) *FeedHandler {
+	return &FeedHandler{repo}
+}
+
+func (h *FeedHandler) CreateFeed(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	var req models.CreateFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest("Invalid request.")
+	}
+
+	feedURL, ok := normalizeHTTPURL(req.URL)
+	if !ok {
+		return BadRequest("Feed URL must be a valid http or https URL.")
+	}
+	if isPrivateHost(feedURL) {
+		return BadRequest("Feed URL must point to a public address.")
+	}
+
+	count, err := h.repo.CountFeedsByUserID(user.ID)
+	if err != nil {
+		return InternalError(err, "count feeds: ")
+	}
+	if count >= maxFeedsPerUser {
+		return BadRequest("You have reached the maximum number of feeds.")
+	}
+
+	id, err := h.repo.CreateFeed(data.Feed{UserID: user.ID, URL: feedURL})
+	if err != nil {
+		return InternalError(err, "create feed: ")
+	}
+
+	return Created(id)
+}
+
+func (h *FeedHandler) GetFeeds(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	feeds, err := h.repo.GetFeedsByUserID(user.ID)
+	if err != nil {
+		return InternalError(err, "get feeds: ")
+	}
+
+	res := models.GetFeedsResponse{Feeds: make([]models.Feed, 0, len(feeds))}
+	for _, feed := range feeds {
+		res.Feeds = append(res.Feeds, toFeedModel(feed))
+	}
+
+	return Ok(res)
+}
+
+func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return BadRequest("Invalid feed ID.")
+	}
+
+	feed, err := h.repo.GetFeedByID(id, user.ID)
+	if err != nil {
+		return InternalError(err, "get feed: ")
+	}
+	if feed == nil {
+		return NotFound("Feed not found.")
+	}
+
+	return Ok(toFeedModel(*feed))
+}
+
+func (h *FeedHandler) UpdateFeed(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return BadRequest("Invalid feed ID.")
+	}
+
+	var req models.UpdateFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest("Invalid request.")
+	}
+
+	feedURL, ok := normalizeHTTPURL(req.URL)
+	if !ok {
+		return BadRequest("Feed URL must be a valid http or https URL.")
+	}
+	if isPrivateHost(feedURL) {
+		return BadRequest("Feed URL must point to a public address.")
+	}
+
+	found, err := h.repo.UpdateFeed(data.Feed{
+		ID:     id,
+		UserID: user.ID,
+		URL:    feedURL,
+		Active: req.Active,
+	})
+	if err != nil {
+		return InternalError(err, "update feed: ")
+	}
+	if !found {
+		return NotFound("Feed not found.")
+	}
+
+	return Ok(nil)
+}
+
+func (h *FeedHandler) DeleteFeed(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return BadRequest("Invalid feed ID.")
+	}
+
+	if err := h.repo.DeleteFeed(id, user.ID); err != nil {
+		return InternalError(err, "delete feed: ")
+	}
+
+	return Ok(nil)
+}
+
+func normalizeHTTPURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > 2048 {
+		return "", false
+	}
+
+	parsed, err := neturl.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", false
+	}
+	if parsed.Host == "" {
+		return "", false
+	}
+
+	return parsed.String(), true
+}
+
+// isPrivateHost reports whether the URL names a local host or an address that is not publicly
+// routable. Host names are resolved when the feed is fetched, where the address is checked again.
+func isPrivateHost(rawURL string) bool {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !sources.IsPublicAddr(addr)
+}
+
+func toFeedModel(feed data.Feed) models.Feed {
+	status := feedStatusHealthy
+	switch {
+	case feed.LastFetchedAt == nil:
+		status = feedStatusPending
+	case feed.ConsecutiveFailures > 0:
+		status = feedStatusFailing
+	}
+
+	return models.Feed{
+		ID:                  feed.ID,
+		URL:                 feed.URL,
+		Title:               feed.Title,
+		Active:              feed.Active,
+		Status:              status,
+		LastFetchedAt:       feed.LastFetchedAt,
+		LastSuccessAt:       feed.LastSuccessAt,
+		LastError:           feed.LastError,
+		ConsecutiveFailures: feed.ConsecutiveFailures,
+		CreatedAt:           feed.CreatedAt,
+	}
+}
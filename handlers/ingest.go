@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/kova98/feedgrep.api/sources"
+)
+
+const (
+	maxIngestBodyBytes     = 5 << 20
+	maxIngestLineBytes     = 1 << 20
+	maxIngestBatchItems    = 1000
+	maxIngestIDLength      = 256
+	maxIngestTitleLength   = 1000
+	maxIngestTextLength    = 40000
+	maxIngestAuthorLength  = 256
+	maxIngestMetadataBytes = 16 << 10
+	ingestMonitorSource    = "ingest"
+)
+
+var ingestSourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+type ingestPipeline interface {
+	Match(items []sources.Item, observe sources.EvaluationObserver) sources.MatchSet
+	Persist(set sources.MatchSet) error
+}
+
+type ingestRepo interface {
+	GetIngestedItems(userID uuid.UUID, source string, externalIDs []string) ([]string, error)
+	InsertItems(userID uuid.UUID, source string, externalIDs []string) error
+}
+
+type ingestRateLimitRepo interface {
+	IncrementAllWithinLimit(userID uuid.UUID, windowKey string, increments []repos.RateIncrement, limit int) (string, error)
+	RefundAll(userID uuid.UUID, windowKey string, increments []repos.RateIncrement) error
+}
+
+type IngestHandler struct {
+	pipeline      ingestPipeline
+	ingestRepo    ingestRepo
+	rateLimitRepo ingestRateLimitRepo
+	sm            *monitor.SourceMonitor
+}
+
+func NewIngestHandler(pipeline *sources.Pipeline, ingestRepo *repos.IngestRepo, rateLimitRepo *repos.RateLimitRepo, sourceMonitor *monitor.SourceMonitor) *IngestHandler {
+	return &IngestHandler{
+		pipeline:      pipeline,
+		ingestRepo:    ingestRepo,
+		rateLimitRepo: rateLimitRepo,
+		sm:            sourceMonitor,
+	}
+}
+
+// IngestItem accepts a single JSON item.
+func (h *IngestHandler) IngestItem(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	var item models.IngestItem
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes)).Decode(&item); err != nil {
+		return BadRequest("Invalid request.")
+	}
+	if msg := normalizeIngestItem(&item); msg != "" {
+		return BadRequest(msg)
+	}
+
+	return h.ingest(user, []models.IngestItem{item})
+}
+
+// IngestBatch accepts newline-delimited JSON with one item per line.
+func (h *IngestHandler) IngestBatch(w http.ResponseWriter, r *http.Request) Result {
+	user := r.Context().Value("user").(data.User)
+
+	scanner := bufio.NewScanner(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxIngestLineBytes)
+
+	items := make([]models.IngestItem, 0, 64)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if len(items) >= maxIngestBatchItems {
+			return BadRequest(fmt.Sprintf("A batch can contain at most %d items.", maxIngestBatchItems))
+		}
+
+		var item models.IngestItem
+		if err := json.Unmarshal([]byte(text), &item); err != nil {
+			return BadRequest(fmt.Sprintf("Line %d: invalid JSON.", line))
+		}
+		if msg := normalizeIngestItem(&item); msg != "" {
+			return BadRequest(fmt.Sprintf("Line %d: %s", line, msg))
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, bufio.ErrTooLong) || errors.As(err, &maxBytesErr) {
+			return BadRequest("Request is too large.")
+		}
+		return BadRequest("Invalid request.")
+	}
+	if len(items) == 0 {
+		return BadRequest("Batch contains no items.")
+	}
+
+	return h.ingest(user, items)
+}
+
+// ingest drops items that were already ingested for their source, enforces the per-source quotas
+// and runs the rest through the matching pipeline. Items are only recorded as ingested once their
+// matches are stored and the quota charged for them is refunded on failure, so a failed request
+// can be retried.
+func (h *IngestHandler) ingest(user data.User, items []models.IngestItem) Result {
+	bySource := make(map[string][]models.IngestItem)
+	order := make([]string, 0, 1)
+	seen := make(map[string]struct{}, len(items))
+	duplicates := 0
+	for _, item := range items {
+		key := item.Source + "\x00" + item.ID
+		if _, ok := seen[key]; ok {
+			duplicates++
+			continue
+		}
+		seen[key] = struct{}{}
+		if _, ok := bySource[item.Source]; !ok {
+			order = append(order, item.Source)
+		}
+		bySource[item.Source] = append(bySource[item.Source], item)
+	}
+
+	newIDs := make(map[string][]string, len(order))
+	pipelineItems := make([]sources.Item, 0, len(items))
+	for _, source := range order {
+		sourceItems := bySource[source]
+		ids := make([]string, 0, len(sourceItems))
+		for _, item := range sourceItems {
+			ids = append(ids, item.ID)
+		}
+
+		ingested, err := h.ingestRepo.GetIngestedItems(user.ID, source, ids)
+		if err != nil {
+			return InternalError(err, "get ingested items: ")
+		}
+		isIngested := make(map[string]struct{}, len(ingested))
+		for _, id := range ingested {
+			isIngested[id] = struct{}{}
+		}
+
+		for _, item := range sourceItems {
+			if _, ok := isIngested[item.ID]; ok {
+				duplicates++
+				continue
+			}
+			newIDs[source] = append(newIDs[source], item.ID)
+			pipelineItems = append(pipelineItems, sources.IngestedItem(user.ID, item))
+		}
+	}
+
+	res := models.IngestResponse{
+		Accepted:   len(pipelineItems),
+		Duplicates: duplicates,
+	}
+	if len(pipelineItems) == 0 {
+		return Ok(res)
+	}
+
+	policy := config.RateLimits[config.RateIDIngestItems]
+	increments := make([]repos.RateIncrement, 0, len(newIDs))
+	for _, source := range order {
+		if n := len(newIDs[source]); n > 0 {
+			increments = append(increments, repos.RateIncrement{RateID: policy.RateID + ":" + source, N: n})
+		}
+	}
+	windowKey := policy.WindowKey(time.Now())
+	exceeded, err := h.rateLimitRepo.IncrementAllWithinLimit(user.ID, windowKey, increments, policy.Limit)
+	if err != nil {
+		return InternalError(err, "increment ingest limit: ")
+	}
+	if exceeded != "" {
+		source := strings.TrimPrefix(exceeded, policy.RateID+":")
+		return TooManyRequests(fmt.Sprintf("You have reached the daily ingest limit for source %q.", source))
+	}
+
+	processingStart := time.Now()
+	matches := h.pipeline.Match(pipelineItems, nil)
+	if err := h.pipeline.Persist(matches); err != nil {
+		return h.refund(user, windowKey, increments, InternalError(err, "store ingested matches: "))
+	}
+	for _, source := range order {
+		if ids := newIDs[source]; len(ids) > 0 {
+			if err := h.ingestRepo.InsertItems(user.ID, source, ids); err != nil {
+				return h.refund(user, windowKey, increments, InternalError(err, "insert ingested items: "))
+			}
+		}
+	}
+	h.sm.Batch(ingestMonitorSource, int64(len(pipelineItems)), processingStart, 0)
+	res.Matches = len(matches.Matches)
+
+	return Ok(res)
+}
+
+// refund gives back the quota charged for a request whose items could not be stored, so the
+// client can retry without running into its limit.
+func (h *IngestHandler) refund(user data.User, windowKey string, increments []repos.RateIncrement, res Result) Result {
+	if err := h.rateLimitRepo.RefundAll(user.ID, windowKey, increments); err != nil {
+		res.Error = errors.Join(res.Error, fmt.Errorf("refund ingest limit: %w", err))
+	}
+	return res
+}
+
+// normalizeIngestItem trims the item in place and returns a validation message, or an empty string when it is valid.
+func normalizeIngestItem(item *models.IngestItem) string {
+	item.ID = strings.TrimSpace(item.ID)
+	item.Source = strings.ToLower(strings.TrimSpace(item.Source))
+	item.Title = strings.TrimSpace(item.Title)
+	item.Body = strings.TrimSpace(item.Body)
+	item.Author = strings.TrimSpace(item.Author)
+	item.URL = strings.TrimSpace(item.URL)
+
+	switch {
+	case item.ID == "":
+		return "Item id is required."
+	case len(item.ID) > maxIngestIDLength:
+		return "Item id is too long."
+	case !ingestSourcePattern.MatchString(item.Source):
+		return "Item source must be 1-64 lowercase letters, digits, dots, dashes or underscores."
+	case item.Title == "" && item.Body == "":
+		return "Item title or body is required."
+	case len(item.Title) > maxIngestTitleLength:
+		return "Item title is too long."
+	case len(item.Body) > maxIngestTextLength:
+		return "Item body is too long."
+	case len(item.Author) > maxIngestAuthorLength:
+		return "Item author is too long."
+	}
+
+	if item.URL != "" {
+		normalized, ok := normalizeHTTPURL(item.URL)
+		if !ok {
+			return "Item url must be a valid http or https URL."
+		}
+		item.URL = normalized
+	}
+	if item.CreatedAt != nil && item.CreatedAt.After(time.Now().Add(time.Hour)) {
+		return "Item createdAt cannot be in the future."
+	}
+	if len(item.Metadata) > 0 {
+		raw, err := json.Marshal(item.Metadata)
+		if err != nil || len(raw) > maxIngestMetadataBytes {
+			return "Item metadata is too large."
+		}
+	}
+
+	return ""
+}
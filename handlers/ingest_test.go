@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/kova98/feedgrep.api/sources"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIngestPipeline struct {
+	persistErr error
+}
+
+func (p *fakeIngestPipeline) Match(items []sources.Item, _ sources.EvaluationObserver) sources.MatchSet {
+	return sources.MatchSet{Items: items, Matches: make([]data.Match, len(items))}
+}
+
+func (p *fakeIngestPipeline) Persist(sources.MatchSet) error {
+	return p.persistErr
+}
+
+type fakeIngestRepo struct {
+	inserted map[string][]string
+}
+
+func (r *fakeIngestRepo) GetIngestedItems(uuid.UUID, string, []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeIngestRepo) InsertItems(_ uuid.UUID, source string, externalIDs []string) error {
+	r.inserted[source] = append(r.inserted[source], externalIDs...)
+	return nil
+}
+
+type fakeRateLimitRepo struct {
+	counts map[string]int
+}
+
+func (r *fakeRateLimitRepo) IncrementAllWithinLimit(_ uuid.UUID, windowKey string, increments []repos.RateIncrement, limit int) (string, error) {
+	for _, inc := range increments {
+		if r.counts[windowKey+inc.RateID]+inc.N > limit {
+			return inc.RateID, nil
+		}
+	}
+	for _, inc := range increments {
+		r.counts[windowKey+inc.RateID] += inc.N
+	}
+	return "", nil
+}
+
+func (r *fakeRateLimitRepo) RefundAll(_ uuid.UUID, windowKey string, increments []repos.RateIncrement) error {
+	for _, inc := range increments {
+		r.counts[windowKey+inc.RateID] -= inc.N
+	}
+	return nil
+}
+
+func (r *fakeRateLimitRepo) total() int {
+	total := 0
+	for _, n := range r.counts {
+		total += n
+	}
+	return total
+}
+
+func newTestIngestHandler(pipeline *fakeIngestPipeline) (*IngestHandler, *fakeIngestRepo, *fakeRateLimitRepo) {
+	ingestRepo := &fakeIngestRepo{inserted: make(map[string][]string)}
+	rateLimitRepo := &fakeRateLimitRepo{counts: make(map[string]int)}
+	return &IngestHandler{
+		pipeline:      pipeline,
+		ingestRepo:    ingestRepo,
+		rateLimitRepo: rateLimitRepo,
+		sm:            monitor.NewSourceMonitor(),
+	}, ingestRepo, rateLimitRepo
+}
+
+func TestIngestHandlerIngest(t *testing.T) {
+	config.RateLimits = map[string]config.RateLimitPolicy{
+		config.RateIDIngestItems: {RateID: config.RateIDIngestItems, Limit: 100, WindowKey: config.DailyWindowKey},
+	}
+	user := data.User{ID: uuid.New()}
+	items := []models.IngestItem{
+		{ID: "1", Source: "forum", Title: "first"},
+		{ID: "2", Source: "forum", Title: "second"},
+		{ID: "1", Source: "blog", Title: "third"},
+	}
+
+	t.Run("it charges the quota and records the items when they are stored", func(t *testing.T) {
+		h, ingestRepo, rateLimitRepo := newTestIngestHandler(&fakeIngestPipeline{})
+
+		res := h.ingest(user, items)
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, models.IngestResponse{Accepted: 3, Matches: 3}, res.Body)
+		assert.Equal(t, 3, rateLimitRepo.total())
+		assert.Equal(t, []string{"1", "2"}, ingestRepo.inserted["forum"])
+	})
+
+	t.Run("it refunds the quota and records nothing when storing the matches fails", func(t *testing.T) {
+		h, ingestRepo, rateLimitRepo := newTestIngestHandler(&fakeIngestPipeline{persistErr: errors.New("db down")})
+
+		res := h.ingest(user, items)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Error(t, res.Error)
+		assert.Equal(t, 0, rateLimitRepo.total())
+		assert.Empty(t, ingestRepo.inserted)
+	})
+}
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -12,7 +15,15 @@ import (
 	"github.com/kova98/feedgrep.api/data"
 	"github.com/kova98/feedgrep.api/data/repos"
 	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/matchers"
 	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/sources"
+)
+
+const (
+	defaultEngagementWindowHours = 6
+	maxEngagementWindowHours     = 48
+	maxQueryLength               = 500
 )
 
 // fake user used for global rate limits
@@ -22,15 +33,17 @@ type KeywordHandler struct {
 	repo            *repos.KeywordRepo
 	matchRepo       *repos.MatchRepo
 	rateLimitRepo   *repos.RateLimitRepo
+	pipeline        *sources.Pipeline
 	searchURL       string
 	filterGenerator *SmartFilterGenerator
 }
 
-func NewKeywordHandler(repo *repos.KeywordRepo, matchRepo *repos.MatchRepo, rateLimitRepo *repos.RateLimitRepo, searchURL string, filterGenerator *SmartFilterGenerator) *KeywordHandler {
+func NewKeywordHandler(repo *repos.KeywordRepo, matchRepo *repos.MatchRepo, rateLimitRepo *repos.RateLimitRepo, pipeline *sources.Pipeline, searchURL string, filterGenerator *SmartFilterGenerator) *KeywordHandler {
 	return &KeywordHandler{
 		repo:            repo,
 		matchRepo:       matchRepo,
 		rateLimitRepo:   rateLimitRepo,
+		pipeline:        pipeline,
 		searchURL:       strings.TrimRight(searchURL, "/"),
 		filterGenerator: filterGenerator,
 	}
@@ -88,11 +101,22 @@ func (h *KeywordHandler) CreateKeyword(w http.ResponseWriter, r *http.Request) R
 	}
 
 	normalized := strings.ToLower(strings.TrimSpace(req.Keyword))
+	if req.MatchMode == enums.MatchModeQuery {
+		// Queries keep their case, since their operators are upper case.
+		normalized = strings.TrimSpace(req.Keyword)
+	}
 	if normalized == "" {
 		return BadRequest("Keyword is required.")
 	}
 
-	if len(normalized) < 4 || len(normalized) > 50 {
+	if req.MatchMode == enums.MatchModeQuery {
+		if len(normalized) > maxQueryLength {
+			return BadRequest(fmt.Sprintf("Query must be at most %d characters.", maxQueryLength))
+		}
+		if _, err := matchers.ParseQuery(normalized); err != nil {
+			return invalidQuery(err)
+		}
+	} else if len(normalized) < 4 || len(normalized) > 50 {
 		return BadRequest("Keyword must be between 4 and 50 characters.")
 	}
 
@@ -102,6 +126,14 @@ func (h *KeywordHandler) CreateKeyword(w http.ResponseWriter, r *http.Request) R
 	if req.MatchMode == enums.MatchModeSmart && (req.Filters == nil || req.Filters.Smart == nil) {
 		return BadRequest("Smart match mode requires a smart filter.")
 	}
+	if req.Filters != nil && req.Filters.Reddit != nil && !validRedditPostType(req.Filters.Reddit.PostType) {
+		return BadRequest("Reddit post type must be self or link.")
+	}
+	if req.Filters != nil && req.Filters.Engagement != nil {
+		if msg := normalizeEngagementThreshold(req.Filters.Engagement); msg != "" {
+			return BadRequest(msg)
+		}
+	}
 
 	keyword := data.Keyword{
 		UserID:    user.ID,
@@ -112,13 +144,36 @@ func (h *KeywordHandler) CreateKeyword(w http.ResponseWriter, r *http.Request) R
 	if req.Filters != nil {
 		keyword.Filters = models.ToDataFilters(*req.Filters)
 	}
+	if keyword.Filters.Smart != nil {
+		if _, err := matchers.CompileSmartFilter(*keyword.Filters.Smart); err != nil {
+			return BadRequest("Invalid smart filter: " + err.Error())
+		}
+	}
 
 	id, err := h.repo.CreateKeyword(keyword)
 	if err != nil {
 		return InternalError(err, "create keyword: ")
 	}
+	keyword.ID = id
+
+	return Result{
+		Code: http.StatusCreated,
+		Body: models.CreateKeywordResponse{
+			ID:                 id,
+			RetroactiveMatches: h.matchRecent(keyword),
+		},
+	}
+}
 
-	return Created(id)
+// matchRecent matches the keyword against recently fetched items. The keyword is already saved,
+// so a failure is logged and reported as no matches instead of failing the request.
+func (h *KeywordHandler) matchRecent(keyword data.Keyword) int {
+	count, err := h.pipeline.MatchRecent(keyword)
+	if err != nil {
+		slog.Error("failed to match keyword against recent items", "keyword_id", keyword.ID, "error", err)
+		return 0
+	}
+	return count
 }
 
 func (h *KeywordHandler) GetKeywords(w http.ResponseWriter, r *http.Request) Result {
@@ -143,6 +198,7 @@ func (h *KeywordHandler) GetKeywords(w http.ResponseWriter, r *http.Request) Res
 			UnseenCount:   k.UnseenCount,
 			LastMatchedAt: k.LastMatchedAt,
 			CreatedAt:     k.CreatedAt,
+			Problems:      smartFilterProblems(k.MatchMode, k.Filters),
 		})
 	}
 
@@ -178,11 +234,22 @@ func (h *KeywordHandler) GetKeyword(w http.ResponseWriter, r *http.Request) Resu
 		UnseenCount:   keyword.UnseenCount,
 		LastMatchedAt: keyword.LastMatchedAt,
 		CreatedAt:     keyword.CreatedAt,
+		Problems:      smartFilterProblems(keyword.MatchMode, keyword.Filters),
 	}
 
 	return Ok(res)
 }
 
+// smartFilterProblems returns the parts of a stored smart filter that no longer pass validation
+// and are ignored when matching, so the user can fix them.
+func smartFilterProblems(matchMode enums.MatchMode, filters data.KeywordFilters) []string {
+	if matchMode != enums.MatchModeSmart || filters.Smart == nil {
+		return nil
+	}
+	_, problems := matchers.CompileStoredSmartFilter(*filters.Smart)
+	return problems
+}
+
 func (h *KeywordHandler) UpdateKeyword(w http.ResponseWriter, r *http.Request) Result {
 	user := r.Context().Value("user").(data.User)
 
@@ -198,11 +265,22 @@ func (h *KeywordHandler) UpdateKeyword(w http.ResponseWriter, r *http.Request) R
 	}
 
 	normalized := strings.ToLower(strings.TrimSpace(req.Keyword))
+	if req.MatchMode == enums.MatchModeQuery {
+		// Queries keep their case, since their operators are upper case.
+		normalized = strings.TrimSpace(req.Keyword)
+	}
 	if normalized == "" {
 		return BadRequest("Keyword is required.")
 	}
 
-	if len(normalized) < 4 || len(normalized) > 50 {
+	if req.MatchMode == enums.MatchModeQuery {
+		if len(normalized) > maxQueryLength {
+			return BadRequest(fmt.Sprintf("Query must be at most %d characters.", maxQueryLength))
+		}
+		if _, err := matchers.ParseQuery(normalized); err != nil {
+			return invalidQuery(err)
+		}
+	} else if len(normalized) < 4 || len(normalized) > 50 {
 		return BadRequest("Keyword must be between 4 and 50 characters.")
 	}
 
@@ -212,6 +290,14 @@ func (h *KeywordHandler) UpdateKeyword(w http.ResponseWriter, r *http.Request) R
 	if req.MatchMode == enums.MatchModeSmart && (req.Filters == nil || req.Filters.Smart == nil) {
 		return BadRequest("Smart match mode requires a smart filter.")
 	}
+	if req.Filters != nil && req.Filters.Reddit != nil && !validRedditPostType(req.Filters.Reddit.PostType) {
+		return BadRequest("Reddit post type must be self or link.")
+	}
+	if req.Filters != nil && req.Filters.Engagement != nil {
+		if msg := normalizeEngagementThreshold(req.Filters.Engagement); msg != "" {
+			return BadRequest(msg)
+		}
+	}
 
 	keyword := data.Keyword{
 		ID:        id,
@@ -223,12 +309,25 @@ func (h *KeywordHandler) UpdateKeyword(w http.ResponseWriter, r *http.Request) R
 	if req.Filters != nil {
 		keyword.Filters = models.ToDataFilters(*req.Filters)
 	}
+	if keyword.Filters.Smart != nil {
+		if _, err := matchers.CompileSmartFilter(*keyword.Filters.Smart); err != nil {
+			return BadRequest("Invalid smart filter: " + err.Error())
+		}
+	}
+
+	existing, err := h.repo.GetKeywordByID(id, user.ID)
+	if err != nil {
+		return InternalError(err, "get keyword: ")
+	}
+	if existing == nil {
+		return NotFound("Keyword not found.")
+	}
 
 	if err := h.repo.UpdateKeyword(keyword); err != nil {
 		return InternalError(err, "update keyword: ")
 	}
 
-	return Ok(nil)
+	return Ok(models.UpdateKeywordResponse{RetroactiveMatches: h.matchRecent(keyword)})
 }
 
 func (h *KeywordHandler) DeleteKeyword(w http.ResponseWriter, r *http.Request) Result {
@@ -325,8 +424,8 @@ func (h *KeywordHandler) GetKeywordMatches(w http.ResponseWriter, r *http.Reques
 	}
 
 	for _, m := range matches {
-		var redditData data.RedditData
-		_ = json.Unmarshal(m.DataRaw, &redditData)
+		matchData, _ := models.FromDataMatchData(m.Source, m.DataRaw)
+		alsoPostedIn, _ := m.ParseAlsoPostedIn()
 
 		out.Matches = append(out.Matches, models.Match{
 			ID:        m.ID,
@@ -334,14 +433,9 @@ func (h *KeywordHandler) GetKeywordMatches(w http.ResponseWriter, r *http.Reques
 			Source:    string(m.Source),
 			CreatedAt: m.CreatedAt,
 			SeenAt:    m.SeenAt,
-			Data: models.RedditData{
-				Subreddit: redditData.Subreddit,
-				Author:    redditData.Author,
-				Title:     redditData.Title,
-				Body:      redditData.Body,
-				Permalink: redditData.Permalink,
-				IsComment: redditData.IsComment,
-			},
+			Data:      matchData,
+
+			AlsoPostedIn: models.FromDataMatchLocations(alsoPostedIn),
 		})
 	}
 
@@ -382,3 +476,43 @@ func (h *KeywordHandler) GetKeywordMatchActivity(w http.ResponseWriter, r *http.
 
 	return Ok(out)
 }
+
+func validRedditPostType(postType string) bool {
+	switch postType {
+	case "", data.RedditPostTypeSelf, data.RedditPostTypeLink:
+		return true
+	}
+	return false
+}
+
+// normalizeEngagementThreshold applies the default window and returns a validation message, or an empty string when it is valid.
+func normalizeEngagementThreshold(threshold *models.EngagementThreshold) string {
+	if threshold.MinScore < 0 || threshold.MinComments < 0 {
+		return "Engagement thresholds cannot be negative."
+	}
+	if threshold.MinScore == 0 && threshold.MinComments == 0 {
+		return "Engagement threshold requires a minimum score or number of comments."
+	}
+	if threshold.WithinHours == 0 {
+		threshold.WithinHours = defaultEngagementWindowHours
+	}
+	if threshold.WithinHours < 1 || threshold.WithinHours > maxEngagementWindowHours {
+		return fmt.Sprintf("Engagement window must be between 1 and %d hours.", maxEngagementWindowHours)
+	}
+	return ""
+}
+
+// invalidQuery reports a query syntax error along with the position it was found at.
+func invalidQuery(err error) Result {
+	var queryErr *matchers.QueryError
+	if !errors.As(err, &queryErr) {
+		return BadRequest("Invalid query.")
+	}
+	return Result{
+		Code: http.StatusBadRequest,
+		Body: models.InvalidQueryResponse{
+			Error:    fmt.Sprintf("Invalid query at position %d: %s.", queryErr.Pos, queryErr.Message),
+			Position: queryErr.Pos,
+		},
+	}
+}
@@ -87,14 +87,34 @@ func (h *KeywordHandler) StreamHistoricalSmartMatches(w http.ResponseWriter, r *
 		http.Error(w, "Keyword not found.", http.StatusNotFound)
 		return
 	}
-	if keyword.MatchMode != enums.MatchModeSmart || keyword.Filters.Smart == nil {
-		http.Error(w, "Historical streaming requires a smart keyword.", http.StatusBadRequest)
-		return
-	}
-
-	query, err := compileSmartCandidateQuery(keyword.Filters.Smart.Candidate)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var query string
+	var evaluate func(matchers.SmartInput) matchers.SmartMatchResult
+	switch {
+	case keyword.MatchMode == enums.MatchModeSmart && keyword.Filters.Smart != nil:
+		filter, _ := matchers.CompileStoredSmartFilter(*keyword.Filters.Smart)
+		var err error
+		query, err = compileSmartCandidateQuery(keyword.Filters.Smart.Candidate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		evaluate = filter.Evaluate
+	case keyword.MatchMode == enums.MatchModeQuery:
+		parsed, err := matchers.ParseQuery(keyword.Keyword.Keyword)
+		if err != nil {
+			http.Error(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		query = compileKeywordQuery(parsed)
+		evaluate = func(input matchers.SmartInput) matchers.SmartMatchResult {
+			return matchers.SmartMatchResult{Matched: parsed.Matches(matchers.QueryInput{
+				Title:     input.Title,
+				Body:      input.Body,
+				Subreddit: input.Subreddit,
+			})}
+		}
+	default:
+		http.Error(w, "Historical streaming requires a smart or query keyword.", http.StatusBadRequest)
 		return
 	}
 
@@ -151,14 +171,11 @@ func (h *KeywordHandler) StreamHistoricalSmartMatches(w http.ResponseWriter, r *
 			}
 			processed++
 
-			result, err := matchers.EvaluateSmart(*keyword.Filters.Smart, matchers.SmartInput{
+			result := evaluate(matchers.SmartInput{
 				Title:     hit.Title,
 				Body:      hit.Body,
 				Subreddit: hit.Subreddit,
 			})
-			if err != nil {
-				return err
-			}
 			if result.Matched {
 				matched++
 				writeSSE(w, "match", browserMatchEvent{
@@ -241,8 +258,21 @@ func compileSmartCondition(condition data.SmartCondition, fields []string) (stri
 		}
 		return joinQueryParts(parts, "OR"), nil
 	case len(condition.All) > 0:
+		// A none child only narrows down what the other children retrieve, so it becomes an
+		// AND NOT and the all needs at least one other child.
 		parts := make([]string, 0, len(condition.All))
+		excluded := make([]string, 0)
 		for _, child := range condition.All {
+			if len(child.Any) == 0 && len(child.All) == 0 && len(child.None) > 0 {
+				part, err := compileSmartCondition(data.SmartCondition{Any: child.None}, fields)
+				if err != nil {
+					return "", err
+				}
+				if part != "" {
+					excluded = append(excluded, "NOT "+part)
+				}
+				continue
+			}
 			part, err := compileSmartCondition(child, fields)
 			if err != nil {
 				return "", err
@@ -251,7 +281,31 @@ func compileSmartCondition(condition data.SmartCondition, fields []string) (stri
 				parts = append(parts, part)
 			}
 		}
+		if len(parts) == 0 {
+			if len(excluded) > 0 {
+				return "", fmt.Errorf("smart historical search does not support all candidates made only of none")
+			}
+			return "", nil
+		}
+		return joinQueryParts(append(parts, excluded...), "AND"), nil
+	case len(condition.None) > 0:
+		// On its own a none matches nearly everything, which cannot be searched for.
+		return "", fmt.Errorf("smart historical search does not support none candidates outside all")
+	case condition.Near != nil:
+		// The search service has no proximity operator, so it retrieves items containing every
+		// term and the distance is checked when the hits are evaluated.
+		parts := make([]string, 0, len(condition.Near.Terms))
+		for _, term := range condition.Near.Terms {
+			part, err := compileSmartCondition(data.SmartCondition{AnyPhrase: []string{term}}, fields)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
 		return joinQueryParts(parts, "AND"), nil
+	case condition.CountAtLeast != nil:
+		// Likewise any of the phrases is retrieved and the count is checked on evaluation.
+		return compileSmartCondition(data.SmartCondition{AnyPhrase: condition.CountAtLeast.Phrases}, fields)
 	case len(condition.AnyPhrase) > 0:
 		fieldCount := len(fields)
 		if fieldCount == 0 {
@@ -281,6 +335,44 @@ func compileSmartCondition(condition data.SmartCondition, fields []string) (stri
 	}
 }
 
+// compileKeywordQuery translates a parsed query keyword into the search service syntax. Terms
+// without a field search the title and the body; wildcard terms are passed through unquoted, with
+// the syntax characters other than * escaped.
+func compileKeywordQuery(query *matchers.Query) string {
+	return compileQueryNode(query.Root)
+}
+
+func compileQueryNode(node *matchers.QueryNode) string {
+	switch node.Op {
+	case matchers.QueryAnd, matchers.QueryOr:
+		op := "AND"
+		if node.Op == matchers.QueryOr {
+			op = "OR"
+		}
+		parts := make([]string, 0, len(node.Children))
+		for _, child := range node.Children {
+			parts = append(parts, compileQueryNode(child))
+		}
+		return joinQueryParts(parts, op)
+	case matchers.QueryNot:
+		return "NOT " + compileQueryNode(node.Children[0])
+	default:
+		value := quoteQueryPhrase(node.Value)
+		if node.Wildcard {
+			value = escapeQueryWildcard(node.Value)
+		}
+		fields := []string{"title", "body"}
+		if node.Field != "" {
+			fields = []string{node.Field}
+		}
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			parts = append(parts, fmt.Sprintf(`%s:%s`, field, value))
+		}
+		return joinQueryParts(parts, "OR")
+	}
+}
+
 func joinQueryParts(parts []string, op string) string {
 	if len(parts) == 0 {
 		return ""
@@ -291,6 +383,21 @@ func joinQueryParts(parts []string, op string) string {
 	return "(" + strings.Join(parts, " "+op+" ") + ")"
 }
 
+// queryWildcardEscapes are the characters of the search service syntax that are escaped in
+// unquoted wildcard terms. * is left as is, since it is the wildcard.
+const queryWildcardEscapes = `\+-&|!(){}[]^"~?:/`
+
+func escapeQueryWildcard(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(queryWildcardEscapes, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 func quoteQueryPhrase(value string) string {
 	escaped := strings.ReplaceAll(strings.TrimSpace(value), `\`, `\\`)
 	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
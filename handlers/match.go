@@ -41,8 +41,8 @@ func (h *MatchHandler) GetMatches(w http.ResponseWriter, r *http.Request) Result
 	}
 
 	for _, m := range matches {
-		var redditData data.RedditData
-		_ = json.Unmarshal(m.DataRaw, &redditData)
+		matchData, _ := models.FromDataMatchData(m.Source, m.DataRaw)
+		alsoPostedIn, _ := m.ParseAlsoPostedIn()
 
 		res.Matches = append(res.Matches, models.Match{
 			ID:        m.ID,
@@ -50,14 +50,9 @@ func (h *MatchHandler) GetMatches(w http.ResponseWriter, r *http.Request) Result
 			Source:    string(m.Source),
 			CreatedAt: m.CreatedAt,
 			SeenAt:    m.SeenAt,
-			Data: models.RedditData{
-				Subreddit: redditData.Subreddit,
-				Author:    redditData.Author,
-				Title:     redditData.Title,
-				Body:      redditData.Body,
-				Permalink: redditData.Permalink,
-				IsComment: redditData.IsComment,
-			},
+			Data:      matchData,
+
+			AlsoPostedIn: models.FromDataMatchLocations(alsoPostedIn),
 		})
 	}
 
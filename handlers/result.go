@@ -63,6 +63,13 @@ func Unauthorized(message string) Result {
 	}
 }
 
+func Forbidden(message string) Result {
+	return Result{
+		Code: http.StatusForbidden,
+		Body: ErrorResponse{message},
+	}
+}
+
 func TooManyRequests(message string) Result {
 	return Result{
 		Code: http.StatusTooManyRequests,
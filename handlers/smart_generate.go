@@ -38,6 +38,14 @@ Schema:
     "subreddits": {
       "include": ["string"],
       "exclude": ["string"]
+    },
+    "instances": {
+      "include": ["string"],
+      "exclude": ["string"]
+    },
+    "tags": {
+      "include": ["string"],
+      "exclude": ["string"]
     }
   },
   "candidate": {
@@ -117,6 +125,10 @@ Quality rules:
 - Avoid vague single-word signals like like, need, recommend, problem, or issue unless part of a phrase.
 - Prefer phrases like looking for, wish there was, frustrated with, feature request, would love, alternative to, we built, top 10 when relevant.
 - Use subreddits only when the intent clearly implies them.
+- Use "labels" in where only for GitHub issue, pull request and discussion labels.
+- Use "parentTitle" in where only to match the title of the Reddit thread a comment was posted in.
+- Use instances (Mastodon server domains like mastodon.social) only when the intent clearly implies them.
+- Use tags (Stack Exchange question tags like postgresql) only when the intent clearly implies them.
 - If language is unspecified, default to English only when reasonable.
 - The config should be broad enough to retrieve plausible candidates, then selective enough in signals to reduce noise.
 
@@ -296,6 +308,18 @@ func normalizeSmartFilter(filter *models.SmartFilter, name, intent string) error
 	if filter.Scope.Subreddits.Exclude == nil {
 		filter.Scope.Subreddits.Exclude = []string{}
 	}
+	if filter.Scope.Instances.Include == nil {
+		filter.Scope.Instances.Include = []string{}
+	}
+	if filter.Scope.Instances.Exclude == nil {
+		filter.Scope.Instances.Exclude = []string{}
+	}
+	if filter.Scope.Tags.Include == nil {
+		filter.Scope.Tags.Include = []string{}
+	}
+	if filter.Scope.Tags.Exclude == nil {
+		filter.Scope.Tags.Exclude = []string{}
+	}
 	if len(filter.Candidate.Where) == 0 {
 		filter.Candidate.Where = []string{"title", "body"}
 	}
@@ -319,6 +343,9 @@ func normalizeSmartFilter(filter *models.SmartFilter, name, intent string) error
 func isEmptySmartCondition(condition models.SmartCondition) bool {
 	return len(condition.Any) == 0 &&
 		len(condition.All) == 0 &&
+		len(condition.None) == 0 &&
+		condition.Near == nil &&
+		condition.CountAtLeast == nil &&
 		len(condition.AnyPhrase) == 0 &&
 		len(condition.Regex) == 0
 }
@@ -359,6 +386,9 @@ func normalizeSmartCondition(condition *models.SmartCondition) {
 	for i := range condition.All {
 		normalizeSmartCondition(&condition.All[i])
 	}
+	for i := range condition.None {
+		normalizeSmartCondition(&condition.None[i])
+	}
 }
 
 func normalizeCandidateWhere(where []string) []string {
@@ -370,7 +400,7 @@ func normalizeCandidateWhere(where []string) []string {
 	normalized := make([]string, 0, len(where))
 	for _, field := range where {
 		switch strings.TrimSpace(strings.ToLower(field)) {
-		case "title", "body", "subreddit":
+		case "title", "body", "subreddit", "labels", "parenttitle":
 			if _, ok := seen[field]; ok {
 				continue
 			}
@@ -403,5 +433,11 @@ func countSmartConditionPhrases(condition models.SmartCondition) int {
 	for _, child := range condition.All {
 		count += countSmartConditionPhrases(child)
 	}
+	if condition.Near != nil {
+		count += len(condition.Near.Terms)
+	}
+	if condition.CountAtLeast != nil {
+		count += len(condition.CountAtLeast.Phrases)
+	}
 	return count
 }
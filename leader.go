@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	leaderRetryInterval = 5 * time.Second
+	leaderRenewInterval = 10 * time.Second
+	leaderRenewTimeout  = 5 * time.Second
+)
+
+// LeaderElector makes sure that only one replica runs the background workers. The leader holds a
+// Postgres advisory lock on a dedicated connection and renews its lease by checking that the lock
+// is still held. When the leader dies its connection closes, the lock is released and one of the
+// followers takes over on its next attempt.
+type LeaderElector struct {
+	name     string
+	key      int64
+	lockRepo *repos.AdvisoryLockRepo
+	monitor  *monitor.LeaderMonitor
+}
+
+func NewLeaderElector(name string, lockRepo *repos.AdvisoryLockRepo, leaderMonitor *monitor.LeaderMonitor) *LeaderElector {
+	return &LeaderElector{
+		name:     name,
+		key:      leaderLockKey(name),
+		lockRepo: lockRepo,
+		monitor:  leaderMonitor,
+	}
+}
+
+// Run campaigns for leadership until ctx is cancelled. Every time this instance is elected, lead is
+// called with a context that is cancelled as soon as the lease is lost, and must block until the
+// work it started has stopped. The lock is only released, and leadership only campaigned for
+// again, once lead has returned, so two generations of workers never run side by side.
+func (e *LeaderElector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	e.monitor.Follower(e.name)
+	for {
+		lock, err := e.lockRepo.TryLock(ctx, e.key)
+		if err != nil {
+			slog.Error("leader election: acquire lock", "name", e.name, "error", err)
+		}
+		if lock != nil {
+			e.hold(ctx, lock, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+// hold runs lead and renews the lease until it is lost, ctx is cancelled or lead returns.
+func (e *LeaderElector) hold(ctx context.Context, lock *repos.AdvisoryLock, lead func(ctx context.Context)) {
+	slog.Info("leader election: elected", "name", e.name)
+	e.monitor.Elected(e.name)
+
+	leaderCtx, stepDown := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leaderCtx)
+	}()
+	defer func() {
+		stepDown()
+		<-done
+		e.monitor.Lost(e.name)
+		releaseCtx, cancel := context.WithTimeout(context.Background(), leaderRenewTimeout)
+		defer cancel()
+		if err := lock.Release(releaseCtx); err != nil {
+			slog.Warn("leader election: release lock", "name", e.name, "error", err)
+		}
+	}()
+
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("leader election: stepping down", "name", e.name)
+			return
+		case <-done:
+			slog.Warn("leader election: workers stopped, stepping down", "name", e.name)
+			return
+		case <-ticker.C:
+			renewCtx, cancel := context.WithTimeout(ctx, leaderRenewTimeout)
+			err := lock.Held(renewCtx)
+			cancel()
+			if err != nil {
+				slog.Warn("leader election: lease lost", "name", e.name, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// leaderLockKey derives a stable, non-negative advisory lock key from the election name.
+func leaderLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("feedgrep:" + name))
+	return int64(h.Sum64() & 0x7fffffffffffffff)
+}
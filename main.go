@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -64,12 +65,18 @@ func main() {
 	matchRepo := repos.NewMatchRepo(db)
 	rateLimitRepo := repos.NewRateLimitRepo(db)
 	authActionTokenRepo := repos.NewAuthActionTokenRepo(db)
+	sourceCursorRepo := repos.NewSourceCursorRepo(db)
+	feedRepo := repos.NewFeedRepo(db)
+	ingestRepo := repos.NewIngestRepo(db)
+	advisoryLockRepo := repos.NewAdvisoryLockRepo(db)
+	pendingMatchRepo := repos.NewPendingMatchRepo(db)
+	recentItemRepo := repos.NewRecentItemRepo(db)
 
 	// TODO: clean this shit up
 	smartFilterGenerator := handlers.NewSmartFilterGenerator(config.Config.OpenAIAPIKey, config.Config.OpenAIModel)
 
-	keywords := handlers.NewKeywordHandler(keywordRepo, matchRepo, rateLimitRepo, config.Config.SearchAPIURL, smartFilterGenerator)
 	matches := handlers.NewMatchHandler(matchRepo)
+	feeds := handlers.NewFeedHandler(feedRepo)
 
 	arcticShiftMonitor := monitor.NewArcticShiftMonitor()
 	arcticShiftMonitor.Register(prometheus.DefaultRegisterer)
@@ -79,12 +86,85 @@ func main() {
 	notificationsMonitor.Register(prometheus.DefaultRegisterer)
 	keywordMonitor := monitor.NewKeywordMonitor()
 	keywordMonitor.Register(prometheus.DefaultRegisterer)
+	sourceMonitor := monitor.NewSourceMonitor()
+	sourceMonitor.Register(prometheus.DefaultRegisterer)
+	pipelineMonitor := monitor.NewPipelineMonitor()
+	pipelineMonitor.Register(prometheus.DefaultRegisterer)
+	leaderMonitor := monitor.NewLeaderMonitor()
+	leaderMonitor.Register(prometheus.DefaultRegisterer)
 
-	arcticShiftPoller := sources.NewArcticShiftPoller(logger, keywordRepo, matchRepo, arcticShiftMonitor, keywordMonitor)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+
+	pipeline := sources.NewPipeline(logger, keywordRepo, matchRepo, pendingMatchRepo, recentItemRepo, keywordMonitor)
+	pipeline.LoadKeywords()
+	go pipeline.Start(ctx)
+
+	keywords := handlers.NewKeywordHandler(keywordRepo, matchRepo, rateLimitRepo, pipeline, config.Config.SearchAPIURL, smartFilterGenerator)
+
+	matchPool := sources.NewMatchPool(logger, pipeline, pipelineMonitor, config.Config.MatchWorkers, config.Config.MatchQueueSize, config.Config.MatchWriteBatchSize)
+
+	// Background workers only run on the elected leader, so replicas do not fetch the
+	// same items or send the same notifications twice.
+	workers := []func(ctx context.Context){matchPool.Start, pipeline.StartPruning}
+
+	parentResolver := sources.NewParentResolver(logger, sourceMonitor, config.Config.ParentCacheSize, config.Config.ResolveParentBody)
+	arcticShiftPoller := sources.NewArcticShiftPoller(logger, pipeline, matchPool, sourceCursorRepo, parentResolver, arcticShiftMonitor, sourceMonitor)
 	if config.Config.EnableArcticShift {
-		go arcticShiftPoller.StartPolling(ctx)
+		workers = append(workers, arcticShiftPoller.StartPolling)
+	}
+
+	engagementChecker := sources.NewEngagementChecker(logger, pendingMatchRepo, pipeline, sourceMonitor)
+	workers = append(workers, engagementChecker.StartChecking)
+
+	if config.Config.RedditPollMode != config.RedditPollModeOff {
+		redditPoller := sources.NewRedditPoller(logger, matchPool, sourceCursorRepo, parentResolver, sourceMonitor, arcticShiftMonitor)
+		workers = append(workers, redditPoller.StartPolling)
+	}
+
+	if config.Config.EnableHackerNews {
+		interval := time.Duration(config.Config.HackerNewsPollIntervalMs) * time.Millisecond
+		hackerNewsPoller := sources.NewPoller(logger, sources.NewHackerNewsSource(sourceMonitor), matchPool, sourceCursorRepo, sourceMonitor, interval)
+		workers = append(workers, hackerNewsPoller.StartPolling)
+	}
+
+	if config.Config.EnableMastodon {
+		interval := time.Duration(config.Config.MastodonPollIntervalMs) * time.Millisecond
+		for _, instance := range config.Config.MastodonInstances {
+			src := sources.NewMastodonSource(instance, config.Config.MastodonHashtags, sourceMonitor)
+			mastodonPoller := sources.NewPoller(logger, src, matchPool, sourceCursorRepo, sourceMonitor, interval)
+			workers = append(workers, mastodonPoller.StartPolling)
+		}
+	}
+
+	if config.Config.EnableBluesky {
+		blueskyStreamer := sources.NewStreamer(logger, sources.NewJetstreamSource(config.Config.BlueskyJetstreamURL), pipeline, sourceCursorRepo, sourceMonitor)
+		workers = append(workers, blueskyStreamer.StartStreaming)
+	}
+
+	if config.Config.EnableGitHub {
+		interval := time.Duration(config.Config.GitHubPollIntervalMs) * time.Millisecond
+		githubClient := sources.NewGitHubClient(config.Config.GitHubToken, sourceMonitor)
+		for _, repo := range config.Config.GitHubRepos {
+			githubPoller := sources.NewPoller(logger, sources.NewGitHubSource(githubClient, repo), matchPool, sourceCursorRepo, sourceMonitor, interval)
+			workers = append(workers, githubPoller.StartPolling)
+		}
+	}
+
+	if config.Config.EnableStackExchange {
+		interval := time.Duration(config.Config.StackExchangePollIntervalMs) * time.Millisecond
+		stackExchangeClient := sources.NewStackExchangeClient(config.Config.StackExchangeKey, sourceMonitor)
+		for _, site := range config.Config.StackExchangeSites {
+			for _, endpoint := range []string{sources.StackExchangeQuestions, sources.StackExchangeAnswers} {
+				stackExchangePoller := sources.NewPoller(logger, sources.NewStackExchangeSource(stackExchangeClient, site, endpoint), matchPool, sourceCursorRepo, sourceMonitor, interval)
+				workers = append(workers, stackExchangePoller.StartPolling)
+			}
+		}
+	}
+
+	if config.Config.EnableFeeds {
+		feedPoller := sources.NewFeedPoller(logger, feedRepo, pipeline, sourceMonitor)
+		workers = append(workers, feedPoller.StartPolling)
 	}
 
 	mailer := notifiers.NewMailer(
@@ -100,9 +180,24 @@ func main() {
 	go auth.StartTokenTicker()
 
 	notifier := NewNotifier(mailer, matchRepo, usersRepo, notificationsMonitor)
-	go notifier.Start(ctx)
+	workers = append(workers, notifier.Start)
+
+	leaderElector := NewLeaderElector("workers", advisoryLockRepo, leaderMonitor)
+	go leaderElector.Run(ctx, func(ctx context.Context) {
+		var wg sync.WaitGroup
+		for _, start := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start(ctx)
+			}()
+		}
+		wg.Wait()
+	})
 
 	feedback := handlers.NewFeedbackHandler(mailer)
+	ingest := handlers.NewIngestHandler(pipeline, ingestRepo, rateLimitRepo, sourceMonitor)
+	diagnostics := handlers.NewDiagnosticsHandler(arcticShiftPoller)
 
 	mux := http.NewServeMux()
 
@@ -128,8 +223,17 @@ func main() {
 	mux.Handle("GET /keywords/{id}/historical-stream", privateHTTP(keywords.StreamHistoricalSmartMatches))
 	mux.Handle("GET /matches", private(matches.GetMatches))
 	mux.Handle("PUT /matches/{id}/seen", private(matches.UpdateMatchSeen))
+	mux.Handle("POST /feeds", private(feeds.CreateFeed))
+	mux.Handle("GET /feeds", private(feeds.GetFeeds))
+	mux.Handle("GET /feeds/{id}", private(feeds.GetFeed))
+	mux.Handle("PUT /feeds/{id}", private(feeds.UpdateFeed))
+	mux.Handle("DELETE /feeds/{id}", private(feeds.DeleteFeed))
+	mux.Handle("POST /ingest", private(ingest.IngestItem))
+	mux.Handle("POST /ingest/batch", private(ingest.IngestBatch))
 	mux.Handle("POST /feedback", private(feedback.SubmitFeedback))
 
+	mux.Handle("GET /admin/diagnostics", admin(diagnostics.GetDiagnostics))
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
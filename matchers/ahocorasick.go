@@ -0,0 +1,146 @@
+package matchers
+
+// PatternIndex finds every occurrence of a fixed set of patterns in a text in a single pass,
+// using an Aho-Corasick automaton. Matching is byte-wise and case-sensitive, so callers lower
+// both the patterns and the text.
+type PatternIndex struct {
+	nodes    []patternNode
+	patterns int
+}
+
+type patternNode struct {
+	// edges are sorted by label.
+	edges []patternEdge
+	// fail is the node of the longest proper suffix of this node that is also in the trie.
+	fail int32
+	// output is the pattern ending at this node, or -1.
+	output int32
+	// dict is the nearest node on the fail chain that has an output, or -1.
+	dict int32
+}
+
+type patternEdge struct {
+	label byte
+	node  int32
+}
+
+// NewPatternIndex builds an index of the patterns. Pattern ids are their positions in patterns;
+// empty patterns never match and duplicates report the id of their first occurrence.
+func NewPatternIndex(patterns []string) *PatternIndex {
+	idx := &PatternIndex{
+		nodes:    []patternNode{{fail: 0, output: -1, dict: -1}},
+		patterns: len(patterns),
+	}
+
+	for id, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		node := int32(0)
+		for i := 0; i < len(pattern); i++ {
+			node = idx.child(node, pattern[i], true)
+		}
+		if idx.nodes[node].output < 0 {
+			idx.nodes[node].output = int32(id)
+		}
+	}
+
+	idx.link()
+	return idx
+}
+
+// Len returns the number of patterns the index was built from.
+func (idx *PatternIndex) Len() int {
+	return idx.patterns
+}
+
+// Scan calls fn with the pattern id and end offset of every occurrence of a pattern in text.
+func (idx *PatternIndex) Scan(text string, fn func(pattern, end int)) {
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		node = idx.next(node, text[i])
+		out := node
+		if idx.nodes[out].output < 0 {
+			out = idx.nodes[out].dict
+		}
+		for out > 0 {
+			fn(int(idx.nodes[out].output), i+1)
+			out = idx.nodes[out].dict
+		}
+	}
+}
+
+// child returns the node reached from node over label. When create is set a missing node is
+// added, otherwise -1 is returned.
+func (idx *PatternIndex) child(node int32, label byte, create bool) int32 {
+	edges := idx.nodes[node].edges
+	i := searchEdges(edges, label)
+	if i < len(edges) && edges[i].label == label {
+		return edges[i].node
+	}
+	if !create {
+		return -1
+	}
+
+	next := int32(len(idx.nodes))
+	idx.nodes = append(idx.nodes, patternNode{output: -1, dict: -1})
+	edges = append(edges, patternEdge{})
+	copy(edges[i+1:], edges[i:])
+	edges[i] = patternEdge{label: label, node: next}
+	idx.nodes[node].edges = edges
+	return next
+}
+
+// searchEdges returns the position of label in edges, or where it would be inserted.
+func searchEdges(edges []patternEdge, label byte) int {
+	lo, hi := 0, len(edges)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if edges[mid].label < label {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// next follows fail links until a node with an edge over label is found.
+func (idx *PatternIndex) next(node int32, label byte) int32 {
+	for {
+		if child := idx.child(node, label, false); child >= 0 {
+			return child
+		}
+		if node == 0 {
+			return 0
+		}
+		node = idx.nodes[node].fail
+	}
+}
+
+// link computes the fail and dictionary links breadth-first, so the links of shorter suffixes
+// are known before they are needed.
+func (idx *PatternIndex) link() {
+	queue := make([]int32, 0, len(idx.nodes))
+	for _, edge := range idx.nodes[0].edges {
+		idx.nodes[edge.node].fail = 0
+		queue = append(queue, edge.node)
+	}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range idx.nodes[node].edges {
+			child := edge.node
+			fail := idx.next(idx.nodes[node].fail, edge.label)
+			idx.nodes[child].fail = fail
+			if idx.nodes[fail].output >= 0 {
+				idx.nodes[child].dict = fail
+			} else {
+				idx.nodes[child].dict = idx.nodes[fail].dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
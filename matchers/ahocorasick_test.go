@@ -0,0 +1,150 @@
+package matchers
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type occurrence struct {
+	pattern int
+	end     int
+}
+
+func scanAll(idx *PatternIndex, text string) []occurrence {
+	var found []occurrence
+	idx.Scan(text, func(pattern, end int) {
+		found = append(found, occurrence{pattern, end})
+	})
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].end != found[j].end {
+			return found[i].end < found[j].end
+		}
+		return found[i].pattern < found[j].pattern
+	})
+	return found
+}
+
+func TestPatternIndex(t *testing.T) {
+	t.Run("it finds every occurrence including overlapping patterns", func(t *testing.T) {
+		idx := NewPatternIndex([]string{"he", "she", "his", "hers"})
+
+		assert.Equal(t, []occurrence{{0, 4}, {1, 4}, {3, 6}}, scanAll(idx, "ushers"))
+	})
+
+	t.Run("it finds patterns that are suffixes of other patterns", func(t *testing.T) {
+		idx := NewPatternIndex([]string{"postgres", "gres", "res"})
+
+		assert.Equal(t, []occurrence{{0, 14}, {1, 14}, {2, 14}}, scanAll(idx, "i use postgres"))
+	})
+
+	t.Run("it reports repeated occurrences", func(t *testing.T) {
+		idx := NewPatternIndex([]string{"aa"})
+
+		assert.Equal(t, []occurrence{{0, 2}, {0, 3}, {0, 4}}, scanAll(idx, "aaaa"))
+	})
+
+	t.Run("it ignores empty patterns and reports the first id of duplicates", func(t *testing.T) {
+		idx := NewPatternIndex([]string{"", "crm", "crm"})
+
+		assert.Equal(t, 3, idx.Len())
+		assert.Equal(t, []occurrence{{1, 10}}, scanAll(idx, "a good crm"))
+	})
+
+	t.Run("it matches multi-byte text", func(t *testing.T) {
+		idx := NewPatternIndex([]string{"café", "zürich"})
+
+		assert.Equal(t, []occurrence{{0, 5}, {1, 16}}, scanAll(idx, "café in zürich"))
+	})
+
+	t.Run("it agrees with strings.Contains on random input", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		patterns := make([]string, 200)
+		for i := range patterns {
+			patterns[i] = randomWord(rng, "abc", 1+rng.Intn(4))
+		}
+		idx := NewPatternIndex(patterns)
+
+		for n := 0; n < 100; n++ {
+			text := randomWord(rng, "abcd", rng.Intn(40))
+			found := make(map[string]bool)
+			idx.Scan(text, func(pattern, end int) {
+				found[patterns[pattern]] = true
+			})
+			for _, pattern := range patterns {
+				assert.Equal(t, strings.Contains(text, pattern), found[pattern], "pattern %q in %q", pattern, text)
+			}
+		}
+	})
+}
+
+func randomWord(rng *rand.Rand, alphabet string, length int) string {
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		b.WriteByte(alphabet[rng.Intn(len(alphabet))])
+	}
+	return b.String()
+}
+
+// benchmarkKeywords returns n distinct keywords shaped like the ones users track.
+func benchmarkKeywords(n int) []string {
+	rng := rand.New(rand.NewSource(42))
+	seen := make(map[string]bool, n)
+	keywords := make([]string, 0, n)
+	for len(keywords) < n {
+		keyword := randomWord(rng, "abcdefghijklmnopqrstuvwxyz", 4+rng.Intn(6))
+		if rng.Intn(4) == 0 {
+			keyword += " " + randomWord(rng, "abcdefghijklmnopqrstuvwxyz", 3+rng.Intn(5))
+		}
+		if !seen[keyword] {
+			seen[keyword] = true
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+const benchmarkText = "looking for recommendations on a lightweight crm that integrates with our helpdesk. " +
+	"we are a small team of five and currently track everything in spreadsheets, which is getting painful. " +
+	"ideally it has a decent api, email sync and does not cost a fortune per seat. any experiences welcome!"
+
+func BenchmarkPatternIndexScan(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000, 100000} {
+		idx := NewPatternIndex(benchmarkKeywords(n))
+		b.Run(fmt.Sprintf("keywords=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(benchmarkText)))
+			for i := 0; i < b.N; i++ {
+				idx.Scan(benchmarkText, func(pattern, end int) {})
+			}
+		})
+	}
+}
+
+func BenchmarkContainsPerKeyword(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000, 100000} {
+		keywords := benchmarkKeywords(n)
+		b.Run(fmt.Sprintf("keywords=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(benchmarkText)))
+			for i := 0; i < b.N; i++ {
+				for _, keyword := range keywords {
+					MatchesPartially(benchmarkText, keyword)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNewPatternIndex(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		keywords := benchmarkKeywords(n)
+		b.Run(fmt.Sprintf("keywords=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NewPatternIndex(keywords)
+			}
+		})
+	}
+}
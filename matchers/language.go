@@ -10,10 +10,60 @@ import (
 
 var ErrConflictingLanguageFilters = errors.New("cannot have both include and exclude language filters")
 
-// TODO: Consider playing with WithPreloadedLanguageModels or WithLowAccuracyMode if performance becomes an issue
-var languageDetector = lingua.NewLanguageDetectorBuilder().FromAllLanguages().Build()
+// defaultLanguageDetector serves callers that match a single text without a detector of their own.
+var defaultLanguageDetector = NewLanguageDetector()
+
+// LanguageDetector detects the language of texts.
+type LanguageDetector struct {
+	detector lingua.LanguageDetector
+}
+
+// NewLanguageDetector builds a detector that chooses among all languages. A detector limited to the
+// languages filters refer to attributes text in any other language to the closest of them, often
+// with high confidence, so the confidence cannot be used to tell such text apart either. The
+// language models are shared between detectors and only loaded when a text needs them.
+func NewLanguageDetector() *LanguageDetector {
+	return &LanguageDetector{
+		detector: lingua.NewLanguageDetectorBuilder().FromAllLanguages().Build(),
+	}
+}
+
+// Lazy returns the language of text, detected the first time it is asked for. Sharing it between
+// every filter evaluated against the same text runs the detection at most once.
+func (d *LanguageDetector) Lazy(text string) *DetectedLanguage {
+	return &DetectedLanguage{detector: d, text: text}
+}
+
+// DetectedLanguage is the language of a text, detected on first use.
+type DetectedLanguage struct {
+	detector *LanguageDetector
+	text     string
+	language lingua.Language
+	known    bool
+	detected bool
+}
+
+// Language returns the detected language and whether one could be detected.
+func (l *DetectedLanguage) Language() (lingua.Language, bool) {
+	if !l.detected {
+		l.language, l.known = l.detector.detector.DetectLanguageOf(l.text)
+		l.detected = true
+	}
+	return l.language, l.known
+}
+
+// Detected reports whether the detection has run.
+func (l *DetectedLanguage) Detected() bool {
+	return l.detected
+}
 
 func MatchesLanguage(f data.LanguageFilters, text string) (bool, error) {
+	return MatchesDetectedLanguage(f, defaultLanguageDetector.Lazy(text))
+}
+
+// MatchesDetectedLanguage is MatchesLanguage for a text whose language may already have been
+// detected for another filter.
+func MatchesDetectedLanguage(f data.LanguageFilters, language *DetectedLanguage) (bool, error) {
 	if len(f.Languages) == 0 && len(f.ExcludeLanguages) == 0 {
 		return true, nil
 	}
@@ -22,7 +72,7 @@ func MatchesLanguage(f data.LanguageFilters, text string) (bool, error) {
 		return false, ErrConflictingLanguageFilters
 	}
 
-	textLanguage, exists := languageDetector.DetectLanguageOf(text)
+	textLanguage, exists := language.Language()
 	if !exists {
 		return len(f.Languages) == 0, nil
 	}
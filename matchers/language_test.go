@@ -67,3 +67,47 @@ func TestMatchesLanguage(t *testing.T) {
 		assert.False(t, match)
 	})
 }
+
+func TestLanguageDetector(t *testing.T) {
+	t.Run("it detects languages outside the common ones", func(t *testing.T) {
+		match, err := MatchesDetectedLanguage(data.LanguageFilters{Languages: []string{"sl"}}, NewLanguageDetector().Lazy("To je slovenska poved o podatkovnih bazah."))
+		assert.NoError(t, err)
+		assert.True(t, match)
+	})
+
+	t.Run("it does not attribute text to a filter language it merely resembles", func(t *testing.T) {
+		finnish := "Etsin hyvää ravintolaa Helsingistä, joka tarjoilee kasvisruokaa sunnuntaisin."
+
+		match, err := MatchesDetectedLanguage(data.LanguageFilters{Languages: []string{"nl"}}, NewLanguageDetector().Lazy(finnish))
+		assert.NoError(t, err)
+		assert.False(t, match)
+
+		match, err = MatchesDetectedLanguage(data.LanguageFilters{ExcludeLanguages: []string{"nl"}}, NewLanguageDetector().Lazy(finnish))
+		assert.NoError(t, err)
+		assert.True(t, match)
+	})
+
+	t.Run("it detects the language once and only when a filter needs it", func(t *testing.T) {
+		language := NewLanguageDetector().Lazy("This is a simple english sentence.")
+
+		match, err := MatchesDetectedLanguage(data.LanguageFilters{}, language)
+		assert.NoError(t, err)
+		assert.True(t, match)
+		assert.False(t, language.Detected())
+
+		match, err = MatchesDetectedLanguage(data.LanguageFilters{Languages: []string{"en"}}, language)
+		assert.NoError(t, err)
+		assert.True(t, match)
+		assert.True(t, language.Detected())
+
+		match, err = MatchesDetectedLanguage(data.LanguageFilters{ExcludeLanguages: []string{"en"}}, language)
+		assert.NoError(t, err)
+		assert.False(t, match)
+	})
+
+	t.Run("it tells unreferenced languages apart from referenced ones", func(t *testing.T) {
+		match, err := MatchesDetectedLanguage(data.LanguageFilters{Languages: []string{"en", "de"}}, NewLanguageDetector().Lazy("Esta es una frase en español sobre bases de datos."))
+		assert.NoError(t, err)
+		assert.False(t, match)
+	})
+}
@@ -0,0 +1,464 @@
+package matchers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a parsed boolean keyword query, e.g. `title:"help desk" AND (crm OR erp*) NOT hiring`.
+//
+// Terms are matched as whole words and are case insensitive. Quoted phrases match the words in
+// order, and * in an unquoted term matches any run of word characters. Adjacent terms are
+// combined with AND, which binds tighter than OR; NOT negates the term or group that follows.
+// A term may be prefixed with a field: title:, body: or subreddit:. Unprefixed terms search the
+// title and the body, and subreddit: terms match the whole subreddit name.
+type Query struct {
+	Root *QueryNode
+}
+
+type QueryOp uint8
+
+const (
+	QueryTerm QueryOp = iota
+	QueryAnd
+	QueryOr
+	QueryNot
+)
+
+// QueryNode is a node of a parsed query. Terms have a Value and operators have Children.
+type QueryNode struct {
+	Op       QueryOp
+	Children []*QueryNode
+
+	// Field is the field a term is restricted to, or empty for the title and body.
+	Field string
+	// Value is the lower case term or phrase.
+	Value    string
+	Phrase   bool
+	Wildcard bool
+	// Pos is the 1-based character position of the node in the query.
+	Pos int
+
+	pattern *regexp.Regexp
+	// words are the words of a phrase, matched in order with any whitespace between them.
+	words []string
+}
+
+// QueryError is a syntax error at a 1-based character position of the query.
+type QueryError struct {
+	Pos     int
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Message)
+}
+
+const (
+	QueryFieldTitle     = "title"
+	QueryFieldBody      = "body"
+	QueryFieldSubreddit = "subreddit"
+)
+
+var queryFields = map[string]bool{
+	QueryFieldTitle:     true,
+	QueryFieldBody:      true,
+	QueryFieldSubreddit: true,
+}
+
+// ParseQuery parses a keyword query. A query must contain at least one term that is not negated,
+// since a query made only of exclusions would match nearly everything.
+func ParseQuery(input string) (*Query, error) {
+	tokens, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != queryTokenEOF {
+		return nil, &QueryError{Pos: tok.pos, Message: fmt.Sprintf("unexpected %s", tok.describe())}
+	}
+	if !root.hasPositiveTerm() {
+		return nil, &QueryError{Pos: root.Pos, Message: "query must contain a term that is not negated"}
+	}
+
+	return &Query{Root: root}, nil
+}
+
+// QueryInput is the part of an item a query is matched against.
+type QueryInput struct {
+	Title     string
+	Body      string
+	Subreddit string
+}
+
+// Matches reports whether the input matches the query.
+func (q *Query) Matches(input QueryInput) bool {
+	values := queryValues{
+		title:     strings.ToLower(input.Title),
+		body:      strings.ToLower(input.Body),
+		subreddit: strings.ToLower(strings.TrimSpace(input.Subreddit)),
+	}
+	return q.Root.matches(&values)
+}
+
+type queryValues struct {
+	title     string
+	body      string
+	subreddit string
+}
+
+func (n *QueryNode) matches(values *queryValues) bool {
+	switch n.Op {
+	case QueryAnd:
+		for _, child := range n.Children {
+			if !child.matches(values) {
+				return false
+			}
+		}
+		return true
+	case QueryOr:
+		for _, child := range n.Children {
+			if child.matches(values) {
+				return true
+			}
+		}
+		return false
+	case QueryNot:
+		return !n.Children[0].matches(values)
+	default:
+		switch n.Field {
+		case QueryFieldTitle:
+			return n.matchesText(values.title)
+		case QueryFieldBody:
+			return n.matchesText(values.body)
+		case QueryFieldSubreddit:
+			if n.pattern != nil {
+				return n.pattern.MatchString(values.subreddit)
+			}
+			return values.subreddit == n.Value
+		default:
+			return n.matchesText(values.title) || n.matchesText(values.body)
+		}
+	}
+}
+
+func (n *QueryNode) matchesText(text string) bool {
+	if n.pattern != nil {
+		return n.pattern.MatchString(text)
+	}
+	if n.Phrase {
+		return matchesPhraseWords(text, n.words)
+	}
+	return MatchesWholeWord(text, n.Value)
+}
+
+// matchesPhraseWords reports whether the words occur in text in order, separated by whitespace
+// only, with word boundaries around the phrase. Any run of whitespace separates two words, so a
+// phrase also matches across line breaks.
+func matchesPhraseWords(text string, words []string) bool {
+	for idx := 0; idx < len(text); {
+		pos := strings.Index(text[idx:], words[0])
+		if pos == -1 {
+			return false
+		}
+		pos += idx
+		if pos == 0 || !isWordChar(rune(text[pos-1])) {
+			if end, ok := matchPhraseRest(text, pos+len(words[0]), words[1:]); ok {
+				if end == len(text) || !isWordChar(rune(text[end])) {
+					return true
+				}
+			}
+		}
+		idx = pos + 1
+	}
+	return false
+}
+
+// matchPhraseRest matches the remaining words of a phrase from end, returning where they end.
+func matchPhraseRest(text string, end int, words []string) (int, bool) {
+	for _, word := range words {
+		next := end
+		for next < len(text) {
+			r, size := utf8.DecodeRuneInString(text[next:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			next += size
+		}
+		if next == end || !strings.HasPrefix(text[next:], word) {
+			return 0, false
+		}
+		end = next + len(word)
+	}
+	return end, true
+}
+
+func (n *QueryNode) hasPositiveTerm() bool {
+	switch n.Op {
+	case QueryAnd:
+		for _, child := range n.Children {
+			if child.hasPositiveTerm() {
+				return true
+			}
+		}
+		return false
+	case QueryOr:
+		for _, child := range n.Children {
+			if !child.hasPositiveTerm() {
+				return false
+			}
+		}
+		return true
+	case QueryNot:
+		return false
+	default:
+		return true
+	}
+}
+
+// compileQueryWildcard builds the pattern of a wildcard term. Subreddit terms match the whole
+// name, other terms match whole words.
+func compileQueryWildcard(field, value string) *regexp.Regexp {
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	body := strings.Join(parts, `[\p{L}\p{N}_]*`)
+	if field == QueryFieldSubreddit {
+		return regexp.MustCompile(`^` + body + `$`)
+	}
+	return regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])` + body + `(?:$|[^\p{L}\p{N}_])`)
+}
+
+type queryTokenKind uint8
+
+const (
+	queryTokenEOF queryTokenKind = iota
+	queryTokenWord
+	queryTokenPhrase
+	queryTokenField
+	queryTokenAnd
+	queryTokenOr
+	queryTokenNot
+	queryTokenOpen
+	queryTokenClose
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	value string
+	pos   int
+}
+
+func (t queryToken) describe() string {
+	switch t.kind {
+	case queryTokenEOF:
+		return "end of query"
+	case queryTokenPhrase:
+		return fmt.Sprintf("phrase %q", t.value)
+	case queryTokenField:
+		return fmt.Sprintf("field %s:", t.value)
+	case queryTokenOpen:
+		return `"("`
+	case queryTokenClose:
+		return `")"`
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// lexQuery splits the input into tokens. Operators are only recognized in upper case, so the
+// words "and", "or" and "not" can still be searched for.
+func lexQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	pos := 0
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		pos++
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenOpen, value: "(", pos: pos})
+			i += size
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenClose, value: ")", pos: pos})
+			i += size
+		case r == '"':
+			start := pos
+			end := strings.IndexByte(input[i+1:], '"')
+			if end < 0 {
+				return nil, &QueryError{Pos: start, Message: "unterminated phrase"}
+			}
+			phrase := input[i+1 : i+1+end]
+			tokens = append(tokens, queryToken{kind: queryTokenPhrase, value: phrase, pos: start})
+			pos += utf8.RuneCountInString(phrase) + 1
+			i += end + 2
+		default:
+			start, startPos := i, pos
+			for i += size; i < len(input); i += size {
+				r, size = utf8.DecodeRuneInString(input[i:])
+				if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' || r == ':' {
+					break
+				}
+				pos++
+			}
+			word := input[start:i]
+			if i < len(input) && input[i] == ':' {
+				field := strings.ToLower(word)
+				if !queryFields[field] {
+					return nil, &QueryError{Pos: startPos, Message: fmt.Sprintf("unknown field %q, expected title, body or subreddit", word)}
+				}
+				tokens = append(tokens, queryToken{kind: queryTokenField, value: field, pos: startPos})
+				pos++
+				i++
+				continue
+			}
+			kind := queryTokenWord
+			switch word {
+			case "AND":
+				kind = queryTokenAnd
+			case "OR":
+				kind = queryTokenOr
+			case "NOT":
+				kind = queryTokenNot
+			}
+			tokens = append(tokens, queryToken{kind: kind, value: word, pos: startPos})
+		}
+	}
+	return append(tokens, queryToken{kind: queryTokenEOF, pos: pos + 1}), nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	next   int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() queryToken {
+	tok := p.tokens[p.next]
+	if tok.kind != queryTokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// parseOr parses terms separated by OR.
+func (p *queryParser) parseOr() (*QueryNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []*QueryNode{first}
+	for p.peek().kind == queryTokenOr {
+		p.advance()
+		child, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return groupQueryNodes(QueryOr, children), nil
+}
+
+// parseAnd parses terms separated by AND or by nothing at all.
+func (p *queryParser) parseAnd() (*QueryNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []*QueryNode{first}
+	for {
+		switch p.peek().kind {
+		case queryTokenAnd:
+			p.advance()
+		case queryTokenWord, queryTokenPhrase, queryTokenField, queryTokenNot, queryTokenOpen:
+		default:
+			return groupQueryNodes(QueryAnd, children), nil
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+}
+
+func (p *queryParser) parseUnary() (*QueryNode, error) {
+	if tok := p.peek(); tok.kind == queryTokenNot {
+		p.advance()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &QueryNode{Op: QueryNot, Children: []*QueryNode{child}, Pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (*QueryNode, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case queryTokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.peek(); closing.kind != queryTokenClose {
+			return nil, &QueryError{Pos: closing.pos, Message: fmt.Sprintf("expected \")\" to close the group opened at position %d, found %s", tok.pos, closing.describe())}
+		}
+		p.advance()
+		return node, nil
+	case queryTokenField:
+		term := p.advance()
+		if term.kind != queryTokenWord && term.kind != queryTokenPhrase {
+			return nil, &QueryError{Pos: term.pos, Message: fmt.Sprintf("expected a term after %s:, found %s", tok.value, term.describe())}
+		}
+		return newQueryTerm(tok.value, term, tok.pos)
+	case queryTokenWord, queryTokenPhrase:
+		return newQueryTerm("", tok, tok.pos)
+	default:
+		return nil, &QueryError{Pos: tok.pos, Message: fmt.Sprintf("expected a term, found %s", tok.describe())}
+	}
+}
+
+func newQueryTerm(field string, tok queryToken, pos int) (*QueryNode, error) {
+	node := &QueryNode{Op: QueryTerm, Field: field, Pos: pos}
+	if tok.kind == queryTokenPhrase {
+		node.words = strings.Fields(strings.ToLower(tok.value))
+		node.Value = strings.Join(node.words, " ")
+		node.Phrase = true
+		if node.Value == "" {
+			return nil, &QueryError{Pos: tok.pos, Message: "empty phrase"}
+		}
+		return node, nil
+	}
+
+	node.Value = strings.ToLower(tok.value)
+	if strings.Contains(node.Value, "*") {
+		if strings.Trim(node.Value, "*") == "" {
+			return nil, &QueryError{Pos: tok.pos, Message: "a wildcard needs at least one other character"}
+		}
+		node.Wildcard = true
+		node.pattern = compileQueryWildcard(field, node.Value)
+	}
+	return node, nil
+}
+
+func groupQueryNodes(op QueryOp, children []*QueryNode) *QueryNode {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return &QueryNode{Op: op, Children: children, Pos: children[0].Pos}
+}
@@ -0,0 +1,129 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Run("it binds AND tighter than OR and groups with parentheses", func(t *testing.T) {
+		query, err := ParseQuery(`crm OR help desk AND NOT (jobs OR hiring)`)
+		require.NoError(t, err)
+
+		root := query.Root
+		require.Equal(t, QueryOr, root.Op)
+		require.Len(t, root.Children, 2)
+		assert.Equal(t, "crm", root.Children[0].Value)
+
+		and := root.Children[1]
+		require.Equal(t, QueryAnd, and.Op)
+		require.Len(t, and.Children, 3)
+		assert.Equal(t, "help", and.Children[0].Value)
+		assert.Equal(t, "desk", and.Children[1].Value)
+		assert.Equal(t, QueryNot, and.Children[2].Op)
+		assert.Equal(t, QueryOr, and.Children[2].Children[0].Op)
+		assert.Equal(t, 22, and.Children[2].Pos)
+	})
+
+	t.Run("it parses fields, phrases and wildcards", func(t *testing.T) {
+		query, err := ParseQuery(`title:"Help  Desk" subreddit:SelfHosted postgr*`)
+		require.NoError(t, err)
+
+		terms := query.Root.Children
+		require.Len(t, terms, 3)
+		assert.Equal(t, &QueryNode{Op: QueryTerm, Field: "title", Value: "help desk", Phrase: true, Pos: 1, words: []string{"help", "desk"}}, terms[0])
+		assert.Equal(t, "subreddit", terms[1].Field)
+		assert.Equal(t, "selfhosted", terms[1].Value)
+		assert.True(t, terms[2].Wildcard)
+		assert.Equal(t, 41, terms[2].Pos)
+	})
+
+	t.Run("it treats lower case operators as words", func(t *testing.T) {
+		query, err := ParseQuery(`salt and pepper`)
+		require.NoError(t, err)
+
+		assert.Equal(t, QueryAnd, query.Root.Op)
+		assert.Len(t, query.Root.Children, 3)
+	})
+
+	t.Run("it reports the position of syntax errors", func(t *testing.T) {
+		cases := []struct {
+			query   string
+			pos     int
+			message string
+		}{
+			{`crm AND`, 8, "expected a term, found end of query"},
+			{`(crm OR erp`, 12, `expected ")" to close the group opened at position 1, found end of query`},
+			{`crm "help desk`, 5, "unterminated phrase"},
+			{`author:bob`, 1, `unknown field "author", expected title, body or subreddit`},
+			{`crm)`, 4, `unexpected ")"`},
+			{`title: OR crm`, 8, `expected a term after title:, found "OR"`},
+			{`crm *`, 5, "a wildcard needs at least one other character"},
+			{`crm ""`, 5, "empty phrase"},
+			{`NOT crm`, 1, "query must contain a term that is not negated"},
+			{`zürich AND`, 11, "expected a term, found end of query"},
+		}
+
+		for _, c := range cases {
+			_, err := ParseQuery(c.query)
+
+			var queryErr *QueryError
+			require.ErrorAs(t, err, &queryErr, c.query)
+			assert.Equal(t, c.pos, queryErr.Pos, c.query)
+			assert.Equal(t, c.message, queryErr.Message, c.query)
+		}
+	})
+}
+
+func TestQueryMatches(t *testing.T) {
+	matches := func(t *testing.T, query string, input QueryInput) bool {
+		t.Helper()
+		parsed, err := ParseQuery(query)
+		require.NoError(t, err)
+		return parsed.Matches(input)
+	}
+
+	t.Run("it matches terms as whole words in the title or body", func(t *testing.T) {
+		assert.True(t, matches(t, `crm`, QueryInput{Title: "Which CRM?"}))
+		assert.True(t, matches(t, `crm`, QueryInput{Body: "a crm, please"}))
+		assert.False(t, matches(t, `crm`, QueryInput{Title: "crms"}))
+	})
+
+	t.Run("it applies boolean operators", func(t *testing.T) {
+		query := `(crm OR "help desk") AND NOT hiring`
+
+		assert.True(t, matches(t, query, QueryInput{Title: "Looking for a help desk"}))
+		assert.False(t, matches(t, query, QueryInput{Title: "CRM team is hiring"}))
+		assert.False(t, matches(t, query, QueryInput{Title: "help with my desk"}))
+	})
+
+	t.Run("it restricts terms to their field", func(t *testing.T) {
+		input := QueryInput{Title: "Postgres tips", Body: "about a crm", Subreddit: "SelfHosted"}
+
+		assert.True(t, matches(t, `title:postgres body:crm`, input))
+		assert.False(t, matches(t, `title:crm`, input))
+		assert.True(t, matches(t, `subreddit:selfhosted`, input))
+		assert.False(t, matches(t, `subreddit:self`, input))
+	})
+
+	t.Run("it expands wildcards within a word", func(t *testing.T) {
+		assert.True(t, matches(t, `postgr*`, QueryInput{Title: "PostgreSQL 17 released"}))
+		assert.True(t, matches(t, `*sql`, QueryInput{Title: "mysql or sqlite"}))
+		assert.False(t, matches(t, `postgr*`, QueryInput{Title: "post graduate"}))
+		assert.True(t, matches(t, `subreddit:self*`, QueryInput{Subreddit: "selfhosted"}))
+		assert.False(t, matches(t, `c*m`, QueryInput{Title: "customer team"}))
+	})
+
+	t.Run("it matches phrases across any whitespace", func(t *testing.T) {
+		query := `"help  desk"`
+
+		assert.True(t, matches(t, query, QueryInput{Body: "need a help\n\tdesk tool"}))
+		assert.True(t, matches(t, query, QueryInput{Body: "need a help  desk"}))
+		assert.False(t, matches(t, query, QueryInput{Body: "helpdesk"}))
+		assert.False(t, matches(t, query, QueryInput{Body: "help, desk"}))
+		assert.False(t, matches(t, query, QueryInput{Body: "self help desks"}))
+		assert.True(t, matches(t, query, QueryInput{Body: "help help desk"}))
+	})
+}
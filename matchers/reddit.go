@@ -34,3 +34,89 @@ func MatchesSubreddit(f data.RedditFilters, subreddit string) (bool, error) {
 
 	return true, nil
 }
+
+// RedditPost is the post metadata the post filters are applied to.
+type RedditPost struct {
+	NSFW   bool
+	Flair  string
+	IsSelf bool
+	Domain string
+}
+
+// HasPostFilters reports whether any of the NSFW, flair, domain and post type filters is set.
+func HasPostFilters(f data.RedditFilters) bool {
+	return f.ExcludeNSFW ||
+		len(f.Flairs) > 0 || len(f.ExcludeFlairs) > 0 ||
+		len(f.Domains) > 0 || len(f.ExcludeDomains) > 0 ||
+		f.PostType != ""
+}
+
+// MatchesRedditPost applies the NSFW, flair, domain and post type filters to a post.
+// Include lists are checked before exclude lists, and domain filters only apply to link posts.
+func MatchesRedditPost(f data.RedditFilters, post RedditPost) bool {
+	if f.ExcludeNSFW && post.NSFW {
+		return false
+	}
+
+	switch f.PostType {
+	case data.RedditPostTypeSelf:
+		if !post.IsSelf {
+			return false
+		}
+	case data.RedditPostTypeLink:
+		if post.IsSelf {
+			return false
+		}
+	}
+
+	if len(f.Flairs) > 0 && !containsFold(f.Flairs, post.Flair) {
+		return false
+	}
+	if post.Flair != "" && containsFold(f.ExcludeFlairs, post.Flair) {
+		return false
+	}
+
+	if post.IsSelf {
+		return true
+	}
+	domain := normalizeDomain(post.Domain)
+	if len(f.Domains) > 0 && !matchesDomain(f.Domains, domain) {
+		return false
+	}
+	if domain != "" && matchesDomain(f.ExcludeDomains, domain) {
+		return false
+	}
+	return true
+}
+
+func containsFold(items []string, target string) bool {
+	target = strings.TrimSpace(target)
+	for _, item := range items {
+		if strings.EqualFold(strings.TrimSpace(item), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDomain reports whether domain is one of domains or a subdomain of one of them.
+func matchesDomain(domains []string, domain string) bool {
+	if domain == "" {
+		return false
+	}
+	for _, candidate := range domains {
+		candidate = normalizeDomain(candidate)
+		if candidate == "" {
+			continue
+		}
+		if domain == candidate || strings.HasSuffix(domain, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	return strings.TrimPrefix(domain, "www.")
+}
@@ -83,3 +83,73 @@ func TestMatchesSubreddit(t *testing.T) {
 		assert.False(t, match)
 	})
 }
+
+func TestMatchesRedditPost(t *testing.T) {
+	selfPost := RedditPost{Flair: "Question", IsSelf: true, Domain: "self.golang"}
+	linkPost := RedditPost{Flair: "News", Domain: "www.github.com"}
+
+	t.Run("it matches any post when no post filters are provided", func(t *testing.T) {
+		filters := data.RedditFilters{}
+
+		assert.True(t, MatchesRedditPost(filters, selfPost))
+		assert.True(t, MatchesRedditPost(filters, linkPost))
+	})
+
+	t.Run("it rejects nsfw posts when nsfw is excluded", func(t *testing.T) {
+		filters := data.RedditFilters{ExcludeNSFW: true}
+
+		assert.False(t, MatchesRedditPost(filters, RedditPost{NSFW: true, IsSelf: true}))
+		assert.True(t, MatchesRedditPost(filters, selfPost))
+	})
+
+	t.Run("it applies flair include and exclude lists case insensitively", func(t *testing.T) {
+		include := data.RedditFilters{Flairs: []string{"question"}}
+		exclude := data.RedditFilters{ExcludeFlairs: []string{"NEWS"}}
+
+		assert.True(t, MatchesRedditPost(include, selfPost))
+		assert.False(t, MatchesRedditPost(include, linkPost))
+		assert.False(t, MatchesRedditPost(include, RedditPost{IsSelf: true}))
+		assert.True(t, MatchesRedditPost(exclude, selfPost))
+		assert.False(t, MatchesRedditPost(exclude, linkPost))
+	})
+
+	t.Run("it applies domain filters to link posts including subdomains", func(t *testing.T) {
+		include := data.RedditFilters{Domains: []string{"github.com"}}
+		exclude := data.RedditFilters{ExcludeDomains: []string{"github.com"}}
+
+		assert.True(t, MatchesRedditPost(include, linkPost))
+		assert.True(t, MatchesRedditPost(include, RedditPost{Domain: "gist.github.com"}))
+		assert.False(t, MatchesRedditPost(include, RedditPost{Domain: "notgithub.com"}))
+		assert.False(t, MatchesRedditPost(exclude, linkPost))
+		assert.True(t, MatchesRedditPost(exclude, RedditPost{Domain: "gitlab.com"}))
+	})
+
+	t.Run("it does not apply domain filters to self posts", func(t *testing.T) {
+		filters := data.RedditFilters{Domains: []string{"github.com"}}
+
+		assert.True(t, MatchesRedditPost(filters, selfPost))
+	})
+
+	t.Run("it filters by post type", func(t *testing.T) {
+		selfOnly := data.RedditFilters{PostType: data.RedditPostTypeSelf}
+		linkOnly := data.RedditFilters{PostType: data.RedditPostTypeLink}
+
+		assert.True(t, MatchesRedditPost(selfOnly, selfPost))
+		assert.False(t, MatchesRedditPost(selfOnly, linkPost))
+		assert.False(t, MatchesRedditPost(linkOnly, selfPost))
+		assert.True(t, MatchesRedditPost(linkOnly, linkPost))
+	})
+}
+
+func TestHasPostFilters(t *testing.T) {
+	t.Run("it ignores the subreddit filters", func(t *testing.T) {
+		assert.False(t, HasPostFilters(data.RedditFilters{Subreddits: []string{"golang"}, ExcludeSubreddits: []string{"test"}}))
+	})
+
+	t.Run("it reports any post filter", func(t *testing.T) {
+		assert.True(t, HasPostFilters(data.RedditFilters{ExcludeNSFW: true}))
+		assert.True(t, HasPostFilters(data.RedditFilters{ExcludeFlairs: []string{"Meme"}}))
+		assert.True(t, HasPostFilters(data.RedditFilters{Domains: []string{"github.com"}}))
+		assert.True(t, HasPostFilters(data.RedditFilters{PostType: data.RedditPostTypeSelf}))
+	})
+}
@@ -1,18 +1,25 @@
 package matchers
 
 import (
-	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/kova98/feedgrep.api/data"
-	"github.com/pemistahl/lingua-go"
 )
 
 type SmartInput struct {
 	Title     string
 	Body      string
 	Subreddit string
+	Instance  string
+	Labels    []string
+	Tags      []string
+
+	// Language is the language of the title and body, shared between the filters evaluated on the
+	// same item. When nil it is detected on demand.
+	Language *DetectedLanguage
+
+	// ParentTitle is the title of the post a comment was made on.
+	ParentTitle string
 }
 
 type SmartMatchResult struct {
@@ -47,61 +54,17 @@ func MatchesSmart(filter data.SmartFilter, input SmartInput) (bool, error) {
 	return result.Matched, nil
 }
 
+// EvaluateSmart compiles the filter and evaluates the input against it. Callers evaluating the
+// same filter repeatedly should compile it once with CompileSmartFilter.
 func EvaluateSmart(filter data.SmartFilter, input SmartInput) (SmartMatchResult, error) {
-	result := SmartMatchResult{
-		AcceptMinScore: filter.Thresholds.AcceptMinScore,
-	}
-
-	if !matchesScopeList(filter.Scope.Subreddits, input.Subreddit) {
-		result.RejectedBy = "subreddit_scope"
-		return result, nil
-	}
-
-	candidateMatched, candidateDetails, err := evaluateSmartRule(filter.Candidate, input)
+	compiled, err := CompileSmartFilter(filter)
 	if err != nil {
-		return result, err
-	}
-	result.CandidateMatched = candidateMatched
-	result.CandidateDetails = candidateDetails
-	if !candidateMatched {
-		result.RejectedBy = "candidate"
-		return result, nil
-	}
-
-	text := strings.TrimSpace(strings.TrimSpace(input.Title) + "\n" + strings.TrimSpace(input.Body))
-	if !matchesLanguageScope(filter.Scope.Language, text) {
-		result.RejectedBy = "language_scope"
-		return result, nil
-	}
-
-	for _, signal := range filter.Signals {
-		matched, details, err := evaluateSmartRule(data.SmartRule{
-			Where:     signal.Where,
-			Condition: signal.Condition,
-		}, input)
-		if err != nil {
-			return result, err
-		}
-		if matched {
-			result.Score += signal.Weight
-			result.MatchedSignals = append(result.MatchedSignals, signal.Name)
-			result.SignalDetails = append(result.SignalDetails, SmartSignalMatchDetail{
-				Name:          signal.Name,
-				Weight:        signal.Weight,
-				MatchedFields: details,
-			})
-		}
-	}
-
-	result.Matched = result.Score >= result.AcceptMinScore
-	if !result.Matched {
-		result.RejectedBy = "score_threshold"
+		return SmartMatchResult{AcceptMinScore: filter.Thresholds.AcceptMinScore}, err
 	}
-
-	return result, nil
+	return compiled.Evaluate(input), nil
 }
 
-func matchesLanguageScope(scope data.SmartScopeList, text string) bool {
+func matchesLanguageScope(scope data.SmartScopeList, text string, language *DetectedLanguage) bool {
 	if len(scope.Include) == 0 && len(scope.Exclude) == 0 {
 		return true
 	}
@@ -109,8 +72,11 @@ func matchesLanguageScope(scope data.SmartScopeList, text string) bool {
 		return len(scope.Include) == 0
 	}
 
-	detected, err := detectLanguage(text)
-	if err != nil {
+	if language == nil {
+		language = defaultLanguageDetector.Lazy(text)
+	}
+	detected, ok := language.Language()
+	if !ok {
 		return len(scope.Include) == 0
 	}
 
@@ -136,147 +102,16 @@ func matchesLanguageScope(scope data.SmartScopeList, text string) bool {
 	return true
 }
 
-func detectLanguage(text string) (lingua.Language, error) {
-	textLanguage, exists := languageDetector.DetectLanguageOf(text)
-	if !exists {
-		return lingua.English, fmt.Errorf("language undetected")
-	}
-	return textLanguage, nil
-}
-
-func matchesScopeList(scope data.SmartScopeList, value string) bool {
-	normalized := normalizeSmartValue(value)
-	if normalized == "" {
-		return len(scope.Include) == 0
-	}
-
-	if len(scope.Include) > 0 && !containsNormalized(scope.Include, normalized) {
-		return false
-	}
-	if len(scope.Exclude) > 0 && containsNormalized(scope.Exclude, normalized) {
-		return false
-	}
-	return true
-}
-
-func containsNormalized(items []string, target string) bool {
-	for _, item := range items {
-		if normalizeSmartValue(item) == target {
-			return true
-		}
-	}
-	return false
-}
-
 func normalizeSmartValue(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
 }
 
-func evaluateSmartRule(rule data.SmartRule, input SmartInput) (bool, []SmartRuleMatchDetail, error) {
-	if isEmptySmartCondition(rule.Condition) {
-		return false, nil, nil
-	}
-
-	fields := rule.Where
-	if len(fields) == 0 {
-		fields = []string{"title", "body"}
-	}
-
-	return evaluateSmartCondition(rule.Condition, fields, input)
-}
-
-func evaluateSmartCondition(condition data.SmartCondition, fields []string, input SmartInput) (bool, []SmartRuleMatchDetail, error) {
-	if len(condition.Any) > 0 {
-		for _, child := range condition.Any {
-			matched, details, err := evaluateSmartCondition(child, fields, input)
-			if err != nil {
-				return false, nil, err
-			}
-			if matched {
-				return true, details, nil
-			}
-		}
-		return false, nil, nil
-	}
-
-	if len(condition.All) > 0 {
-		allDetails := make([]SmartRuleMatchDetail, 0)
-		for _, child := range condition.All {
-			matched, details, err := evaluateSmartCondition(child, fields, input)
-			if err != nil {
-				return false, nil, err
-			}
-			if !matched {
-				return false, nil, nil
-			}
-			allDetails = append(allDetails, details...)
-		}
-		return true, allDetails, nil
-	}
-
-	if len(condition.AnyPhrase) > 0 {
-		for _, field := range fields {
-			originalValue := fieldValue(field, input)
-			value := strings.ToLower(originalValue)
-			if value == "" {
-				continue
-			}
-			for _, phrase := range condition.AnyPhrase {
-				normalizedPhrase := strings.ToLower(strings.TrimSpace(phrase))
-				if strings.Contains(value, normalizedPhrase) {
-					return true, []SmartRuleMatchDetail{{
-						Field:       field,
-						MatchType:   "anyPhrase",
-						MatchedTerm: phrase,
-						MatchedText: originalValue,
-					}}, nil
-				}
-			}
-		}
-	}
-
-	if len(condition.Regex) > 0 {
-		for _, field := range fields {
-			value := fieldValue(field, input)
-			if value == "" {
-				continue
-			}
-			for _, pattern := range condition.Regex {
-				re, err := regexp.Compile("(?i)" + pattern)
-				if err != nil {
-					return false, nil, err
-				}
-				if re.MatchString(value) {
-					return true, []SmartRuleMatchDetail{{
-						Field:       field,
-						MatchType:   "regex",
-						MatchedTerm: pattern,
-						MatchedText: value,
-					}}, nil
-				}
-			}
-		}
-	}
-
-	return false, nil, nil
-}
-
-func fieldValue(field string, input SmartInput) string {
-	switch strings.ToLower(strings.TrimSpace(field)) {
-	case "title":
-		return input.Title
-	case "body":
-		return input.Body
-	case "subreddit":
-		return input.Subreddit
-	default:
-		return ""
-	}
-}
-
 func isEmptySmartCondition(condition data.SmartCondition) bool {
 	return len(condition.Any) == 0 &&
 		len(condition.All) == 0 &&
+		len(condition.None) == 0 &&
+		condition.Near == nil &&
+		condition.CountAtLeast == nil &&
 		len(condition.AnyPhrase) == 0 &&
 		len(condition.Regex) == 0
 }
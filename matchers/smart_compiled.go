@@ -0,0 +1,863 @@
+package matchers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kova98/feedgrep.api/data"
+)
+
+// CompiledSmartFilter is a SmartFilter prepared for repeated evaluation: regexes are compiled,
+// phrases and scope lists are normalized and where fields are resolved, so evaluating an item
+// does no per-item setup.
+type CompiledSmartFilter struct {
+	subreddits     compiledScopeList
+	instances      compiledScopeList
+	tags           compiledScopeList
+	language       data.SmartScopeList
+	candidate      compiledRule
+	signals        []compiledSignal
+	acceptMinScore int
+}
+
+type compiledScopeList struct {
+	include []string
+	exclude []string
+}
+
+type compiledRule struct {
+	fields    []smartField
+	condition compiledCondition
+	empty     bool
+}
+
+type compiledSignal struct {
+	name   string
+	weight int
+	rule   compiledRule
+}
+
+// compiledCondition keeps the evaluation order of SmartCondition: any, all, none, near,
+// countAtLeast, then phrases and regexes.
+type compiledCondition struct {
+	any     []compiledCondition
+	all     []compiledCondition
+	none    []compiledCondition
+	near    *compiledNear
+	count   *compiledCount
+	phrases []compiledPhrase
+	regexes []compiledRegex
+}
+
+type compiledNear struct {
+	terms []string
+	// words are the lower case words of each term.
+	words    [][]string
+	distance int
+	ordered  bool
+}
+
+type compiledCount struct {
+	count   int
+	phrases []compiledPhrase
+}
+
+type compiledPhrase struct {
+	term       string
+	normalized string
+}
+
+type compiledRegex struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+type smartField struct {
+	name string
+	kind smartFieldKind
+}
+
+type smartFieldKind uint8
+
+const (
+	smartFieldTitle smartFieldKind = iota
+	smartFieldBody
+	smartFieldSubreddit
+	smartFieldLabels
+	smartFieldParentTitle
+	smartFieldCount
+)
+
+var smartFieldKinds = map[string]smartFieldKind{
+	"title":       smartFieldTitle,
+	"body":        smartFieldBody,
+	"subreddit":   smartFieldSubreddit,
+	"labels":      smartFieldLabels,
+	"parenttitle": smartFieldParentTitle,
+}
+
+var defaultSmartFields = []smartField{
+	{name: "title", kind: smartFieldTitle},
+	{name: "body", kind: smartFieldBody},
+}
+
+// CompileSmartFilter validates and compiles the filter. Errors name the path of the offending
+// part, e.g. "signals[1].condition.any[0].regex[2]".
+func CompileSmartFilter(filter data.SmartFilter) (*CompiledSmartFilter, error) {
+	c := &smartCompiler{}
+	return c.compile(filter)
+}
+
+// CompileStoredSmartFilter compiles a filter that was already stored, which may not pass the
+// validation of CompileSmartFilter if it was saved by an older version. Invalid parts are left out
+// instead, as before validation existed: unknown where fields are ignored and invalid regexes,
+// near and countAtLeast conditions never match. It returns the problems it left out.
+func CompileStoredSmartFilter(filter data.SmartFilter) (*CompiledSmartFilter, []string) {
+	c := &smartCompiler{lenient: true}
+	compiled, _ := c.compile(filter)
+	return compiled, c.problems
+}
+
+// smartCompiler compiles smart filters. A lenient compiler records problems instead of failing.
+type smartCompiler struct {
+	lenient  bool
+	problems []string
+}
+
+// invalid returns err, or records it and returns nil when the compiler is lenient.
+func (c *smartCompiler) invalid(err error) error {
+	if !c.lenient {
+		return err
+	}
+	c.problems = append(c.problems, err.Error())
+	return nil
+}
+
+func (c *smartCompiler) compile(filter data.SmartFilter) (*CompiledSmartFilter, error) {
+	candidate, err := c.compileRule("candidate", filter.Candidate.Where, filter.Candidate.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := &CompiledSmartFilter{
+		subreddits:     compileScopeList(filter.Scope.Subreddits),
+		instances:      compileScopeList(filter.Scope.Instances),
+		tags:           compileScopeList(filter.Scope.Tags),
+		language:       filter.Scope.Language,
+		candidate:      candidate,
+		signals:        make([]compiledSignal, 0, len(filter.Signals)),
+		acceptMinScore: filter.Thresholds.AcceptMinScore,
+	}
+	for i, signal := range filter.Signals {
+		rule, err := c.compileRule(fmt.Sprintf("signals[%d]", i), signal.Where, signal.Condition)
+		if err != nil {
+			return nil, err
+		}
+		compiled.signals = append(compiled.signals, compiledSignal{
+			name:   signal.Name,
+			weight: signal.Weight,
+			rule:   rule,
+		})
+	}
+
+	return compiled, nil
+}
+
+func compileScopeList(scope data.SmartScopeList) compiledScopeList {
+	return compiledScopeList{
+		include: normalizeSmartValues(scope.Include),
+		exclude: normalizeSmartValues(scope.Exclude),
+	}
+}
+
+func normalizeSmartValues(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		normalized = append(normalized, normalizeSmartValue(value))
+	}
+	return normalized
+}
+
+func (c *smartCompiler) compileRule(path string, where []string, condition data.SmartCondition) (compiledRule, error) {
+	rule := compiledRule{fields: defaultSmartFields}
+	if len(where) > 0 {
+		rule.fields = make([]smartField, 0, len(where))
+		for i, name := range where {
+			kind, ok := smartFieldKinds[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				if err := c.invalid(fmt.Errorf("%s.where[%d]: unknown field %q", path, i, name)); err != nil {
+					return compiledRule{}, err
+				}
+				continue
+			}
+			rule.fields = append(rule.fields, smartField{name: name, kind: kind})
+		}
+	}
+
+	rule.empty = isEmptySmartCondition(condition)
+	compiled, err := c.compileCondition(path+".condition", condition)
+	if err != nil {
+		return compiledRule{}, err
+	}
+	rule.condition = compiled
+	return rule, nil
+}
+
+func (c *smartCompiler) compileCondition(path string, condition data.SmartCondition) (compiledCondition, error) {
+	var compiled compiledCondition
+	var err error
+
+	if compiled.any, err = c.compileConditions(path+".any", condition.Any); err != nil {
+		return compiledCondition{}, err
+	}
+	if compiled.all, err = c.compileConditions(path+".all", condition.All); err != nil {
+		return compiledCondition{}, err
+	}
+	if compiled.none, err = c.compileConditions(path+".none", condition.None); err != nil {
+		return compiledCondition{}, err
+	}
+	if condition.Near != nil {
+		if compiled.near, err = compileNear(path+".near", *condition.Near); err != nil {
+			if err := c.invalid(err); err != nil {
+				return compiledCondition{}, err
+			}
+		}
+	}
+	if condition.CountAtLeast != nil {
+		if compiled.count, err = compileCount(path+".countAtLeast", *condition.CountAtLeast); err != nil {
+			if err := c.invalid(err); err != nil {
+				return compiledCondition{}, err
+			}
+		}
+	}
+
+	for _, phrase := range condition.AnyPhrase {
+		compiled.phrases = append(compiled.phrases, compiledPhrase{
+			term:       phrase,
+			normalized: strings.ToLower(strings.TrimSpace(phrase)),
+		})
+	}
+	for i, pattern := range condition.Regex {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			if err := c.invalid(fmt.Errorf("%s.regex[%d]: %w", path, i, err)); err != nil {
+				return compiledCondition{}, err
+			}
+			continue
+		}
+		compiled.regexes = append(compiled.regexes, compiledRegex{pattern: pattern, re: re})
+	}
+
+	return compiled, nil
+}
+
+const (
+	maxNearTerms    = 5
+	maxNearDistance = 50
+)
+
+func compileNear(path string, near data.SmartNear) (*compiledNear, error) {
+	if len(near.Terms) < 2 || len(near.Terms) > maxNearTerms {
+		return nil, fmt.Errorf("%s.terms: must have between 2 and %d terms", path, maxNearTerms)
+	}
+	if near.Distance < 0 || near.Distance > maxNearDistance {
+		return nil, fmt.Errorf("%s.distance: must be between 0 and %d", path, maxNearDistance)
+	}
+
+	compiled := &compiledNear{distance: near.Distance, ordered: near.Ordered}
+	for i, term := range near.Terms {
+		words := splitWords(strings.ToLower(term))
+		if len(words) == 0 {
+			return nil, fmt.Errorf("%s.terms[%d]: has no words", path, i)
+		}
+		compiled.terms = append(compiled.terms, strings.TrimSpace(term))
+		compiled.words = append(compiled.words, words)
+	}
+	return compiled, nil
+}
+
+func compileCount(path string, count data.SmartCountAtLeast) (*compiledCount, error) {
+	if len(count.Phrases) == 0 {
+		return nil, fmt.Errorf("%s.phrases: must not be empty", path)
+	}
+	if count.Count < 1 || count.Count > len(count.Phrases) {
+		return nil, fmt.Errorf("%s.count: must be between 1 and %d", path, len(count.Phrases))
+	}
+
+	compiled := &compiledCount{count: count.Count}
+	for i, phrase := range count.Phrases {
+		normalized := strings.ToLower(strings.TrimSpace(phrase))
+		if normalized == "" {
+			return nil, fmt.Errorf("%s.phrases[%d]: is empty", path, i)
+		}
+		compiled.phrases = append(compiled.phrases, compiledPhrase{term: phrase, normalized: normalized})
+	}
+	return compiled, nil
+}
+
+func (c *smartCompiler) compileConditions(path string, conditions []data.SmartCondition) ([]compiledCondition, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	compiled := make([]compiledCondition, 0, len(conditions))
+	for i, condition := range conditions {
+		child, err := c.compileCondition(fmt.Sprintf("%s[%d]", path, i), condition)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, child)
+	}
+	return compiled, nil
+}
+
+// Matches reports whether the input matches the filter. Unlike Evaluate it does not record
+// which terms matched, so evaluating a rule allocates nothing.
+func (f *CompiledSmartFilter) Matches(input SmartInput) bool {
+	if !f.inScope(input) {
+		return false
+	}
+
+	values := smartValues{input: input}
+	if !f.candidate.matches(&values) {
+		return false
+	}
+	if !f.matchesLanguage(&values) {
+		return false
+	}
+
+	score := 0
+	for i := range f.signals {
+		if f.signals[i].rule.matches(&values) {
+			score += f.signals[i].weight
+		}
+	}
+	return score >= f.acceptMinScore
+}
+
+// Evaluate matches the input against the filter and reports which rules and terms matched.
+func (f *CompiledSmartFilter) Evaluate(input SmartInput) SmartMatchResult {
+	result := SmartMatchResult{
+		AcceptMinScore: f.acceptMinScore,
+	}
+
+	switch {
+	case !f.subreddits.matches(input.Subreddit):
+		result.RejectedBy = "subreddit_scope"
+		return result
+	case !f.instances.matches(input.Instance):
+		result.RejectedBy = "instance_scope"
+		return result
+	case !f.tags.matchesValues(input.Tags):
+		result.RejectedBy = "tag_scope"
+		return result
+	}
+
+	values := smartValues{input: input}
+	candidateMatched, candidateDetails := f.candidate.evaluate(&values)
+	result.CandidateMatched = candidateMatched
+	result.CandidateDetails = candidateDetails
+	if !candidateMatched {
+		result.RejectedBy = "candidate"
+		return result
+	}
+
+	if !f.matchesLanguage(&values) {
+		result.RejectedBy = "language_scope"
+		return result
+	}
+
+	for _, signal := range f.signals {
+		matched, details := signal.rule.evaluate(&values)
+		if matched {
+			result.Score += signal.weight
+			result.MatchedSignals = append(result.MatchedSignals, signal.name)
+			result.SignalDetails = append(result.SignalDetails, SmartSignalMatchDetail{
+				Name:          signal.name,
+				Weight:        signal.weight,
+				MatchedFields: details,
+			})
+		}
+	}
+
+	result.Matched = result.Score >= result.AcceptMinScore
+	if !result.Matched {
+		result.RejectedBy = "score_threshold"
+	}
+
+	return result
+}
+
+func (f *CompiledSmartFilter) inScope(input SmartInput) bool {
+	return f.subreddits.matches(input.Subreddit) &&
+		f.instances.matches(input.Instance) &&
+		f.tags.matchesValues(input.Tags)
+}
+
+// matchesLanguage only builds the detected text when the filter has a language scope.
+func (f *CompiledSmartFilter) matchesLanguage(values *smartValues) bool {
+	if len(f.language.Include) == 0 && len(f.language.Exclude) == 0 {
+		return true
+	}
+	return matchesLanguageScope(f.language, values.text(), values.input.Language)
+}
+
+func (s compiledScopeList) matches(value string) bool {
+	normalized := normalizeSmartValue(value)
+	if normalized == "" {
+		return len(s.include) == 0
+	}
+	if len(s.include) > 0 && !containsString(s.include, normalized) {
+		return false
+	}
+	if len(s.exclude) > 0 && containsString(s.exclude, normalized) {
+		return false
+	}
+	return true
+}
+
+// matchesValues applies a scope list to a multi-valued field such as tags. Any value may satisfy
+// the include list, and any value on the exclude list rejects the input.
+func (s compiledScopeList) matchesValues(values []string) bool {
+	included := len(s.include) == 0
+	for _, value := range values {
+		normalized := normalizeSmartValue(value)
+		if normalized == "" {
+			continue
+		}
+		if containsString(s.exclude, normalized) {
+			return false
+		}
+		if !included && containsString(s.include, normalized) {
+			included = true
+		}
+	}
+	return included
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) matches(values *smartValues) bool {
+	if r.empty {
+		return false
+	}
+	return r.condition.matches(r.fields, values)
+}
+
+func (r *compiledRule) evaluate(values *smartValues) (bool, []SmartRuleMatchDetail) {
+	if r.empty {
+		return false, nil
+	}
+	return r.condition.evaluate(r.fields, values)
+}
+
+func (c *compiledCondition) matches(fields []smartField, values *smartValues) bool {
+	if len(c.any) > 0 {
+		for i := range c.any {
+			if c.any[i].matches(fields, values) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(c.all) > 0 {
+		for i := range c.all {
+			if !c.all[i].matches(fields, values) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(c.none) > 0 {
+		for i := range c.none {
+			if c.none[i].matches(fields, values) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if c.near != nil {
+		return c.near.find(fields, values) >= 0
+	}
+
+	if c.count != nil {
+		return c.count.matches(fields, values, nil)
+	}
+
+	if field, _ := c.findPhrase(fields, values); field >= 0 {
+		return true
+	}
+	field, _ := c.findRegex(fields, values)
+	return field >= 0
+}
+
+func (c *compiledCondition) evaluate(fields []smartField, values *smartValues) (bool, []SmartRuleMatchDetail) {
+	if len(c.any) > 0 {
+		for i := range c.any {
+			if matched, details := c.any[i].evaluate(fields, values); matched {
+				return true, details
+			}
+		}
+		return false, nil
+	}
+
+	if len(c.all) > 0 {
+		allDetails := make([]SmartRuleMatchDetail, 0)
+		for i := range c.all {
+			matched, details := c.all[i].evaluate(fields, values)
+			if !matched {
+				return false, nil
+			}
+			allDetails = append(allDetails, details...)
+		}
+		return true, allDetails
+	}
+
+	if len(c.none) > 0 {
+		for i := range c.none {
+			if c.none[i].matches(fields, values) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	if c.near != nil {
+		field := c.near.find(fields, values)
+		if field < 0 {
+			return false, nil
+		}
+		return true, []SmartRuleMatchDetail{{
+			Field:       fields[field].name,
+			MatchType:   "near",
+			MatchedTerm: strings.Join(c.near.terms, ", "),
+			MatchedText: values.original(fields[field].kind),
+		}}
+	}
+
+	if c.count != nil {
+		var details []SmartRuleMatchDetail
+		if !c.count.matches(fields, values, &details) {
+			return false, nil
+		}
+		return true, details
+	}
+
+	if field, term := c.findPhrase(fields, values); field >= 0 {
+		return true, []SmartRuleMatchDetail{{
+			Field:       fields[field].name,
+			MatchType:   "anyPhrase",
+			MatchedTerm: c.phrases[term].term,
+			MatchedText: values.original(fields[field].kind),
+		}}
+	}
+	if field, term := c.findRegex(fields, values); field >= 0 {
+		return true, []SmartRuleMatchDetail{{
+			Field:       fields[field].name,
+			MatchType:   "regex",
+			MatchedTerm: c.regexes[term].pattern,
+			MatchedText: values.original(fields[field].kind),
+		}}
+	}
+	return false, nil
+}
+
+// findPhrase returns the positions of the first field and phrase that match, or -1.
+func (c *compiledCondition) findPhrase(fields []smartField, values *smartValues) (int, int) {
+	if len(c.phrases) == 0 {
+		return -1, -1
+	}
+	for i, field := range fields {
+		value := values.original(field.kind)
+		if value == "" {
+			continue
+		}
+		for j := range c.phrases {
+			if containsLower(value, c.phrases[j].normalized) {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// matches counts the phrases that occur in any of the fields. When details is set every matched
+// phrase is recorded, otherwise counting stops once enough phrases were found.
+func (c *compiledCount) matches(fields []smartField, values *smartValues, details *[]SmartRuleMatchDetail) bool {
+	found := 0
+	for j := range c.phrases {
+		for i, field := range fields {
+			value := values.original(field.kind)
+			if value == "" || !containsLower(value, c.phrases[j].normalized) {
+				continue
+			}
+			found++
+			if details != nil {
+				*details = append(*details, SmartRuleMatchDetail{
+					Field:       fields[i].name,
+					MatchType:   "countAtLeast",
+					MatchedTerm: c.phrases[j].term,
+					MatchedText: value,
+				})
+			}
+			break
+		}
+		if found >= c.count && details == nil {
+			return true
+		}
+	}
+	return found >= c.count
+}
+
+// find returns the position of the first field in which the terms occur near each other, or -1.
+func (n *compiledNear) find(fields []smartField, values *smartValues) int {
+	for i, field := range fields {
+		if n.matches(values.original(field.kind)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches reports whether the terms occur near each other in text. Words are read from text in
+// place and the permutations of the terms are tried in a fixed size array, so matching does not
+// allocate.
+func (n *compiledNear) matches(text string) bool {
+	for _, term := range n.words {
+		if !containsTerm(text, term) {
+			return false
+		}
+	}
+
+	var order [maxNearTerms]int
+	for i := range n.words {
+		order[i] = i
+	}
+	if n.ordered {
+		return n.within(text, order[:len(n.words)])
+	}
+	return n.permute(text, order[:len(n.words)], 0)
+}
+
+// permute tries every permutation of order[k:] until the terms occur within the distance in one.
+func (n *compiledNear) permute(text string, order []int, k int) bool {
+	if k == len(order) {
+		return n.within(text, order)
+	}
+	for i := k; i < len(order); i++ {
+		order[k], order[i] = order[i], order[k]
+		found := n.permute(text, order, k+1)
+		order[k], order[i] = order[i], order[k]
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether the terms occur in the given order, without overlapping and with at most
+// distance other words between them. Taking the earliest occurrence of each next term keeps the
+// gap as small as possible for a given start.
+func (n *compiledNear) within(text string, order []int) bool {
+	for start, end := nextWord(text, 0); start < len(text); start, end = nextWord(text, end) {
+		termEnd, ok := termAt(text, start, end, n.words[order[0]])
+		if ok && n.follows(text, termEnd, order[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// follows reports whether the terms occur in order after offset, with at most distance words
+// skipped between them in total.
+func (n *compiledNear) follows(text string, offset int, order []int) bool {
+	gap := 0
+	for _, term := range order {
+		start, end := nextWord(text, offset)
+		for {
+			if start == len(text) {
+				return false
+			}
+			if termEnd, ok := termAt(text, start, end, n.words[term]); ok {
+				offset = termEnd
+				break
+			}
+			gap++
+			if gap > n.distance {
+				return false
+			}
+			start, end = nextWord(text, end)
+		}
+	}
+	return true
+}
+
+// containsTerm reports whether the words of term occur consecutively anywhere in text.
+func containsTerm(text string, term []string) bool {
+	for start, end := nextWord(text, 0); start < len(text); start, end = nextWord(text, end) {
+		if _, ok := termAt(text, start, end, term); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// termAt reports whether the words of term occur consecutively from the word text[start:end],
+// and returns the offset after the last of them.
+func termAt(text string, start, end int, term []string) (int, bool) {
+	for i, word := range term {
+		if i > 0 {
+			start, end = nextWord(text, end)
+		}
+		if start == len(text) || !strings.EqualFold(text[start:end], word) {
+			return 0, false
+		}
+	}
+	return end, true
+}
+
+// nextWord returns the bounds of the first run of letters, digits and underscores at or after
+// offset. start is len(text) when there is none.
+func nextWord(text string, offset int) (start, end int) {
+	for offset < len(text) {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if isWordChar(r) {
+			break
+		}
+		offset += size
+	}
+	start = offset
+	for offset < len(text) {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if !isWordChar(r) {
+			break
+		}
+		offset += size
+	}
+	return start, offset
+}
+
+// splitWords splits text into runs of letters, digits and underscores.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !isWordChar(r)
+	})
+}
+
+// findRegex returns the positions of the first field and regex that match, or -1.
+func (c *compiledCondition) findRegex(fields []smartField, values *smartValues) (int, int) {
+	if len(c.regexes) == 0 {
+		return -1, -1
+	}
+	for i, field := range fields {
+		value := values.original(field.kind)
+		if value == "" {
+			continue
+		}
+		for j := range c.regexes {
+			if c.regexes[j].re.MatchString(value) {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// smartValues resolves the fields of an input once per evaluation, so every rule that reads a
+// field shares the same value.
+type smartValues struct {
+	input  SmartInput
+	values [smartFieldCount]string
+	loaded [smartFieldCount]bool
+}
+
+func (v *smartValues) original(kind smartFieldKind) string {
+	if !v.loaded[kind] {
+		v.values[kind] = v.fieldValue(kind)
+		v.loaded[kind] = true
+	}
+	return v.values[kind]
+}
+
+func (v *smartValues) fieldValue(kind smartFieldKind) string {
+	switch kind {
+	case smartFieldTitle:
+		return v.input.Title
+	case smartFieldBody:
+		return v.input.Body
+	case smartFieldSubreddit:
+		return v.input.Subreddit
+	case smartFieldLabels:
+		return strings.Join(v.input.Labels, "\n")
+	case smartFieldParentTitle:
+		return v.input.ParentTitle
+	default:
+		return ""
+	}
+}
+
+// text is the title and body the language scope is detected on.
+func (v *smartValues) text() string {
+	return strings.TrimSpace(strings.TrimSpace(v.input.Title) + "\n" + strings.TrimSpace(v.input.Body))
+}
+
+// containsLower reports whether substr, which is already lower case, occurs in s compared case
+// insensitively. It matches exactly what strings.Contains(strings.ToLower(s), substr) would,
+// without building the lowered copy of s.
+func containsLower(s, substr string) bool {
+	if substr == "" {
+		return true
+	}
+	for i := 0; i < len(s); {
+		if hasLowerPrefix(s[i:], substr) {
+			return true
+		}
+		if s[i] < utf8.RuneSelf {
+			i++
+		} else {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
+		}
+	}
+	return false
+}
+
+func hasLowerPrefix(s, prefix string) bool {
+	for prefix != "" {
+		if s == "" {
+			return false
+		}
+		if c, p := s[0], prefix[0]; c < utf8.RuneSelf && p < utf8.RuneSelf {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			if c != p {
+				return false
+			}
+			s, prefix = s[1:], prefix[1:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		p, psize := utf8.DecodeRuneInString(prefix)
+		if unicode.ToLower(r) != p {
+			return false
+		}
+		s, prefix = s[size:], prefix[psize:]
+	}
+	return true
+}
@@ -0,0 +1,291 @@
+package matchers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileSmartFilter(t *testing.T) {
+	t.Run("it reports the path of an invalid regex", func(t *testing.T) {
+		_, err := CompileSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{Condition: data.SmartCondition{AnyPhrase: []string{"crm"}}},
+			Signals: []data.SmartSignal{
+				{Name: "first", Condition: data.SmartCondition{AnyPhrase: []string{"help"}}},
+				{Name: "second", Condition: data.SmartCondition{Any: []data.SmartCondition{
+					{Regex: []string{`ok`, `(broken`}},
+				}}},
+			},
+		})
+
+		require.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "signals[1].condition.any[0].regex[1]: "), err.Error())
+	})
+
+	t.Run("it reports the path of an unknown where field", func(t *testing.T) {
+		_, err := CompileSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{"title", "comments"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"crm"}},
+			},
+		})
+
+		require.EqualError(t, err, `candidate.where[1]: unknown field "comments"`)
+	})
+
+	t.Run("it accepts where fields regardless of case", func(t *testing.T) {
+		_, err := CompileSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{" Title ", "parentTitle"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"crm"}},
+			},
+		})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestCompileStoredSmartFilter(t *testing.T) {
+	t.Run("it ignores unknown where fields and reports them", func(t *testing.T) {
+		filter, problems := CompileStoredSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{"comments", "title"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"crm"}},
+			},
+		})
+
+		assert.Equal(t, []string{`candidate.where[0]: unknown field "comments"`}, problems)
+		assert.True(t, filter.Matches(SmartInput{Title: "Looking for a CRM"}))
+		assert.False(t, filter.Matches(SmartInput{Body: "Looking for a CRM"}))
+	})
+
+	t.Run("it leaves out invalid conditions and reports them", func(t *testing.T) {
+		filter, problems := CompileStoredSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{
+				Condition: data.SmartCondition{Any: []data.SmartCondition{
+					{Regex: []string{"("}},
+					{Near: &data.SmartNear{Terms: []string{"crm"}}},
+					{AnyPhrase: []string{"crm"}},
+				}},
+			},
+		})
+
+		require.Len(t, problems, 2)
+		assert.True(t, strings.HasPrefix(problems[0], "candidate.condition.any[0].regex[0]: "), problems[0])
+		assert.True(t, strings.HasPrefix(problems[1], "candidate.condition.any[1].near.terms: "), problems[1])
+		assert.True(t, filter.Matches(SmartInput{Title: "Looking for a CRM"}))
+	})
+
+	t.Run("it reports no problems for a valid filter", func(t *testing.T) {
+		_, problems := CompileStoredSmartFilter(data.SmartFilter{
+			Candidate: data.SmartRule{Condition: data.SmartCondition{AnyPhrase: []string{"crm"}}},
+		})
+
+		assert.Empty(t, problems)
+	})
+}
+
+func TestCompiledSmartFilter(t *testing.T) {
+	filter := data.SmartFilter{
+		Scope: data.SmartScope{
+			Subreddits: data.SmartScopeList{Exclude: []string{"Spam"}},
+		},
+		Candidate: data.SmartRule{Condition: data.SmartCondition{Any: []data.SmartCondition{
+			{All: []data.SmartCondition{
+				{AnyPhrase: []string{"CRM", "help desk"}},
+				{AnyPhrase: []string{"recommend", "alternative"}},
+			}},
+			{Regex: []string{`self[- ]?hosted`}},
+		}}},
+		Signals: []data.SmartSignal{
+			{Name: "Request", Weight: 30, Condition: data.SmartCondition{AnyPhrase: []string{"looking for", "any"}}},
+			{Name: "Launch", Weight: -50, Where: []string{"title"}, Condition: data.SmartCondition{AnyPhrase: []string{"launching"}}},
+		},
+		Thresholds: data.SmartThresholds{AcceptMinScore: 20},
+	}
+	compiled, err := CompileSmartFilter(filter)
+	require.NoError(t, err)
+
+	inputs := []SmartInput{
+		{Title: "Looking for a CRM", Body: "Can anyone recommend one?"},
+		{Title: "Launching our CRM", Body: "An alternative for small teams, any feedback?"},
+		{Title: "Self-Hosted help desk", Body: "Looking for ideas"},
+		{Title: "Any CRM to recommend?", Subreddit: "spam"},
+		{Title: "Nothing relevant", Body: "at all"},
+	}
+
+	t.Run("it matches the same inputs as the detailed evaluation", func(t *testing.T) {
+		for _, input := range inputs {
+			assert.Equal(t, compiled.Evaluate(input).Matched, compiled.Matches(input), input.Title)
+		}
+		assert.True(t, compiled.Matches(inputs[0]))
+		assert.False(t, compiled.Matches(inputs[1]))
+		assert.True(t, compiled.Matches(inputs[2]))
+		assert.False(t, compiled.Matches(inputs[3]))
+	})
+
+	t.Run("it reports the original phrase as the matched term", func(t *testing.T) {
+		result := compiled.Evaluate(inputs[0])
+
+		require.Len(t, result.CandidateDetails, 2)
+		assert.Equal(t, "CRM", result.CandidateDetails[0].MatchedTerm)
+		assert.Equal(t, "title", result.CandidateDetails[0].Field)
+	})
+
+	t.Run("it does not allocate when matching", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			for _, input := range inputs {
+				compiled.Matches(input)
+			}
+		})
+
+		assert.Zero(t, allocs)
+	})
+}
+
+func TestContainsLower(t *testing.T) {
+	cases := []struct {
+		s      string
+		substr string
+	}{
+		{"Looking for a CRM", "crm"},
+		{"Looking for a CRM", "a c"},
+		{"ZÜRICH office", "zürich"},
+		{"ZÜRICH office", "zurich"},
+		{"The Kelvin sign", "kelvin"},
+		{"short", "longer than s"},
+		{"", ""},
+		{"abc", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, strings.Contains(strings.ToLower(c.s), c.substr), containsLower(c.s, c.substr), "%q in %q", c.substr, c.s)
+	}
+}
+
+func BenchmarkCompiledSmartFilterMatches(b *testing.B) {
+	compiled, err := CompileSmartFilter(data.SmartFilter{
+		Candidate: data.SmartRule{Condition: data.SmartCondition{All: []data.SmartCondition{
+			{AnyPhrase: []string{"crm", "help desk", "helpdesk", "ticketing"}},
+			{AnyPhrase: []string{"recommend", "alternative", "looking for"}},
+		}}},
+		Signals: []data.SmartSignal{
+			{Name: "Request", Weight: 30, Condition: data.SmartCondition{AnyPhrase: []string{"any experiences", "suggestions"}}},
+		},
+		Thresholds: data.SmartThresholds{AcceptMinScore: 20},
+	})
+	require.NoError(b, err)
+	input := SmartInput{Title: "Looking for a lightweight CRM", Body: benchmarkText}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		compiled.Matches(input)
+	}
+}
+
+func TestSmartConditionOperators(t *testing.T) {
+	matches := func(t *testing.T, condition data.SmartCondition, input SmartInput) bool {
+		t.Helper()
+		compiled, err := CompileSmartFilter(data.SmartFilter{Candidate: data.SmartRule{Condition: condition}})
+		require.NoError(t, err)
+		assert.Equal(t, compiled.Evaluate(input).Matched, compiled.Matches(input))
+		return compiled.Matches(input)
+	}
+
+	t.Run("it matches none when no child matches", func(t *testing.T) {
+		condition := data.SmartCondition{All: []data.SmartCondition{
+			{AnyPhrase: []string{"crm"}},
+			{None: []data.SmartCondition{{AnyPhrase: []string{"hiring"}}, {Regex: []string{`\bjobs?\b`}}}},
+		}}
+
+		assert.True(t, matches(t, condition, SmartInput{Title: "Which CRM do you use?"}))
+		assert.False(t, matches(t, condition, SmartInput{Title: "Our CRM team is hiring"}))
+		assert.False(t, matches(t, condition, SmartInput{Title: "CRM job opening"}))
+	})
+
+	t.Run("it matches near terms within the distance in any order", func(t *testing.T) {
+		condition := data.SmartCondition{Near: &data.SmartNear{Terms: []string{"pricing", "Acme CRM"}, Distance: 3}}
+
+		assert.True(t, matches(t, condition, SmartInput{Body: "What is the pricing for Acme CRM?"}))
+		assert.True(t, matches(t, condition, SmartInput{Body: "Acme-CRM: how does the pricing work"}))
+		assert.False(t, matches(t, condition, SmartInput{Body: "pricing is a question I keep asking about acme crm"}))
+		assert.False(t, matches(t, condition, SmartInput{Title: "pricing", Body: "acme crm"}))
+	})
+
+	t.Run("it requires near terms in order when ordered", func(t *testing.T) {
+		condition := data.SmartCondition{Near: &data.SmartNear{Terms: []string{"alternative", "jira"}, Distance: 1, Ordered: true}}
+
+		assert.True(t, matches(t, condition, SmartInput{Title: "Alternative to Jira?"}))
+		assert.False(t, matches(t, condition, SmartInput{Title: "Jira alternative?"}))
+	})
+
+	t.Run("it matches near terms across several occurrences", func(t *testing.T) {
+		condition := data.SmartCondition{Near: &data.SmartNear{Terms: []string{"a", "b", "c"}, Distance: 1}}
+
+		assert.True(t, matches(t, condition, SmartInput{Body: "a x x b c x a b x c"}))
+		assert.False(t, matches(t, condition, SmartInput{Body: "a x x b x x c"}))
+	})
+
+	t.Run("it does not allocate when matching near terms", func(t *testing.T) {
+		compiled, err := CompileSmartFilter(data.SmartFilter{Candidate: data.SmartRule{Condition: data.SmartCondition{
+			Near: &data.SmartNear{Terms: []string{"pricing", "Acme CRM", "seats"}, Distance: 6},
+		}}})
+		require.NoError(t, err)
+		input := SmartInput{Title: "Acme CRM seats", Body: "Is the pricing of Acme CRM per seat or for all seats?"}
+		require.True(t, compiled.Matches(input))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			compiled.Matches(input)
+		})
+
+		assert.Zero(t, allocs)
+	})
+
+	t.Run("it counts the distinct phrases that occur", func(t *testing.T) {
+		condition := data.SmartCondition{CountAtLeast: &data.SmartCountAtLeast{Count: 2, Phrases: []string{"crm", "help desk", "ticketing"}}}
+
+		assert.True(t, matches(t, condition, SmartInput{Title: "CRM with ticketing"}))
+		assert.True(t, matches(t, condition, SmartInput{Title: "CRM", Body: "and a help desk"}))
+		assert.False(t, matches(t, condition, SmartInput{Title: "CRM, CRM and more CRM"}))
+	})
+
+	t.Run("it reports the phrases that were counted", func(t *testing.T) {
+		compiled, err := CompileSmartFilter(data.SmartFilter{Candidate: data.SmartRule{Condition: data.SmartCondition{
+			CountAtLeast: &data.SmartCountAtLeast{Count: 1, Phrases: []string{"crm", "ticketing"}},
+		}}})
+		require.NoError(t, err)
+
+		result := compiled.Evaluate(SmartInput{Title: "CRM with ticketing"})
+		require.Len(t, result.CandidateDetails, 2)
+		assert.Equal(t, "countAtLeast", result.CandidateDetails[1].MatchType)
+		assert.Equal(t, "ticketing", result.CandidateDetails[1].MatchedTerm)
+	})
+
+	t.Run("it rejects invalid operators with their path", func(t *testing.T) {
+		cases := map[string]data.SmartCondition{
+			"candidate.condition.near.terms: must have between 2 and 5 terms": {
+				Near: &data.SmartNear{Terms: []string{"crm"}, Distance: 2},
+			},
+			"candidate.condition.all[1].near.terms[1]: has no words": {
+				All: []data.SmartCondition{{AnyPhrase: []string{"crm"}}, {Near: &data.SmartNear{Terms: []string{"crm", "--"}}}},
+			},
+			"candidate.condition.near.distance: must be between 0 and 50": {
+				Near: &data.SmartNear{Terms: []string{"crm", "price"}, Distance: -1},
+			},
+			"candidate.condition.countAtLeast.count: must be between 1 and 2": {
+				CountAtLeast: &data.SmartCountAtLeast{Count: 3, Phrases: []string{"crm", "erp"}},
+			},
+			"candidate.condition.none[0].countAtLeast.phrases[1]: is empty": {
+				None: []data.SmartCondition{{CountAtLeast: &data.SmartCountAtLeast{Count: 1, Phrases: []string{"crm", " "}}}},
+			},
+		}
+
+		for message, condition := range cases {
+			_, err := CompileSmartFilter(data.SmartFilter{Candidate: data.SmartRule{Condition: condition}})
+			assert.EqualError(t, err, message)
+		}
+	})
+}
@@ -97,4 +97,96 @@ func TestMatchesSmart(t *testing.T) {
 		assert.NoError(t, err)
 		assert.False(t, matched)
 	})
+
+	t.Run("it applies instance scope filters the same way as subreddits", func(t *testing.T) {
+		input := SmartInput{
+			Title:    "Looking for an open source alternative to Notion?",
+			Body:     "Can anyone recommend something self-hosted for note taking?",
+			Instance: "fosstodon.org",
+		}
+
+		included := filter
+		included.Scope.Instances = data.SmartScopeList{Include: []string{"Fosstodon.org"}}
+		matched, err := MatchesSmart(included, input)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+
+		excluded := filter
+		excluded.Scope.Instances = data.SmartScopeList{Exclude: []string{"fosstodon.org"}}
+		result, err := EvaluateSmart(excluded, input)
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Equal(t, "instance_scope", result.RejectedBy)
+	})
+
+	t.Run("it matches conditions against labels when labels is a where field", func(t *testing.T) {
+		labelled := data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{"labels"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"feature request"}},
+			},
+		}
+
+		matched, err := MatchesSmart(labelled, SmartInput{
+			Title:  "Support dark mode",
+			Labels: []string{"enhancement", "Feature Request"},
+		})
+		assert.NoError(t, err)
+		assert.True(t, matched)
+
+		matched, err = MatchesSmart(labelled, SmartInput{
+			Title: "This is a feature request for dark mode",
+		})
+		assert.NoError(t, err)
+		assert.False(t, matched)
+	})
+
+	t.Run("it matches conditions against the parent title when parentTitle is a where field", func(t *testing.T) {
+		threaded := data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{"parentTitle"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"self-hosted"}},
+			},
+		}
+
+		matched, err := MatchesSmart(threaded, SmartInput{
+			Body:        "I have been running this for a year now.",
+			ParentTitle: "What is your favorite self-hosted note taking app?",
+		})
+		assert.NoError(t, err)
+		assert.True(t, matched)
+
+		matched, err = MatchesSmart(threaded, SmartInput{
+			Body: "I moved everything to a self-hosted setup.",
+		})
+		assert.NoError(t, err)
+		assert.False(t, matched)
+	})
+
+	t.Run("it applies tag scope filters to any of the input tags", func(t *testing.T) {
+		input := SmartInput{
+			Title: "Looking for an open source alternative to Notion?",
+			Body:  "Can anyone recommend something self-hosted for note taking?",
+			Tags:  []string{"postgresql", "self-hosting"},
+		}
+
+		included := filter
+		included.Scope.Tags = data.SmartScopeList{Include: []string{"PostgreSQL"}}
+		matched, err := MatchesSmart(included, input)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+
+		excluded := filter
+		excluded.Scope.Tags = data.SmartScopeList{Include: []string{"postgresql"}, Exclude: []string{"self-hosting"}}
+		result, err := EvaluateSmart(excluded, input)
+		assert.NoError(t, err)
+		assert.False(t, result.Matched)
+		assert.Equal(t, "tag_scope", result.RejectedBy)
+
+		untagged := input
+		untagged.Tags = nil
+		matched, err = MatchesSmart(included, untagged)
+		assert.NoError(t, err)
+		assert.False(t, matched)
+	})
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/kova98/feedgrep.api/config"
 	"github.com/kova98/feedgrep.api/data"
 	"github.com/kova98/feedgrep.api/handlers"
 )
@@ -32,6 +33,10 @@ func privateHTTP(handler http.HandlerFunc) http.Handler {
 	return withMetrics(withAuth(handler))
 }
 
+func admin(handler handlers.Handler) http.Handler {
+	return withMetrics(withAuth(withAdmin(handlerAdapter(handler))))
+}
+
 func public(handler handlers.Handler) http.Handler {
 	return withMetrics(handlerAdapter(handler))
 }
@@ -75,6 +80,18 @@ func withAuth(next http.Handler) http.Handler {
 	})
 }
 
+func withAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(UserContextKey).(data.User)
+		if !config.Config.IsAdmin(user.Email) {
+			slog.Warn("forbidden admin request", "path", r.URL.Path, "user_id", user.ID)
+			writeResult(w, handlers.Forbidden("Forbidden."))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func withMetrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ts := time.Now()
@@ -5,12 +5,20 @@ type ArcticShiftSearchResponse[T any] struct {
 }
 
 type ArcticShiftPost struct {
-	ID         string `json:"id"`
-	Subreddit  string `json:"subreddit"`
-	Author     string `json:"author"`
-	Title      string `json:"title"`
-	Selftext   string `json:"selftext"`
-	CreatedUTC int64  `json:"created_utc"`
+	ID              string `json:"id"`
+	Subreddit       string `json:"subreddit"`
+	Author          string `json:"author"`
+	Title           string `json:"title"`
+	Selftext        string `json:"selftext"`
+	CreatedUTC      int64  `json:"created_utc"`
+	Score           int    `json:"score"`
+	NumComments     int    `json:"num_comments"`
+	LinkFlairText   string `json:"link_flair_text"`
+	AuthorFlairText string `json:"author_flair_text"`
+	Over18          bool   `json:"over_18"`
+	IsSelf          bool   `json:"is_self"`
+	URL             string `json:"url"`
+	Domain          string `json:"domain"`
 }
 
 type ArcticShiftComment struct {
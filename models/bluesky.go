@@ -0,0 +1,32 @@
+package models
+
+type JetstreamEvent struct {
+	DID    string           `json:"did"`
+	TimeUS int64            `json:"time_us"`
+	Kind   string           `json:"kind"`
+	Commit *JetstreamCommit `json:"commit"`
+}
+
+type JetstreamCommit struct {
+	Operation  string             `json:"operation"`
+	Collection string             `json:"collection"`
+	RKey       string             `json:"rkey"`
+	Record     *BlueskyPostRecord `json:"record"`
+}
+
+type BlueskyPostRecord struct {
+	Text      string            `json:"text"`
+	CreatedAt string            `json:"createdAt"`
+	Langs     []string          `json:"langs"`
+	Reply     *BlueskyReplyRefs `json:"reply"`
+}
+
+type BlueskyReplyRefs struct {
+	Root   BlueskyStrongRef `json:"root"`
+	Parent BlueskyStrongRef `json:"parent"`
+}
+
+type BlueskyStrongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type PollLoopStatus struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	IntervalMs          int64      `json:"intervalMs"`
+	Cursor              int64      `json:"cursor"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt"`
+	LastErrorAt         *time.Time `json:"lastErrorAt"`
+	LastError           string     `json:"lastError"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	NextPollAt          *time.Time `json:"nextPollAt"`
+}
+
+type DiagnosticsResponse struct {
+	PollLoops []PollLoopStatus `json:"pollLoops"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title string    `xml:"title"`
+	Items []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string `xml:"author"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []AtomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Author    AtomAuthor `xml:"author"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type CreateFeedRequest struct {
+	URL string `json:"url"`
+}
+
+type UpdateFeedRequest struct {
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+type Feed struct {
+	ID                  int        `json:"id"`
+	URL                 string     `json:"url"`
+	Title               string     `json:"title"`
+	Active              bool       `json:"active"`
+	Status              string     `json:"status"`
+	LastFetchedAt       *time.Time `json:"lastFetchedAt,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+type GetFeedsResponse struct {
+	Feeds []Feed `json:"feeds"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+type GitHubEvent struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt time.Time          `json:"created_at"`
+	Payload   GitHubEventPayload `json:"payload"`
+}
+
+type GitHubEventPayload struct {
+	Action      string         `json:"action"`
+	Issue       *GitHubIssue   `json:"issue"`
+	PullRequest *GitHubIssue   `json:"pull_request"`
+	Comment     *GitHubComment `json:"comment"`
+}
+
+// GitHubIssue covers both issues and pull requests, which share these fields.
+type GitHubIssue struct {
+	Number      int           `json:"number"`
+	Title       string        `json:"title"`
+	Body        string        `json:"body"`
+	HTMLURL     string        `json:"html_url"`
+	State       string        `json:"state"`
+	User        GitHubUser    `json:"user"`
+	Labels      []GitHubLabel `json:"labels"`
+	PullRequest *struct{}     `json:"pull_request"`
+}
+
+type GitHubComment struct {
+	ID      int64      `json:"id"`
+	Body    string     `json:"body"`
+	HTMLURL string     `json:"html_url"`
+	User    GitHubUser `json:"user"`
+}
+
+type GitHubUser struct {
+	Login string `json:"login"`
+}
+
+type GitHubLabel struct {
+	Name string `json:"name"`
+}
+
+type GitHubGraphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type GitHubDiscussionsResponse struct {
+	Data struct {
+		Repository struct {
+			Discussions struct {
+				Nodes []GitHubDiscussion `json:"nodes"`
+			} `json:"discussions"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type GitHubDiscussion struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url"`
+	Closed    bool      `json:"closed"`
+	CreatedAt time.Time `json:"createdAt"`
+	Author    *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []GitHubLabel `json:"nodes"`
+	} `json:"labels"`
+}
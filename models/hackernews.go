@@ -0,0 +1,24 @@
+package models
+
+type HackerNewsSearchResponse struct {
+	Hits    []HackerNewsHit `json:"hits"`
+	Page    int             `json:"page"`
+	NbPages int             `json:"nbPages"`
+}
+
+type HackerNewsHit struct {
+	ObjectID    string   `json:"objectID"`
+	Author      string   `json:"author"`
+	CreatedAtI  int64    `json:"created_at_i"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	StoryText   string   `json:"story_text"`
+	CommentText string   `json:"comment_text"`
+	Points      *int     `json:"points"`
+	NumComments *int     `json:"num_comments"`
+	StoryID     *int64   `json:"story_id"`
+	StoryTitle  string   `json:"story_title"`
+	StoryURL    string   `json:"story_url"`
+	ParentID    *int64   `json:"parent_id"`
+	Tags        []string `json:"_tags"`
+}
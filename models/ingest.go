@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type IngestItem struct {
+	ID        string         `json:"id"`
+	Source    string         `json:"source"`
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Author    string         `json:"author"`
+	URL       string         `json:"url"`
+	CreatedAt *time.Time     `json:"createdAt"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+type IngestResponse struct {
+	Accepted   int `json:"accepted"`
+	Duplicates int `json:"duplicates"`
+	Matches    int `json:"matches"`
+}
@@ -17,6 +17,11 @@ type CreateKeywordRequest struct {
 	Filters   *KeywordFilters `json:"filters,omitempty"`
 }
 
+type CreateKeywordResponse struct {
+	ID                 int `json:"id"`
+	RetroactiveMatches int `json:"retroactiveMatches"`
+}
+
 type UpdateKeywordRequest struct {
 	Keyword   string          `json:"keyword"`
 	Active    bool            `json:"active"`
@@ -24,10 +29,30 @@ type UpdateKeywordRequest struct {
 	Filters   *KeywordFilters `json:"filters,omitempty"`
 }
 
+type UpdateKeywordResponse struct {
+	RetroactiveMatches int `json:"retroactiveMatches"`
+}
+
+// InvalidQueryResponse is returned for a query keyword with a syntax error. Position is the
+// 1-based character position of the error in the query.
+type InvalidQueryResponse struct {
+	Error    string `json:"error"`
+	Position int    `json:"position"`
+}
+
 type KeywordFilters struct {
-	Reddit   *RedditFilters   `json:"reddit,omitempty"`
-	Language *LanguageFilters `json:"language,omitempty"`
-	Smart    *SmartFilter     `json:"smart,omitempty"`
+	Reddit     *RedditFilters       `json:"reddit,omitempty"`
+	Language   *LanguageFilters     `json:"language,omitempty"`
+	Smart      *SmartFilter         `json:"smart,omitempty"`
+	Engagement *EngagementThreshold `json:"engagement,omitempty"`
+}
+
+// EngagementThreshold holds back matches of Reddit posts until the post is popular enough.
+// Comments and items from other sources match right away.
+type EngagementThreshold struct {
+	MinScore    int `json:"minScore,omitempty"`
+	MinComments int `json:"minComments,omitempty"`
+	WithinHours int `json:"withinHours,omitempty"`
 }
 
 func ToDataFilters(filters KeywordFilters) data.KeywordFilters {
@@ -37,6 +62,12 @@ func ToDataFilters(filters KeywordFilters) data.KeywordFilters {
 		out.Reddit = &data.RedditFilters{
 			Subreddits:        filters.Reddit.Subreddits,
 			ExcludeSubreddits: filters.Reddit.ExcludeSubreddits,
+			ExcludeNSFW:       filters.Reddit.ExcludeNSFW,
+			Flairs:            filters.Reddit.Flairs,
+			ExcludeFlairs:     filters.Reddit.ExcludeFlairs,
+			Domains:           filters.Reddit.Domains,
+			ExcludeDomains:    filters.Reddit.ExcludeDomains,
+			PostType:          filters.Reddit.PostType,
 		}
 	}
 
@@ -51,6 +82,14 @@ func ToDataFilters(filters KeywordFilters) data.KeywordFilters {
 		out.Smart = toDataSmartFilter(*filters.Smart)
 	}
 
+	if filters.Engagement != nil {
+		out.Engagement = &data.EngagementThreshold{
+			MinScore:    filters.Engagement.MinScore,
+			MinComments: filters.Engagement.MinComments,
+			WithinHours: filters.Engagement.WithinHours,
+		}
+	}
+
 	return out
 }
 
@@ -61,6 +100,12 @@ func FromDataFilters(filters data.KeywordFilters) KeywordFilters {
 		out.Reddit = &RedditFilters{
 			Subreddits:        filters.Reddit.Subreddits,
 			ExcludeSubreddits: filters.Reddit.ExcludeSubreddits,
+			ExcludeNSFW:       filters.Reddit.ExcludeNSFW,
+			Flairs:            filters.Reddit.Flairs,
+			ExcludeFlairs:     filters.Reddit.ExcludeFlairs,
+			Domains:           filters.Reddit.Domains,
+			ExcludeDomains:    filters.Reddit.ExcludeDomains,
+			PostType:          filters.Reddit.PostType,
 		}
 	}
 
@@ -75,12 +120,29 @@ func FromDataFilters(filters data.KeywordFilters) KeywordFilters {
 		out.Smart = fromDataSmartFilter(*filters.Smart)
 	}
 
+	if filters.Engagement != nil {
+		out.Engagement = &EngagementThreshold{
+			MinScore:    filters.Engagement.MinScore,
+			MinComments: filters.Engagement.MinComments,
+			WithinHours: filters.Engagement.WithinHours,
+		}
+	}
+
 	return out
 }
 
+// RedditFilters narrow down Reddit matches. The post filters (excludeNsfw, flairs, excludeFlairs,
+// domains, excludeDomains and postType) apply to comments through the post they were made on; a
+// comment whose post cannot be looked up is not matched while any of them is set.
 type RedditFilters struct {
 	Subreddits        []string `json:"subreddits,omitempty"`
 	ExcludeSubreddits []string `json:"excludeSubreddits,omitempty"`
+	ExcludeNSFW       bool     `json:"excludeNsfw,omitempty"`
+	Flairs            []string `json:"flairs,omitempty"`
+	ExcludeFlairs     []string `json:"excludeFlairs,omitempty"`
+	Domains           []string `json:"domains,omitempty"`
+	ExcludeDomains    []string `json:"excludeDomains,omitempty"`
+	PostType          string   `json:"postType,omitempty"`
 }
 
 type LanguageFilters struct {
@@ -101,6 +163,8 @@ type SmartFilter struct {
 type SmartScope struct {
 	Language   SmartScopeList `json:"language,omitempty"`
 	Subreddits SmartScopeList `json:"subreddits,omitempty"`
+	Instances  SmartScopeList `json:"instances,omitempty"`
+	Tags       SmartScopeList `json:"tags,omitempty"`
 }
 
 type SmartScopeList struct {
@@ -121,18 +185,38 @@ type SmartSignal struct {
 }
 
 type SmartCondition struct {
-	Any       []SmartCondition `json:"any,omitempty"`
-	All       []SmartCondition `json:"all,omitempty"`
-	AnyPhrase []string         `json:"anyPhrase,omitempty"`
-	Regex     []string         `json:"regex,omitempty"`
+	Any          []SmartCondition   `json:"any,omitempty"`
+	All          []SmartCondition   `json:"all,omitempty"`
+	None         []SmartCondition   `json:"none,omitempty"`
+	Near         *SmartNear         `json:"near,omitempty"`
+	CountAtLeast *SmartCountAtLeast `json:"countAtLeast,omitempty"`
+	AnyPhrase    []string           `json:"anyPhrase,omitempty"`
+	Regex        []string           `json:"regex,omitempty"`
+}
+
+type SmartNear struct {
+	Terms    []string `json:"terms"`
+	Distance int      `json:"distance"`
+	Ordered  bool     `json:"ordered,omitempty"`
 }
 
+type SmartCountAtLeast struct {
+	Count   int      `json:"count"`
+	Phrases []string `json:"phrases"`
+}
+
+// UnmarshalJSON accepts a single string wherever a list of phrases is expected and a phrase
+// wherever a condition is expected. "not" is read as a none with a single condition.
 func (c *SmartCondition) UnmarshalJSON(data []byte) error {
 	type rawCondition struct {
-		Any       []json.RawMessage `json:"any,omitempty"`
-		All       []json.RawMessage `json:"all,omitempty"`
-		AnyPhrase json.RawMessage   `json:"anyPhrase,omitempty"`
-		Regex     json.RawMessage   `json:"regex,omitempty"`
+		Any          []json.RawMessage `json:"any,omitempty"`
+		All          []json.RawMessage `json:"all,omitempty"`
+		None         []json.RawMessage `json:"none,omitempty"`
+		Not          json.RawMessage   `json:"not,omitempty"`
+		Near         json.RawMessage   `json:"near,omitempty"`
+		CountAtLeast json.RawMessage   `json:"countAtLeast,omitempty"`
+		AnyPhrase    json.RawMessage   `json:"anyPhrase,omitempty"`
+		Regex        json.RawMessage   `json:"regex,omitempty"`
 	}
 
 	var raw rawCondition
@@ -156,14 +240,85 @@ func (c *SmartCondition) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("decode regex: %w", err)
 	}
+	none, err := parseSmartConditionChildren(raw.None)
+	if err != nil {
+		return fmt.Errorf("decode none: %w", err)
+	}
+	not, err := parseSmartConditionNot(raw.Not)
+	if err != nil {
+		return fmt.Errorf("decode not: %w", err)
+	}
+	near, err := parseSmartNear(raw.Near)
+	if err != nil {
+		return fmt.Errorf("decode near: %w", err)
+	}
+	countAtLeast, err := parseSmartCountAtLeast(raw.CountAtLeast)
+	if err != nil {
+		return fmt.Errorf("decode countAtLeast: %w", err)
+	}
 
 	c.Any = any
 	c.All = all
+	c.None = append(none, not...)
+	c.Near = near
+	c.CountAtLeast = countAtLeast
 	c.AnyPhrase = anyPhrase
 	c.Regex = regex
 	return nil
 }
 
+// parseSmartConditionNot reads "not" as either a single condition or a list of conditions.
+func parseSmartConditionNot(raw json.RawMessage) ([]SmartCondition, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var many []json.RawMessage
+	if err := json.Unmarshal(raw, &many); err == nil {
+		return parseSmartConditionChildren(many)
+	}
+	return parseSmartConditionChildren([]json.RawMessage{raw})
+}
+
+func parseSmartNear(raw json.RawMessage) (*SmartNear, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var near struct {
+		Terms    json.RawMessage `json:"terms"`
+		Distance int             `json:"distance"`
+		Ordered  bool            `json:"ordered"`
+	}
+	if err := json.Unmarshal(raw, &near); err != nil {
+		return nil, err
+	}
+	terms, err := parseSmartConditionStrings(near.Terms)
+	if err != nil {
+		return nil, fmt.Errorf("decode terms: %w", err)
+	}
+	return &SmartNear{Terms: terms, Distance: near.Distance, Ordered: near.Ordered}, nil
+}
+
+func parseSmartCountAtLeast(raw json.RawMessage) (*SmartCountAtLeast, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var count struct {
+		Count   int             `json:"count"`
+		Phrases json.RawMessage `json:"phrases"`
+	}
+	if err := json.Unmarshal(raw, &count); err != nil {
+		return nil, err
+	}
+	phrases, err := parseSmartConditionStrings(count.Phrases)
+	if err != nil {
+		return nil, fmt.Errorf("decode phrases: %w", err)
+	}
+	return &SmartCountAtLeast{Count: count.Count, Phrases: phrases}, nil
+}
+
 func parseSmartConditionChildren(items []json.RawMessage) ([]SmartCondition, error) {
 	if len(items) == 0 {
 		return nil, nil
@@ -251,6 +406,8 @@ func toDataSmartFilter(filter SmartFilter) *data.SmartFilter {
 		Scope: data.SmartScope{
 			Language:   toDataSmartScopeList(filter.Scope.Language),
 			Subreddits: toDataSmartScopeList(filter.Scope.Subreddits),
+			Instances:  toDataSmartScopeList(filter.Scope.Instances),
+			Tags:       toDataSmartScopeList(filter.Scope.Tags),
 		},
 		Candidate: data.SmartRule{
 			Where:     append([]string(nil), filter.Candidate.Where...),
@@ -281,6 +438,8 @@ func fromDataSmartFilter(filter data.SmartFilter) *SmartFilter {
 		Scope: SmartScope{
 			Language:   fromDataSmartScopeList(filter.Scope.Language),
 			Subreddits: fromDataSmartScopeList(filter.Scope.Subreddits),
+			Instances:  fromDataSmartScopeList(filter.Scope.Instances),
+			Tags:       fromDataSmartScopeList(filter.Scope.Tags),
 		},
 		Candidate: SmartRule{
 			Where:     append([]string(nil), filter.Candidate.Where...),
@@ -312,6 +471,19 @@ func toDataSmartCondition(condition SmartCondition) data.SmartCondition {
 		AnyPhrase: append([]string(nil), condition.AnyPhrase...),
 		Regex:     append([]string(nil), condition.Regex...),
 	}
+	if condition.Near != nil {
+		out.Near = &data.SmartNear{
+			Terms:    append([]string(nil), condition.Near.Terms...),
+			Distance: condition.Near.Distance,
+			Ordered:  condition.Near.Ordered,
+		}
+	}
+	if condition.CountAtLeast != nil {
+		out.CountAtLeast = &data.SmartCountAtLeast{
+			Count:   condition.CountAtLeast.Count,
+			Phrases: append([]string(nil), condition.CountAtLeast.Phrases...),
+		}
+	}
 	if len(condition.Any) > 0 {
 		out.Any = make([]data.SmartCondition, 0, len(condition.Any))
 		for _, child := range condition.Any {
@@ -324,6 +496,12 @@ func toDataSmartCondition(condition SmartCondition) data.SmartCondition {
 			out.All = append(out.All, toDataSmartCondition(child))
 		}
 	}
+	if len(condition.None) > 0 {
+		out.None = make([]data.SmartCondition, 0, len(condition.None))
+		for _, child := range condition.None {
+			out.None = append(out.None, toDataSmartCondition(child))
+		}
+	}
 	return out
 }
 
@@ -332,6 +510,19 @@ func fromDataSmartCondition(condition data.SmartCondition) SmartCondition {
 		AnyPhrase: append([]string(nil), condition.AnyPhrase...),
 		Regex:     append([]string(nil), condition.Regex...),
 	}
+	if condition.Near != nil {
+		out.Near = &SmartNear{
+			Terms:    append([]string(nil), condition.Near.Terms...),
+			Distance: condition.Near.Distance,
+			Ordered:  condition.Near.Ordered,
+		}
+	}
+	if condition.CountAtLeast != nil {
+		out.CountAtLeast = &SmartCountAtLeast{
+			Count:   condition.CountAtLeast.Count,
+			Phrases: append([]string(nil), condition.CountAtLeast.Phrases...),
+		}
+	}
 	if len(condition.Any) > 0 {
 		out.Any = make([]SmartCondition, 0, len(condition.Any))
 		for _, child := range condition.Any {
@@ -344,6 +535,12 @@ func fromDataSmartCondition(condition data.SmartCondition) SmartCondition {
 			out.All = append(out.All, fromDataSmartCondition(child))
 		}
 	}
+	if len(condition.None) > 0 {
+		out.None = make([]SmartCondition, 0, len(condition.None))
+		for _, child := range condition.None {
+			out.None = append(out.None, fromDataSmartCondition(child))
+		}
+	}
 	return out
 }
 
@@ -358,6 +555,8 @@ type Keyword struct {
 	UnseenCount   int             `json:"unseenCount"`
 	LastMatchedAt *time.Time      `json:"lastMatchedAt,omitempty"`
 	CreatedAt     time.Time       `json:"createdAt"`
+	// Problems lists the invalid parts of the stored smart filter, which are ignored when matching.
+	Problems []string `json:"problems,omitempty"`
 }
 
 type GetKeywordsResponse struct {
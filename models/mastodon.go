@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type MastodonStatus struct {
+	ID          string          `json:"id"`
+	URI         string          `json:"uri"`
+	URL         string          `json:"url"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Content     string          `json:"content"`
+	SpoilerText string          `json:"spoiler_text"`
+	Sensitive   bool            `json:"sensitive"`
+	Account     MastodonAccount `json:"account"`
+	Reblog      *MastodonStatus `json:"reblog"`
+}
+
+type MastodonAccount struct {
+	Acct     string `json:"acct"`
+	Username string `json:"username"`
+	URL      string `json:"url"`
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+)
 
 type Match struct {
 	ID        int        `json:"id"`
@@ -8,7 +14,30 @@ type Match struct {
 	Source    string     `json:"source"`
 	CreatedAt time.Time  `json:"createdAt"`
 	SeenAt    *time.Time `json:"seenAt,omitempty"`
-	Data      RedditData `json:"data"`
+	// Data holds the source specific fields of the match, e.g. RedditData or HackerNewsData.
+	Data any `json:"data"`
+
+	AlsoPostedIn []MatchLocation `json:"alsoPostedIn,omitempty"`
+}
+
+type MatchLocation struct {
+	Source    string `json:"source"`
+	Subreddit string `json:"subreddit,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Permalink string `json:"permalink"`
+}
+
+func FromDataMatchLocations(locations []data.MatchLocation) []MatchLocation {
+	out := make([]MatchLocation, 0, len(locations))
+	for _, l := range locations {
+		out = append(out, MatchLocation{
+			Source:    string(l.Source),
+			Subreddit: l.Subreddit,
+			Instance:  l.Instance,
+			Permalink: l.Permalink,
+		})
+	}
+	return out
 }
 
 type RedditData struct {
@@ -18,6 +47,229 @@ type RedditData struct {
 	Body      string `json:"body"`
 	Permalink string `json:"permalink"`
 	IsComment bool   `json:"isComment"`
+
+	ParentTitle string `json:"parentTitle,omitempty"`
+	ParentBody  string `json:"parentBody,omitempty"`
+
+	Score       int    `json:"score,omitempty"`
+	NumComments int    `json:"numComments,omitempty"`
+	Flair       string `json:"flair,omitempty"`
+	AuthorFlair string `json:"authorFlair,omitempty"`
+	NSFW        bool   `json:"nsfw,omitempty"`
+	IsSelf      bool   `json:"isSelf,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+}
+
+func FromDataRedditData(d data.RedditData) RedditData {
+	return RedditData{
+		Subreddit:   d.Subreddit,
+		Author:      d.Author,
+		Title:       d.Title,
+		Body:        d.Body,
+		Permalink:   d.Permalink,
+		IsComment:   d.IsComment,
+		ParentTitle: d.ParentTitle,
+		ParentBody:  d.ParentBody,
+		Score:       d.Score,
+		NumComments: d.NumComments,
+		Flair:       d.Flair,
+		AuthorFlair: d.AuthorFlair,
+		NSFW:        d.NSFW,
+		IsSelf:      d.IsSelf,
+		URL:         d.URL,
+		Domain:      d.Domain,
+	}
+}
+
+type HackerNewsData struct {
+	ItemID     int64  `json:"itemId"`
+	StoryID    int64  `json:"storyId"`
+	StoryTitle string `json:"storyTitle,omitempty"`
+	Author     string `json:"author"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+	Points     int    `json:"points,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Permalink  string `json:"permalink"`
+	IsComment  bool   `json:"isComment"`
+}
+
+type MastodonData struct {
+	StatusID       string `json:"statusId"`
+	Instance       string `json:"instance"`
+	Account        string `json:"account"`
+	ContentWarning string `json:"contentWarning,omitempty"`
+	Body           string `json:"body"`
+	Permalink      string `json:"permalink"`
+}
+
+type BlueskyData struct {
+	DID       string `json:"did"`
+	RKey      string `json:"rkey"`
+	Body      string `json:"body"`
+	Permalink string `json:"permalink"`
+	IsReply   bool   `json:"isReply"`
+}
+
+type GitHubData struct {
+	Repo      string   `json:"repo"`
+	Number    int      `json:"number"`
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels"`
+	State     string   `json:"state"`
+	Permalink string   `json:"permalink"`
+	IsComment bool     `json:"isComment"`
+}
+
+type StackExchangeData struct {
+	Site       string   `json:"site"`
+	QuestionID int64    `json:"questionId"`
+	AnswerID   int64    `json:"answerId,omitempty"`
+	Title      string   `json:"title"`
+	Body       string   `json:"body"`
+	Author     string   `json:"author"`
+	Tags       []string `json:"tags"`
+	Score      int      `json:"score"`
+	Permalink  string   `json:"permalink"`
+	IsAnswer   bool     `json:"isAnswer"`
+}
+
+type IngestData struct {
+	Source    string         `json:"source"`
+	ItemID    string         `json:"itemId"`
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Author    string         `json:"author"`
+	Permalink string         `json:"permalink"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+type FeedData struct {
+	FeedID    int    `json:"feedId"`
+	FeedTitle string `json:"feedTitle"`
+	Author    string `json:"author"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Permalink string `json:"permalink"`
+}
+
+// FromDataMatchData decodes the stored payload of a match from source into its API model.
+func FromDataMatchData(source enums.Source, raw json.RawMessage) (any, error) {
+	switch source {
+	case enums.SourceHackerNews:
+		var d data.HackerNewsData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return HackerNewsData{
+			ItemID:     d.ItemID,
+			StoryID:    d.StoryID,
+			StoryTitle: d.StoryTitle,
+			Author:     d.Author,
+			Title:      d.Title,
+			Body:       d.Body,
+			Points:     d.Points,
+			URL:        d.URL,
+			Permalink:  d.Permalink,
+			IsComment:  d.IsComment,
+		}, nil
+	case enums.SourceMastodon:
+		var d data.MastodonData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return MastodonData{
+			StatusID:       d.StatusID,
+			Instance:       d.Instance,
+			Account:        d.Account,
+			ContentWarning: d.ContentWarning,
+			Body:           d.Body,
+			Permalink:      d.Permalink,
+		}, nil
+	case enums.SourceBluesky:
+		var d data.BlueskyData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return BlueskyData{
+			DID:       d.DID,
+			RKey:      d.RKey,
+			Body:      d.Body,
+			Permalink: d.Permalink,
+			IsReply:   d.IsReply,
+		}, nil
+	case enums.SourceGitHub:
+		var d data.GitHubData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return GitHubData{
+			Repo:      d.Repo,
+			Number:    d.Number,
+			Type:      d.Type,
+			Title:     d.Title,
+			Body:      d.Body,
+			Author:    d.Author,
+			Labels:    d.Labels,
+			State:     d.State,
+			Permalink: d.Permalink,
+			IsComment: d.IsComment,
+		}, nil
+	case enums.SourceStackExchange:
+		var d data.StackExchangeData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return StackExchangeData{
+			Site:       d.Site,
+			QuestionID: d.QuestionID,
+			AnswerID:   d.AnswerID,
+			Title:      d.Title,
+			Body:       d.Body,
+			Author:     d.Author,
+			Tags:       d.Tags,
+			Score:      d.Score,
+			Permalink:  d.Permalink,
+			IsAnswer:   d.IsAnswer,
+		}, nil
+	case enums.SourceIngest:
+		var d data.IngestData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return IngestData{
+			Source:    d.Source,
+			ItemID:    d.ItemID,
+			Title:     d.Title,
+			Body:      d.Body,
+			Author:    d.Author,
+			Permalink: d.Permalink,
+			Metadata:  d.Metadata,
+		}, nil
+	case enums.SourceFeed:
+		var d data.FeedData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return FeedData{
+			FeedID:    d.FeedID,
+			FeedTitle: d.FeedTitle,
+			Author:    d.Author,
+			Title:     d.Title,
+			Body:      d.Body,
+			Permalink: d.Permalink,
+		}, nil
+	default:
+		var d data.RedditData
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return nil, err
+		}
+		return FromDataRedditData(d), nil
+	}
 }
 
 type GetMatchesResponse struct {
@@ -1,24 +1,43 @@
 package models
 
+type RedditTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
 type RedditListing struct {
-	Data struct {
-		After    string `json:"after"`
-		Before   string `json:"before"`
-		Children []struct {
-			Data RedditPost `json:"data"`
-		} `json:"children"`
-	} `json:"data"`
+	Data RedditListingData `json:"data"`
+}
+
+type RedditListingData struct {
+	After    string        `json:"after"`
+	Children []RedditThing `json:"children"`
 }
 
-type RedditPost struct {
+type RedditThing struct {
+	Kind string          `json:"kind"`
+	Data RedditThingData `json:"data"`
+}
+
+type RedditThingData struct {
 	ID         string  `json:"id"`
-	Name       string  `json:"name"` // fullname, e.g. "t3_abc123" or "t1_xyz789"
+	Name       string  `json:"name"`
+	Subreddit  string  `json:"subreddit"`
+	Author     string  `json:"author"`
 	Title      string  `json:"title"`
 	Selftext   string  `json:"selftext"`
 	Body       string  `json:"body"`
-	Author     string  `json:"author"`
-	Subreddit  string  `json:"subreddit"`
-	Permalink  string  `json:"permalink"`
-	CreatedUTC float64 `json:"created_utc"`
 	LinkID     string  `json:"link_id"`
+	LinkTitle  string  `json:"link_title"`
+	CreatedUTC float64 `json:"created_utc"`
+
+	Score           int    `json:"score"`
+	NumComments     int    `json:"num_comments"`
+	LinkFlairText   string `json:"link_flair_text"`
+	AuthorFlairText string `json:"author_flair_text"`
+	Over18          bool   `json:"over_18"`
+	IsSelf          bool   `json:"is_self"`
+	URL             string `json:"url"`
+	Domain          string `json:"domain"`
 }
@@ -0,0 +1,33 @@
+package models
+
+type StackExchangeResponse[T any] struct {
+	Items          []T  `json:"items"`
+	HasMore        bool `json:"has_more"`
+	QuotaMax       int  `json:"quota_max"`
+	QuotaRemaining int  `json:"quota_remaining"`
+	Backoff        int  `json:"backoff"`
+}
+
+type StackExchangeQuestion struct {
+	QuestionID   int64              `json:"question_id"`
+	Title        string             `json:"title"`
+	Body         string             `json:"body"`
+	Link         string             `json:"link"`
+	Tags         []string           `json:"tags"`
+	Score        int                `json:"score"`
+	CreationDate int64              `json:"creation_date"`
+	Owner        StackExchangeOwner `json:"owner"`
+}
+
+type StackExchangeAnswer struct {
+	AnswerID     int64              `json:"answer_id"`
+	QuestionID   int64              `json:"question_id"`
+	Body         string             `json:"body"`
+	Score        int                `json:"score"`
+	CreationDate int64              `json:"creation_date"`
+	Owner        StackExchangeOwner `json:"owner"`
+}
+
+type StackExchangeOwner struct {
+	DisplayName string `json:"display_name"`
+}
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/kova98/feedgrep.api/enums"
@@ -18,7 +19,12 @@ type ArcticShiftMonitor struct {
 	processingDuration  *prometheus.HistogramVec
 	matchEvaluation     *prometheus.HistogramVec
 	lagSeconds          *prometheus.GaugeVec
+	gapItemsRecovered   *prometheus.CounterVec
 	registeredCollector []prometheus.Collector
+
+	startedAt            time.Time
+	newestPostCreated    atomic.Int64
+	newestCommentCreated atomic.Int64
 }
 
 func NewArcticShiftMonitor() *ArcticShiftMonitor {
@@ -71,6 +77,15 @@ func NewArcticShiftMonitor() *ArcticShiftMonitor {
 			},
 			[]string{"kind"},
 		),
+		gapItemsRecovered: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "arcticshift",
+				Name:      "gap_items_recovered_total",
+				Help:      "Total ArcticShift items recovered beyond the first page of a poll or by backfill.",
+			},
+			[]string{"kind", "via"},
+		),
 	}
 	m.registeredCollector = []prometheus.Collector{
 		m.itemsProcessed,
@@ -78,7 +93,9 @@ func NewArcticShiftMonitor() *ArcticShiftMonitor {
 		m.processingDuration,
 		m.matchEvaluation,
 		m.lagSeconds,
+		m.gapItemsRecovered,
 	}
+	m.startedAt = time.Now()
 	m.initSeries()
 	return m
 }
@@ -87,12 +104,28 @@ func (m *ArcticShiftMonitor) Register(registerer prometheus.Registerer) {
 	registerer.MustRegister(m.registeredCollector...)
 }
 
-func (m *ArcticShiftMonitor) PostBatch(count int64, requestDuration time.Duration, processingStart time.Time, newestCreatedUTC int64) {
-	m.captureBatch(arcticShiftKindPost, count, requestDuration, processingStart, newestCreatedUTC)
+// LagSeconds returns the age of the newest item processed by the slower of the post and comment pollers.
+// Until a kind has produced its first batch, its lag is measured from when the monitor was created.
+func (m *ArcticShiftMonitor) LagSeconds() int64 {
+	return max(m.kindLagSeconds(&m.newestPostCreated), m.kindLagSeconds(&m.newestCommentCreated))
 }
 
-func (m *ArcticShiftMonitor) CommentBatch(count int64, requestDuration time.Duration, processingStart time.Time, newestCreatedUTC int64) {
-	m.captureBatch(arcticShiftKindComment, count, requestDuration, processingStart, newestCreatedUTC)
+func (m *ArcticShiftMonitor) kindLagSeconds(newest *atomic.Int64) int64 {
+	created := newest.Load()
+	if created <= 0 {
+		return int64(time.Since(m.startedAt).Seconds())
+	}
+	return feedLagSeconds(created)
+}
+
+func (m *ArcticShiftMonitor) PostBatch(count int64, processingStart time.Time, newestCreatedUTC int64) {
+	storeNewest(&m.newestPostCreated, newestCreatedUTC)
+	m.captureBatch(arcticShiftKindPost, count, processingStart, newestCreatedUTC)
+}
+
+func (m *ArcticShiftMonitor) CommentBatch(count int64, processingStart time.Time, newestCreatedUTC int64) {
+	storeNewest(&m.newestCommentCreated, newestCreatedUTC)
+	m.captureBatch(arcticShiftKindComment, count, processingStart, newestCreatedUTC)
 }
 
 func (m *ArcticShiftMonitor) PostRequestError(requestDuration time.Duration) {
@@ -119,9 +152,16 @@ func (m *ArcticShiftMonitor) CommentMatchEvaluation(matchMode string, start time
 	m.captureMatchEvaluation(arcticShiftKindComment, matchMode, start)
 }
 
-func (m *ArcticShiftMonitor) captureBatch(kind string, count int64, requestDuration time.Duration, processingStart time.Time, newestCreatedUTC int64) {
+func (m *ArcticShiftMonitor) PostGapRecovered(via string, count int64) {
+	m.gapItemsRecovered.WithLabelValues(arcticShiftKindPost, via).Add(float64(count))
+}
+
+func (m *ArcticShiftMonitor) CommentGapRecovered(via string, count int64) {
+	m.gapItemsRecovered.WithLabelValues(arcticShiftKindComment, via).Add(float64(count))
+}
+
+func (m *ArcticShiftMonitor) captureBatch(kind string, count int64, processingStart time.Time, newestCreatedUTC int64) {
 	m.itemsProcessed.WithLabelValues(kind).Add(float64(count))
-	m.captureRequest(kind, "ok", requestDuration)
 	m.processingDuration.WithLabelValues(kind).Observe(time.Since(processingStart).Seconds())
 	m.lagSeconds.WithLabelValues(kind).Set(float64(feedLagSeconds(newestCreatedUTC)))
 }
@@ -134,6 +174,15 @@ func (m *ArcticShiftMonitor) captureMatchEvaluation(kind string, matchMode strin
 	m.matchEvaluation.WithLabelValues(kind, matchMode).Observe(time.Since(start).Seconds())
 }
 
+func storeNewest(newest *atomic.Int64, createdUTC int64) {
+	for {
+		current := newest.Load()
+		if createdUTC <= current || newest.CompareAndSwap(current, createdUTC) {
+			return
+		}
+	}
+}
+
 func feedLagSeconds(newestCreatedUTC int64) int64 {
 	if newestCreatedUTC <= 0 {
 		return 0
@@ -148,7 +197,9 @@ func (m *ArcticShiftMonitor) initSeries() {
 		m.requestDuration.WithLabelValues(kind, "error")
 		m.processingDuration.WithLabelValues(kind)
 		m.lagSeconds.WithLabelValues(kind).Set(0)
-		for _, matchMode := range []enums.MatchMode{enums.MatchModeBroad, enums.MatchModeExact, enums.MatchModeSmart} {
+		m.gapItemsRecovered.WithLabelValues(kind, "pagination").Add(0)
+		m.gapItemsRecovered.WithLabelValues(kind, "backfill").Add(0)
+		for _, matchMode := range []enums.MatchMode{enums.MatchModeBroad, enums.MatchModeExact, enums.MatchModeSmart, enums.MatchModeQuery} {
 			m.matchEvaluation.WithLabelValues(kind, string(matchMode))
 		}
 	}
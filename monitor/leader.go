@@ -0,0 +1,49 @@
+package monitor
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type LeaderMonitor struct {
+	isLeader    *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+}
+
+func NewLeaderMonitor() *LeaderMonitor {
+	return &LeaderMonitor{
+		isLeader: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "leader",
+				Name:      "is_leader",
+				Help:      "Whether this instance currently holds the leader lock.",
+			},
+			[]string{"name"},
+		),
+		transitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "leader",
+				Name:      "transitions_total",
+				Help:      "Total leadership changes of this instance.",
+			},
+			[]string{"name", "event"},
+		),
+	}
+}
+
+func (m *LeaderMonitor) Register(registerer prometheus.Registerer) {
+	registerer.MustRegister(m.isLeader, m.transitions)
+}
+
+func (m *LeaderMonitor) Elected(name string) {
+	m.isLeader.WithLabelValues(name).Set(1)
+	m.transitions.WithLabelValues(name, "elected").Inc()
+}
+
+func (m *LeaderMonitor) Lost(name string) {
+	m.isLeader.WithLabelValues(name).Set(0)
+	m.transitions.WithLabelValues(name, "lost").Inc()
+}
+
+func (m *LeaderMonitor) Follower(name string) {
+	m.isLeader.WithLabelValues(name).Set(0)
+}
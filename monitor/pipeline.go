@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type PipelineMonitor struct {
+	queueDepth       prometheus.Gauge
+	queueCapacity    prometheus.Gauge
+	enqueueWait      *prometheus.HistogramVec
+	busyWorkers      prometheus.Gauge
+	pendingBatches   *prometheus.GaugeVec
+	writeBatchSize   prometheus.Histogram
+	writeDuration    prometheus.Histogram
+	writeErrors      prometheus.Counter
+	registeredMetric []prometheus.Collector
+}
+
+func NewPipelineMonitor() *PipelineMonitor {
+	m := &PipelineMonitor{
+		queueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "queue_depth",
+				Help:      "Item chunks waiting for a matcher worker.",
+			},
+		),
+		queueCapacity: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "queue_capacity",
+				Help:      "Maximum item chunks the matcher queue holds before fetchers block.",
+			},
+		),
+		enqueueWait: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "enqueue_wait_seconds",
+				Help:      "Time a fetcher was blocked handing a batch to the matcher queue.",
+				Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"source"},
+		),
+		busyWorkers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "busy_workers",
+				Help:      "Matcher workers currently evaluating items.",
+			},
+		),
+		pendingBatches: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "pending_batches",
+				Help:      "Submitted batches whose cursor has not been committed yet.",
+			},
+			[]string{"source"},
+		),
+		writeBatchSize: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "write_batch_size",
+				Help:      "Matches stored per write.",
+				Buckets:   prometheus.ExponentialBuckets(1, 4, 7),
+			},
+		),
+		writeDuration: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "write_duration_seconds",
+				Help:      "Latency of storing a batch of matches in seconds.",
+				Buckets:   prometheus.DefBuckets,
+			},
+		),
+		writeErrors: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "pipeline",
+				Name:      "write_errors_total",
+				Help:      "Total failed attempts to store a batch of matches.",
+			},
+		),
+	}
+	m.registeredMetric = []prometheus.Collector{
+		m.queueDepth,
+		m.queueCapacity,
+		m.enqueueWait,
+		m.busyWorkers,
+		m.pendingBatches,
+		m.writeBatchSize,
+		m.writeDuration,
+		m.writeErrors,
+	}
+	return m
+}
+
+func (m *PipelineMonitor) Register(registerer prometheus.Registerer) {
+	registerer.MustRegister(m.registeredMetric...)
+}
+
+func (m *PipelineMonitor) QueueCapacity(capacity int) {
+	m.queueCapacity.Set(float64(capacity))
+}
+
+func (m *PipelineMonitor) QueueDepth(depth int) {
+	m.queueDepth.Set(float64(depth))
+}
+
+func (m *PipelineMonitor) EnqueueWait(source string, start time.Time) {
+	m.enqueueWait.WithLabelValues(source).Observe(time.Since(start).Seconds())
+}
+
+func (m *PipelineMonitor) WorkerBusy() {
+	m.busyWorkers.Inc()
+}
+
+func (m *PipelineMonitor) WorkerIdle() {
+	m.busyWorkers.Dec()
+}
+
+func (m *PipelineMonitor) PendingBatches(source string, count int) {
+	m.pendingBatches.WithLabelValues(source).Set(float64(count))
+}
+
+func (m *PipelineMonitor) Write(count int, start time.Time) {
+	m.writeBatchSize.Observe(float64(count))
+	m.writeDuration.Observe(time.Since(start).Seconds())
+}
+
+func (m *PipelineMonitor) WriteError() {
+	m.writeErrors.Inc()
+}
@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type SourceMonitor struct {
+	itemsProcessed     *prometheus.CounterVec
+	requestErrors      *prometheus.CounterVec
+	processingDuration *prometheus.HistogramVec
+	lagSeconds         *prometheus.GaugeVec
+	quotaRemaining     *prometheus.GaugeVec
+	requestRetries     *prometheus.CounterVec
+	breakerState       *prometheus.GaugeVec
+}
+
+// Breaker states are exported as gauge values so dashboards can graph them.
+var breakerStateValues = map[string]float64{
+	"closed":    0,
+	"half_open": 1,
+	"open":      2,
+}
+
+func NewSourceMonitor() *SourceMonitor {
+	return &SourceMonitor{
+		itemsProcessed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "items_processed_total",
+				Help:      "Total items processed per ingestion source.",
+			},
+			[]string{"source"},
+		),
+		requestErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "request_errors_total",
+				Help:      "Total failed fetches per ingestion source.",
+			},
+			[]string{"source"},
+		),
+		processingDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "processing_duration_seconds",
+				Help:      "Batch processing latency per ingestion source in seconds.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"source"},
+		),
+		lagSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "lag_seconds",
+				Help:      "Age in seconds of the newest item processed in the latest batch per ingestion source.",
+			},
+			[]string{"source"},
+		),
+		quotaRemaining: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "quota_remaining",
+				Help:      "Remaining API quota reported by rate-limited ingestion sources.",
+			},
+			[]string{"source"},
+		),
+		requestRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "request_retries_total",
+				Help:      "Total retried requests per ingestion source.",
+			},
+			[]string{"source"},
+		),
+		breakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "feedgrep",
+				Subsystem: "sources",
+				Name:      "circuit_breaker_state",
+				Help:      "Circuit breaker state per ingestion source: 0 closed, 1 half-open, 2 open.",
+			},
+			[]string{"source"},
+		),
+	}
+}
+
+func (m *SourceMonitor) Register(registerer prometheus.Registerer) {
+	registerer.MustRegister(m.itemsProcessed, m.requestErrors, m.processingDuration, m.lagSeconds, m.quotaRemaining, m.requestRetries, m.breakerState)
+}
+
+func (m *SourceMonitor) Batch(source string, count int64, processingStart time.Time, newestCreatedUTC int64) {
+	m.itemsProcessed.WithLabelValues(source).Add(float64(count))
+	m.processingDuration.WithLabelValues(source).Observe(time.Since(processingStart).Seconds())
+	m.lagSeconds.WithLabelValues(source).Set(float64(feedLagSeconds(newestCreatedUTC)))
+}
+
+func (m *SourceMonitor) RequestError(source string) {
+	m.requestErrors.WithLabelValues(source).Inc()
+}
+
+func (m *SourceMonitor) QuotaRemaining(source string, remaining int) {
+	m.quotaRemaining.WithLabelValues(source).Set(float64(remaining))
+}
+
+func (m *SourceMonitor) RequestRetry(source string) {
+	m.requestRetries.WithLabelValues(source).Inc()
+}
+
+func (m *SourceMonitor) BreakerState(source string, state string) {
+	m.breakerState.WithLabelValues(source).Set(breakerStateValues[state])
+}
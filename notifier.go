@@ -13,6 +13,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// notifyClaimLease is how long claimed matches are held by a notifier that stopped before it
+// could notify them, after which another notifier may claim them.
+const notifyClaimLease = 10 * time.Minute
+
 type Notifier struct {
 	matchRepo *repos.MatchRepo
 	usersRepo *repos.UserRepo
@@ -29,30 +33,30 @@ func NewNotifier(mailer *notifiers.Mailer, matchRepo *repos.MatchRepo, usersRepo
 	}
 }
 
+// Start notifies users about new matches every minute until ctx is cancelled.
 func (n *Notifier) Start(ctx context.Context) {
 	if err := n.notifyUsers(); err != nil {
 		slog.Error("notify users:", "error", err)
 	}
 
-	go func() {
-		ticker := time.NewTicker(1 * time.Minute)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if err := n.notifyUsers(); err != nil {
-					slog.Error("notify users:", "error", err)
-				}
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.notifyUsers(); err != nil {
+				slog.Error("notify users:", "error", err)
 			}
 		}
-	}()
+	}
 }
 
 func (n *Notifier) notifyUsers() error {
-	unnotified, err := n.matchRepo.GetUnnotifiedMatches()
+	unnotified, err := n.matchRepo.ClaimUnnotifiedMatches(notifyClaimLease)
 	if err != nil {
-		return errors.Wrap(err, "notify users: get unnotified matches")
+		return errors.Wrap(err, "notify users: claim unnotified matches")
 	}
 	if len(unnotified) == 0 {
 		return nil
@@ -70,6 +74,7 @@ func (n *Notifier) notifyUsers() error {
 
 	u, err := n.usersRepo.GetUsersByIDs(userIDs)
 	if err != nil {
+		n.release(unnotified)
 		return errors.Wrap(err, "notify users: get users by IDs")
 	}
 	users := make(map[uuid.UUID]data.User)
@@ -81,17 +86,20 @@ func (n *Notifier) notifyUsers() error {
 		user, ok := users[userID]
 		if !ok {
 			slog.Error("notify users: user not found", "userID", userID)
+			n.release(matches)
 			continue
 		}
 
 		if len(matches) == 1 {
-			mail, err := n.mailer.RedditMatchEmail(user.Email, matches[0])
+			mail, err := n.mailer.MatchEmail(user.Email, matches[0])
 			if err != nil {
 				slog.Error("notify users: create email", "userID", userID, "error", err)
+				n.release(matches)
 				continue
 			}
 			if err = n.mailer.Send(mail); err != nil {
 				slog.Error("notify users: send match notification", "userID", userID, "error", err)
+				n.release(matches)
 				continue
 			}
 			n.monitor.MatchEmailSent()
@@ -101,14 +109,16 @@ func (n *Notifier) notifyUsers() error {
 			continue
 		}
 
-		digest, err := n.mailer.RedditDigestEmail(user.Email, matches)
+		digest, err := n.mailer.DigestEmail(user.Email, matches)
 		if err != nil {
 			slog.Error("notify users: create digest email", "userID", userID, "error", err)
+			n.release(matches)
 			continue
 		}
 		err = n.mailer.Send(digest)
 		if err != nil {
 			slog.Error("notify users: send digest notification", "userID", userID, "error", err)
+			n.release(matches)
 			continue
 		}
 		n.monitor.DigestEmailSent()
@@ -125,3 +135,14 @@ func (n *Notifier) notifyUsers() error {
 
 	return nil
 }
+
+// release hands matches that could not be notified back, so the next run retries them.
+func (n *Notifier) release(matches []data.Match) {
+	ids := make([]int64, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, int64(match.ID))
+	}
+	if err := n.matchRepo.ReleaseNotifyClaims(ids); err != nil {
+		slog.Error("notify users: release claims", "error", err)
+	}
+}
@@ -11,13 +11,14 @@ import (
 	"text/template"
 
 	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
 	"github.com/kova98/feedgrep.api/models"
 )
 
-//go:embed templates/reddit_match.html templates/reddit_digest.html
+//go:embed templates/match.html templates/digest.html
 var emailTemplates embed.FS
 
-var redditTemplates = template.Must(template.New("emails").ParseFS(emailTemplates, "templates/*.html"))
+var matchTemplates = template.Must(template.New("emails").ParseFS(emailTemplates, "templates/*.html"))
 
 type Mailer struct {
 	smtpHost string
@@ -28,6 +29,29 @@ type Mailer struct {
 	appBase  string
 }
 
+// matchView holds the source-specific parts of a match rendered by the email templates.
+type matchView struct {
+	Keyword          string
+	Location         string
+	Author           string
+	MatchType        string
+	Details          string
+	Context          string
+	Title            string
+	Body             string
+	URL              string
+	LinkLabel        string
+	ExternalURL      string
+	KeywordConfigURL string
+	AlsoPostedIn     []locationView
+}
+
+// locationView is another place a near-duplicate of a match was posted in.
+type locationView struct {
+	Label string
+	URL   string
+}
+
 func NewMailer(smtpHost, smtpPort, from, username, password, appBase string) *Mailer {
 	return &Mailer{
 		smtpHost: smtpHost,
@@ -39,112 +63,39 @@ func NewMailer(smtpHost, smtpPort, from, username, password, appBase string) *Ma
 	}
 }
 
-func (h *Mailer) RedditMatchEmail(email string, match data.Match) (models.Email, error) {
-	var payload data.RedditData
-	if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+func (h *Mailer) MatchEmail(email string, match data.Match) (models.Email, error) {
+	view, err := h.buildMatchView(match, 500)
+	if err != nil {
 		return models.Email{}, err
 	}
 
-	subject := "feedgrep: new match"
-
-	matchType := "Post"
-	if payload.IsComment {
-		matchType = "Comment"
-	}
-
-	body := strings.TrimSpace(payload.Body)
-	if len(body) > 500 {
-		body = body[:500] + "..."
-	}
-	body = strings.ReplaceAll(body, "\n", "<br>")
-
-	url := "https://reddit.com" + payload.Permalink
-
 	var buf bytes.Buffer
-	tmplData := struct {
-		Keyword          string
-		Subreddit        string
-		Author           string
-		MatchType        string
-		Title            string
-		Body             string
-		URL              string
-		KeywordConfigURL string
-	}{
-		Keyword:          payload.Keyword,
-		Subreddit:        payload.Subreddit,
-		Author:           payload.Author,
-		MatchType:        matchType,
-		Title:            payload.Title,
-		Body:             body,
-		URL:              url,
-		KeywordConfigURL: h.keywordConfigURL(match.KeywordID),
-	}
-	if err := redditTemplates.ExecuteTemplate(&buf, "reddit_match.html", tmplData); err != nil {
-		return models.Email{}, fmt.Errorf("render reddit match template: %w", err)
+	if err := matchTemplates.ExecuteTemplate(&buf, "match.html", view); err != nil {
+		return models.Email{}, fmt.Errorf("render match template: %w", err)
 	}
 
 	return models.Email{
 		To:      email,
-		Subject: subject,
+		Subject: "feedgrep: new match",
 		Body:    buf.String(),
 	}, nil
 }
 
-func (h *Mailer) RedditDigestEmail(email string, matches []data.Match) (models.Email, error) {
-	type digestItem struct {
-		Keyword          string
-		Subreddit        string
-		Author           string
-		Title            string
-		Body             string
-		URL              string
-		MatchType        string
-		KeywordConfigURL string
-	}
-
-	items := make([]digestItem, 0, 10)
+func (h *Mailer) DigestEmail(email string, matches []data.Match) (models.Email, error) {
+	items := make([]matchView, 0, 10)
 	keywordSet := make(map[string]struct{})
 	total := 0
 	for _, match := range matches {
-		var payload data.RedditData
-		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+		view, err := h.buildMatchView(match, 300)
+		if err != nil {
 			continue
 		}
 		total++
-		keywordSet[payload.Keyword] = struct{}{}
+		keywordSet[view.Keyword] = struct{}{}
 		if len(items) >= 10 {
 			continue
 		}
-
-		matchType := "Post"
-		if payload.IsComment {
-			matchType = "Comment"
-		}
-
-		url := payload.Permalink
-		if !strings.HasPrefix(url, "http") {
-			url = "https://reddit.com" + url
-		}
-
-		title := strings.TrimSpace(payload.Title)
-
-		body := strings.TrimSpace(payload.Body)
-		if len(body) > 300 {
-			body = body[:300] + "..."
-		}
-		body = strings.ReplaceAll(body, "\n", "<br>")
-
-		items = append(items, digestItem{
-			Keyword:          payload.Keyword,
-			Subreddit:        payload.Subreddit,
-			Author:           payload.Author,
-			Title:            title,
-			Body:             body,
-			URL:              url,
-			MatchType:        matchType,
-			KeywordConfigURL: h.keywordConfigURL(match.KeywordID),
-		})
+		items = append(items, view)
 	}
 
 	if total == 0 {
@@ -163,7 +114,7 @@ func (h *Mailer) RedditDigestEmail(email string, matches []data.Match) (models.E
 
 	var buf bytes.Buffer
 	tmplData := struct {
-		Items     []digestItem
+		Items     []matchView
 		Keywords  []string
 		Total     int
 		Remaining int
@@ -173,8 +124,8 @@ func (h *Mailer) RedditDigestEmail(email string, matches []data.Match) (models.E
 		Total:     total,
 		Remaining: remaining,
 	}
-	if err := redditTemplates.ExecuteTemplate(&buf, "reddit_digest.html", tmplData); err != nil {
-		return models.Email{}, fmt.Errorf("render reddit digest template: %w", err)
+	if err := matchTemplates.ExecuteTemplate(&buf, "digest.html", tmplData); err != nil {
+		return models.Email{}, fmt.Errorf("render digest template: %w", err)
 	}
 
 	subject := "feedgrep: new match"
@@ -186,6 +137,233 @@ func (h *Mailer) RedditDigestEmail(email string, matches []data.Match) (models.E
 	}, nil
 }
 
+func githubMatchType(githubType string) string {
+	switch githubType {
+	case "pull_request":
+		return "Pull request"
+	case "discussion":
+		return "Discussion"
+	default:
+		return "Issue"
+	}
+}
+
+func redditPostDetails(payload data.RedditData) string {
+	details := make([]string, 0, 2)
+	if flair := strings.TrimSpace(payload.Flair); flair != "" {
+		details = append(details, flair)
+	}
+	if payload.NSFW {
+		details = append(details, "NSFW")
+	}
+	return strings.Join(details, " · ")
+}
+
+func (h *Mailer) buildMatchView(match data.Match, bodyLimit int) (matchView, error) {
+	var view matchView
+	switch match.Source {
+	case enums.SourceHackerNews:
+		var payload data.HackerNewsData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:     payload.Keyword,
+			Location:    "Hacker News",
+			Author:      payload.Author,
+			MatchType:   "Story",
+			Title:       strings.TrimSpace(payload.Title),
+			Body:        payload.Body,
+			URL:         payload.Permalink,
+			LinkLabel:   "View on Hacker News",
+			ExternalURL: payload.URL,
+		}
+		if payload.IsComment {
+			view.MatchType = "Comment"
+			view.Context = strings.TrimSpace(payload.StoryTitle)
+		} else if payload.Points > 0 {
+			view.Details = fmt.Sprintf("%d points", payload.Points)
+		}
+	case enums.SourceMastodon:
+		var payload data.MastodonData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  payload.Instance,
+			Author:    "@" + payload.Account,
+			MatchType: "Post",
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View on Mastodon",
+		}
+		if payload.ContentWarning != "" {
+			view.Details = "CW: " + payload.ContentWarning
+		}
+	case enums.SourceBluesky:
+		var payload data.BlueskyData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  "Bluesky",
+			Author:    payload.DID,
+			MatchType: "Post",
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View on Bluesky",
+		}
+		if payload.IsReply {
+			view.MatchType = "Reply"
+		}
+	case enums.SourceGitHub:
+		var payload data.GitHubData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  fmt.Sprintf("%s#%d", payload.Repo, payload.Number),
+			Author:    payload.Author,
+			MatchType: githubMatchType(payload.Type),
+			Title:     strings.TrimSpace(payload.Title),
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View on GitHub",
+		}
+		details := []string{payload.State}
+		if len(payload.Labels) > 0 {
+			details = append(details, strings.Join(payload.Labels, ", "))
+		}
+		view.Details = strings.Join(details, " · ")
+		if payload.IsComment {
+			view.MatchType = "Comment"
+			view.Context = view.Title
+			view.Title = ""
+		}
+	case enums.SourceStackExchange:
+		var payload data.StackExchangeData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  payload.Site,
+			Author:    payload.Author,
+			MatchType: "Question",
+			Title:     strings.TrimSpace(payload.Title),
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View on Stack Exchange",
+		}
+		if payload.IsAnswer {
+			view.MatchType = "Answer"
+		}
+		if len(payload.Tags) > 0 {
+			view.Details = strings.Join(payload.Tags, ", ")
+		}
+	case enums.SourceIngest:
+		var payload data.IngestData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  payload.Source,
+			Author:    payload.Author,
+			MatchType: "Item",
+			Title:     strings.TrimSpace(payload.Title),
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View item",
+		}
+		if view.Author == "" {
+			view.Author = "unknown author"
+		}
+	case enums.SourceFeed:
+		var payload data.FeedData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  payload.FeedTitle,
+			Author:    payload.Author,
+			MatchType: "Entry",
+			Title:     strings.TrimSpace(payload.Title),
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View entry",
+		}
+		if view.Location == "" {
+			view.Location = "your feed"
+		}
+		if view.Author == "" {
+			view.Author = "unknown author"
+		}
+	default:
+		var payload data.RedditData
+		if err := json.Unmarshal(match.DataRaw, &payload); err != nil {
+			return matchView{}, err
+		}
+		view = matchView{
+			Keyword:   payload.Keyword,
+			Location:  "r/" + payload.Subreddit,
+			Author:    "u/" + payload.Author,
+			MatchType: "Post",
+			Title:     strings.TrimSpace(payload.Title),
+			Body:      payload.Body,
+			URL:       payload.Permalink,
+			LinkLabel: "View on Reddit",
+		}
+		if payload.IsComment {
+			view.MatchType = "Comment"
+			view.Context = strings.TrimSpace(payload.ParentTitle)
+		} else {
+			view.Details = redditPostDetails(payload)
+			if !payload.IsSelf && payload.URL != "" && !strings.Contains(payload.URL, payload.Permalink) {
+				view.ExternalURL = payload.URL
+			}
+		}
+		if !strings.HasPrefix(view.URL, "http") {
+			view.URL = "https://reddit.com" + view.URL
+		}
+	}
+
+	body := strings.TrimSpace(view.Body)
+	if len(body) > bodyLimit {
+		body = body[:bodyLimit] + "..."
+	}
+	view.Body = strings.ReplaceAll(body, "\n", "<br>")
+	view.KeywordConfigURL = h.keywordConfigURL(match.KeywordID)
+
+	locations, err := match.ParseAlsoPostedIn()
+	if err != nil {
+		return matchView{}, err
+	}
+	for _, location := range locations {
+		view.AlsoPostedIn = append(view.AlsoPostedIn, buildLocationView(location))
+	}
+
+	return view, nil
+}
+
+func buildLocationView(location data.MatchLocation) locationView {
+	view := locationView{Label: string(location.Source), URL: location.Permalink}
+	switch {
+	case location.Subreddit != "":
+		view.Label = "r/" + location.Subreddit
+		if !strings.HasPrefix(view.URL, "http") {
+			view.URL = "https://reddit.com" + view.URL
+		}
+	case location.Instance != "":
+		view.Label = location.Instance
+	}
+	return view
+}
+
 func (h *Mailer) PasswordResetEmail(email, link string) models.Email {
 	return models.Email{
 		To:      email,
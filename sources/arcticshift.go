@@ -2,60 +2,81 @@ package sources
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	neturl "net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/kova98/feedgrep.api/config"
-	"github.com/kova98/feedgrep.api/data"
 	"github.com/kova98/feedgrep.api/data/repos"
 	"github.com/kova98/feedgrep.api/enums"
-	"github.com/kova98/feedgrep.api/matchers"
 	"github.com/kova98/feedgrep.api/models"
 	"github.com/kova98/feedgrep.api/monitor"
 )
 
 const (
 	arcticShiftBaseURL        = "https://arctic-shift.photon-reddit.com/api"
-	arcticShiftPostsFields    = "id,subreddit,author,title,selftext,created_utc"
+	arcticShiftPostsFields    = "id,subreddit,author,title,selftext,created_utc,score,num_comments,link_flair_text,author_flair_text,over_18,is_self,url,domain"
 	arcticShiftCommentsFields = "id,subreddit,author,body,link_id,parent_id,created_utc"
+
+	// arcticShiftPageSize is the number of items requested per page. A page holding
+	// this many items is saturated and more items may be waiting behind it.
+	arcticShiftPageSize = 100
+	// arcticShiftMaxPagesPerPoll bounds how far a single poll paginates before yielding to the next tick.
+	arcticShiftMaxPagesPerPoll = 10
+	arcticShiftBackfillDelay   = 1 * time.Second
+	arcticShiftBackfillRetries = 5
+	arcticShiftMinBackoff      = 500 * time.Millisecond
+	arcticShiftMaxBackoff      = 2 * time.Minute
+
+	gapRecoveryPagination = "pagination"
+	gapRecoveryBackfill   = "backfill"
 )
 
 type ArcticShiftPoller struct {
-	logger      *slog.Logger
-	keywordRepo *repos.KeywordRepo
-	matchRepo   *repos.MatchRepo
-	am          *monitor.ArcticShiftMonitor
-	km          *monitor.KeywordMonitor
-	client      *http.Client
-
-	subscriptions       []keywordSubscription
+	logger   *slog.Logger
+	pipeline *Pipeline
+	pool     *MatchPool
+	cursors  cursorStore
+	am       *monitor.ArcticShiftMonitor
+	posts    *ArcticShiftSource
+	comments *ArcticShiftSource
+
 	postPollInterval    time.Duration
 	commentPollInterval time.Duration
-	lastPostCreated     int64
-	lastCommentCreated  int64
+	maxCatchUp          time.Duration
+	maxBackfill         time.Duration
+	postHealth          *loopHealth
+	commentHealth       *loopHealth
 }
 
-func NewArcticShiftPoller(logger *slog.Logger, keywordRepo *repos.KeywordRepo, matchRepo *repos.MatchRepo, arcticShiftMonitor *monitor.ArcticShiftMonitor, keywordMonitor *monitor.KeywordMonitor) *ArcticShiftPoller {
-	interval := time.Duration(config.Config.PostPollIntervalMs) * time.Millisecond
+func NewArcticShiftPoller(logger *slog.Logger, pipeline *Pipeline, pool *MatchPool, cursorRepo *repos.SourceCursorRepo, parents *ParentResolver, arcticShiftMonitor *monitor.ArcticShiftMonitor, sourceMonitor *monitor.SourceMonitor) *ArcticShiftPoller {
+	postInterval := time.Duration(config.Config.PostPollIntervalMs) * time.Millisecond
+	commentInterval := time.Duration(config.Config.CommentPollIntervalMs) * time.Millisecond
+	client := NewSourceClient(string(enums.SourceArcticShift), &http.Client{Timeout: 15 * time.Second}, sourceMonitor)
+	posts := NewArcticShiftSource(client, ItemKindPost, arcticShiftMonitor)
+	comments := NewArcticShiftSource(client, ItemKindComment, arcticShiftMonitor)
+	comments.parents = parents
 
 	return &ArcticShiftPoller{
 		logger:              logger,
-		keywordRepo:         keywordRepo,
-		matchRepo:           matchRepo,
+		pipeline:            pipeline,
+		pool:                pool,
+		cursors:             newCursorStore(logger, cursorRepo),
 		am:                  arcticShiftMonitor,
-		km:                  keywordMonitor,
-		client:              &http.Client{Timeout: 15 * time.Second},
-		postPollInterval:    interval,
-		commentPollInterval: interval,
+		posts:               posts,
+		comments:            comments,
+		postPollInterval:    postInterval,
+		commentPollInterval: commentInterval,
+		maxCatchUp:          time.Duration(config.Config.MaxCatchUpMinutes) * time.Minute,
+		maxBackfill:         time.Duration(config.Config.MaxBackfillHours) * time.Hour,
+		postHealth:          newLoopHealth(posts.Name(), postInterval),
+		commentHealth:       newLoopHealth(comments.Name(), commentInterval),
 	}
 }
 
@@ -64,184 +85,376 @@ func (h *ArcticShiftPoller) StartPolling(ctx context.Context) {
 		"post_interval", h.postPollInterval.Seconds(),
 		"comment_interval", h.commentPollInterval.Seconds())
 
-	h.loadKeywords()
+	now := time.Now()
+	oldest := now.Add(-h.maxCatchUp).Unix()
+	postCursor, postGap := h.cursors.load(h.posts.Name(), oldest)
+	commentCursor, commentGap := h.cursors.load(h.comments.Name(), oldest)
+	h.logger.Info("resuming arcticshift polling",
+		"post_cursor", postCursor,
+		"comment_cursor", commentCursor)
+
+	var wg sync.WaitGroup
+	backfillFrom := now.Add(-h.maxBackfill).Unix()
+	if postGap > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Backfill(ctx, h.posts, max(postGap, backfillFrom), oldest)
+		}()
+	}
+	if commentGap > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Backfill(ctx, h.comments, max(commentGap, backfillFrom), oldest)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.runLoop(ctx, h.comments, h.commentPollInterval, h.commentHealth, ArcticShiftCursor{Created: commentCursor})
+	}()
+	h.runLoop(ctx, h.posts, h.postPollInterval, h.postHealth, ArcticShiftCursor{Created: postCursor})
+	wg.Wait()
+	h.logger.Info("stopping arcticshift polling")
+}
 
-	ticker := time.NewTicker(h.postPollInterval)
-	keywordTicker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-	defer keywordTicker.Stop()
+// Diagnostics reports the state of the post and comment loops.
+func (h *ArcticShiftPoller) Diagnostics() []models.PollLoopStatus {
+	return []models.PollLoopStatus{h.postHealth.Status(), h.commentHealth.Status()}
+}
 
+// runLoop polls src every interval until ctx is cancelled. Posts and comments run in separate
+// loops so a slow or failing endpoint does not delay the other. After a failure the loop
+// retries with a jittered exponential backoff instead of waiting for the next interval.
+func (h *ArcticShiftPoller) runLoop(ctx context.Context, src *ArcticShiftSource, interval time.Duration, health *loopHealth, cursor ArcticShiftCursor) {
+	delay := interval
 	for {
+		health.scheduled(time.Now().Add(delay))
 		select {
 		case <-ctx.Done():
-			h.logger.Info("stopping arcticshift polling")
 			return
-		case <-ticker.C:
-			if !h.pollPosts() {
-				time.Sleep(100 * time.Millisecond)
-				h.pollPosts()
-			}
-			if !h.pollComments() {
-				time.Sleep(100 * time.Millisecond)
-				h.pollComments()
+		case <-time.After(delay):
+		}
+
+		pollStart := time.Now()
+		if err := h.poll(ctx, src, &cursor); err != nil {
+			failures := health.failure(err)
+			delay = jitteredBackoff(failures, arcticShiftMinBackoff, arcticShiftMaxBackoff)
+			if failures == loopFailingAfter {
+				h.logger.Warn("arcticshift "+src.kind+" polling is failing", "consecutive_failures", failures, "error", truncateError(err))
 			}
-		case <-keywordTicker.C:
-			h.loadKeywords()
+			continue
 		}
+		health.success(cursor.Created)
+		delay = max(interval-time.Since(pollStart), 0)
 	}
 }
 
-func (h *ArcticShiftPoller) pollPosts() bool {
-	matches := make([]data.Match, 0, 32)
+// poll fetches everything created after cursor, paginating while pages come back saturated.
+// Pages are handed to the match pool, so the next page is fetched while the previous one is
+// being matched. The stored cursor only advances once a page's matches have been persisted.
+// A failure after the first page still counts as a successful poll, as progress was made.
+func (h *ArcticShiftPoller) poll(ctx context.Context, src *ArcticShiftSource, cursor *ArcticShiftCursor) error {
+	for page := 0; page < arcticShiftMaxPagesPerPoll; page++ {
+		result, err := src.FetchPage(ctx, *cursor, 0)
+		processingStart := time.Now()
+		if err != nil {
+			h.logger.Info("poll "+src.kind+"s", "page", page, "error", truncateError(err))
+			if page > 0 {
+				return nil
+			}
+			return err
+		}
+		*cursor = result.Cursor
+		if len(result.Items) == 0 {
+			if result.Saturated {
+				continue
+			}
+			return nil
+		}
+
+		h.submit(ctx, src, result, processingStart)
+		if page > 0 {
+			h.recordGapRecovered(src.kind, gapRecoveryPagination, int64(len(result.Items)))
+		}
 
-	url := fmt.Sprintf("%s/posts/search?limit=auto&sort=desc&fields=%s", arcticShiftBaseURL, arcticShiftPostsFields)
-	if h.lastPostCreated > 0 {
-		after := time.Unix(h.lastPostCreated, 0).UTC().Format(time.RFC3339)
-		url = fmt.Sprintf("%s/posts/search?limit=auto&sort=asc&after=%s&fields=%s", arcticShiftBaseURL, neturl.QueryEscape(after), arcticShiftPostsFields)
+		// The first poll without a cursor reads the latest page only.
+		if !result.Saturated || (page == 0 && result.Descending) {
+			return nil
+		}
 	}
 
-	var resp models.ArcticShiftSearchResponse[models.ArcticShiftPost]
-	requestMs, err := h.fetchArcticShift(url, &resp)
-	processingStart := time.Now()
+	h.logger.Info("poll "+src.kind+"s: page limit reached, continuing on next tick", "cursor", cursor.Created)
+	return nil
+}
+
+func (h *ArcticShiftPoller) submit(ctx context.Context, src *ArcticShiftSource, result ArcticShiftPage, processingStart time.Time) {
+	err := h.pool.Submit(ctx, Batch{
+		Source:  src.Name(),
+		Items:   result.Items,
+		Observe: h.observeEvaluation,
+		Commit: func() {
+			h.cursors.save(src.Name(), result.Cursor.Created)
+			h.recordBatch(src.kind, int64(len(result.Items)), processingStart, newestCreatedUTC(result.Items))
+		},
+	})
 	if err != nil {
-		h.am.PostRequestError(time.Duration(requestMs) * time.Millisecond)
-		h.logger.Info("poll posts", "request_ms", requestMs, "error", truncateError(err))
-		return false
+		h.logger.Info("submit "+src.kind+"s", "error", err)
 	}
+}
 
-	if len(resp.Data) == 0 {
-		h.am.PostRequest(time.Duration(requestMs) * time.Millisecond)
-		return true
+// Backfill re-walks the (from, to) range of src and runs every item through the pipeline.
+// Matches that were already stored are deduplicated by their hash.
+func (h *ArcticShiftPoller) Backfill(ctx context.Context, src *ArcticShiftSource, from, to int64) {
+	if from >= to {
+		return
 	}
 
-	var newestPostUTC int64
-	processedPosts := int64(0)
-	for _, post := range resp.Data {
-		if post.ID == "" {
-			continue
+	h.logger.Info("starting arcticshift backfill", "source", src.Name(), "from", from, "to", to)
+	recovered := int64(0)
+	failures := 0
+	cursor := ArcticShiftCursor{Created: from}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(arcticShiftBackfillDelay):
 		}
-		processedPosts++
-		if post.CreatedUTC > newestPostUTC {
-			newestPostUTC = post.CreatedUTC
+
+		if failures >= arcticShiftBackfillRetries {
+			h.logger.Error("aborting arcticshift backfill", "source", src.Name(), "cursor", cursor.Created, "recovered", recovered)
+			return
 		}
 
-		for _, sub := range h.subscriptions {
-			matchStart := time.Now()
-			subMatches, smartResult, err := sub.Matches(post.Title, post.Selftext, post.Subreddit)
-			h.am.PostMatchEvaluation(string(sub.matchMode), matchStart)
-			if err != nil {
-				h.logger.Error("failed to check match", "error", err, "post_id", post.ID)
-				continue
-			}
-			h.logSmartMatchResult("post", post.ID, sub, smartResult)
-			if !subMatches {
+		result, err := src.FetchPage(ctx, cursor, to)
+		if err != nil {
+			failures++
+			h.logger.Info("backfill "+src.kind+"s", "cursor", cursor.Created, "error", truncateError(err))
+			continue
+		}
+		if len(result.Items) > 0 {
+			if err := h.pipeline.Process(result.Items, h.observeEvaluation); err != nil {
+				failures++
+				h.logger.Error("failed to store backfilled matches", "error", err)
 				continue
 			}
+			recovered += int64(len(result.Items))
+			h.recordGapRecovered(src.kind, gapRecoveryBackfill, int64(len(result.Items)))
+		}
+		failures = 0
 
-			match, err := h.makePostMatch(post, sub)
-			if err != nil {
-				h.logger.Error("failed to make match", "error", err, "post_id", post.ID)
-				continue
-			}
-			matches = append(matches, match)
+		if !result.Saturated || result.Cursor.Created >= to {
+			break
 		}
+		cursor = result.Cursor
 	}
 
-	if len(matches) > 0 {
-		if err := h.matchRepo.CreateMatches(matches); err != nil {
-			h.logger.Error("failed to store matches", "error", err)
-		}
+	h.logger.Info("finished arcticshift backfill", "source", src.Name(), "recovered", recovered)
+}
+
+func (h *ArcticShiftPoller) recordBatch(kind string, count int64, processingStart time.Time, newestCreatedUTC int64) {
+	if kind == ItemKindComment {
+		h.am.CommentBatch(count, processingStart, newestCreatedUTC)
+		return
 	}
-	if newestPostUTC > h.lastPostCreated {
-		h.lastPostCreated = newestPostUTC
+	h.am.PostBatch(count, processingStart, newestCreatedUTC)
+}
+
+func (h *ArcticShiftPoller) recordGapRecovered(kind, via string, count int64) {
+	if kind == ItemKindComment {
+		h.am.CommentGapRecovered(via, count)
+		return
 	}
-	h.am.PostBatch(processedPosts, time.Duration(requestMs)*time.Millisecond, processingStart, newestPostUTC)
-	return true
+	h.am.PostGapRecovered(via, count)
 }
 
-func (h *ArcticShiftPoller) pollComments() bool {
-	matches := make([]data.Match, 0, 32)
+func (h *ArcticShiftPoller) observeEvaluation(item Item, matchMode enums.MatchMode, start time.Time) {
+	if item.IsComment() {
+		h.am.CommentMatchEvaluation(string(matchMode), start)
+		return
+	}
+	h.am.PostMatchEvaluation(string(matchMode), start)
+}
 
-	url := fmt.Sprintf("%s/comments/search?limit=auto&sort=desc&fields=%s", arcticShiftBaseURL, arcticShiftCommentsFields)
-	if h.lastCommentCreated > 0 {
-		after := time.Unix(h.lastCommentCreated, 0).UTC().Format(time.RFC3339)
-		url = fmt.Sprintf("%s/comments/search?limit=auto&sort=asc&after=%s&fields=%s", arcticShiftBaseURL, neturl.QueryEscape(after), arcticShiftCommentsFields)
+// ArcticShiftSource fetches either posts or comments from the Arctic Shift Reddit mirror.
+type ArcticShiftSource struct {
+	client *SourceClient
+	kind   string
+	am     *monitor.ArcticShiftMonitor
+	// parents resolves the parent posts of comments. It is nil for the posts source.
+	parents *ParentResolver
+}
+
+// ArcticShiftCursor is a position in the Arctic Shift results. Timestamps only have a resolution of
+// one second, so pages are requested from Created inclusive and Seen holds the ids already read at
+// that second. Without Seen, the items of the Created second are read again and rely on match
+// deduplication.
+type ArcticShiftCursor struct {
+	Created int64
+	Seen    []string
+}
+
+// advance moves the cursor past an item created at or after it.
+func (c *ArcticShiftCursor) advance(id string, created int64) {
+	if created > c.Created {
+		c.Created = created
+		c.Seen = nil
 	}
+	if created == c.Created {
+		c.Seen = append(c.Seen, id)
+	}
+}
 
-	var resp models.ArcticShiftSearchResponse[models.ArcticShiftComment]
-	requestMs, err := h.fetchArcticShift(url, &resp)
-	processingStart := time.Now()
+func (c ArcticShiftCursor) seen(id string, created int64) bool {
+	return created < c.Created || (created == c.Created && slices.Contains(c.Seen, id))
+}
+
+// ArcticShiftPage is a single page of Arctic Shift search results.
+type ArcticShiftPage struct {
+	Items []Item
+	// Cursor is the position after the newest item seen so far.
+	Cursor ArcticShiftCursor
+	// Saturated is set when the page was full and more items may follow it.
+	Saturated bool
+	// Descending is set when the page holds the latest items rather than items after a cursor.
+	Descending bool
+}
+
+func NewArcticShiftSource(client *SourceClient, kind string, arcticShiftMonitor *monitor.ArcticShiftMonitor) *ArcticShiftSource {
+	return &ArcticShiftSource{
+		client: client,
+		kind:   kind,
+		am:     arcticShiftMonitor,
+	}
+}
+
+func (s *ArcticShiftSource) Name() string {
+	return string(enums.SourceArcticShift) + "_" + s.kind + "s"
+}
+
+func (s *ArcticShiftSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	page, err := s.FetchPage(ctx, ArcticShiftCursor{Created: cursor}, 0)
 	if err != nil {
-		h.am.CommentRequestError(time.Duration(requestMs) * time.Millisecond)
-		h.logger.Debug("poll comments", "request_ms", requestMs, "error", truncateError(err))
-		return false
+		return nil, cursor, err
 	}
+	return page.Items, page.Cursor.Created, nil
+}
 
-	if len(resp.Data) == 0 {
-		h.am.CommentRequest(time.Duration(requestMs) * time.Millisecond)
-		return true
+// FetchPage returns one page of the items after the cursor and, when before is set, created before it.
+// Without a cursor the latest page is returned.
+//
+// A saturated page that does not get past the cursor's second cannot be paginated any further, as
+// the search only filters by timestamp. The rest of that second is then skipped so polling does not stall.
+func (s *ArcticShiftSource) FetchPage(ctx context.Context, after ArcticShiftCursor, before int64) (ArcticShiftPage, error) {
+	if s.kind == ItemKindComment {
+		return s.fetchComments(ctx, after, before)
 	}
+	return s.fetchPosts(ctx, after, before)
+}
 
-	maxCreatedUTC := h.lastCommentCreated
-	newestCommentUTC := int64(0)
-	processedComments := int64(0)
-	for _, comment := range resp.Data {
-		if comment.ID == "" {
+func (s *ArcticShiftSource) fetchPosts(ctx context.Context, after ArcticShiftCursor, before int64) (ArcticShiftPage, error) {
+	page := newArcticShiftPage(after)
+
+	var resp models.ArcticShiftSearchResponse[models.ArcticShiftPost]
+	requestMs, err := s.fetchArcticShift(ctx, s.searchURL("posts", arcticShiftPostsFields, after, before), &resp)
+	if err != nil {
+		s.am.PostRequestError(time.Duration(requestMs) * time.Millisecond)
+		return page, fmt.Errorf("(%dms) %w", requestMs, err)
+	}
+	s.am.PostRequest(time.Duration(requestMs) * time.Millisecond)
+
+	page.Items = make([]Item, 0, len(resp.Data))
+	for _, post := range resp.Data {
+		if post.ID == "" || after.seen(post.ID, post.CreatedUTC) {
 			continue
 		}
-		processedComments++
-		if comment.CreatedUTC > newestCommentUTC {
-			newestCommentUTC = comment.CreatedUTC
-		}
-		if comment.CreatedUTC > maxCreatedUTC {
-			maxCreatedUTC = comment.CreatedUTC
-		}
+		page.add(arcticShiftPostItem(post))
+	}
+	page.finish(after, len(resp.Data))
 
-		for _, sub := range h.subscriptions {
-			matchStart := time.Now()
-			subMatches, smartResult, err := sub.Matches("", comment.Body, comment.Subreddit)
-			h.am.CommentMatchEvaluation(string(sub.matchMode), matchStart)
-			if err != nil {
-				h.logger.Error("failed to check match", "error", err, "comment_id", comment.ID)
-				continue
-			}
-			h.logSmartMatchResult("comment", comment.ID, sub, smartResult)
-			if !subMatches {
-				continue
-			}
+	return page, nil
+}
 
-			match, err := h.makeCommentMatch(comment, sub)
-			if err != nil {
-				h.logger.Error("failed to make match", "error", err, "comment_id", comment.ID)
-				continue
-			}
-			matches = append(matches, match)
-		}
+func (s *ArcticShiftSource) fetchComments(ctx context.Context, after ArcticShiftCursor, before int64) (ArcticShiftPage, error) {
+	page := newArcticShiftPage(after)
+
+	var resp models.ArcticShiftSearchResponse[models.ArcticShiftComment]
+	requestMs, err := s.fetchArcticShift(ctx, s.searchURL("comments", arcticShiftCommentsFields, after, before), &resp)
+	if err != nil {
+		s.am.CommentRequestError(time.Duration(requestMs) * time.Millisecond)
+		return page, fmt.Errorf("(%dms) %w", requestMs, err)
 	}
+	s.am.CommentRequest(time.Duration(requestMs) * time.Millisecond)
 
-	if len(matches) > 0 {
-		if err := h.matchRepo.CreateMatches(matches); err != nil {
-			h.logger.Error("failed to store matches", "error", err)
+	page.Items = make([]Item, 0, len(resp.Data))
+	for _, comment := range resp.Data {
+		if comment.ID == "" || after.seen(comment.ID, comment.CreatedUTC) {
+			continue
 		}
+		page.add(arcticShiftCommentItem(comment))
+	}
+	page.finish(after, len(resp.Data))
+	if s.parents != nil {
+		s.parents.Resolve(ctx, page.Items)
+	}
+
+	return page, nil
+}
+
+func newArcticShiftPage(after ArcticShiftCursor) ArcticShiftPage {
+	return ArcticShiftPage{
+		Cursor:     ArcticShiftCursor{Created: after.Created, Seen: slices.Clone(after.Seen)},
+		Descending: after.Created <= 0,
+	}
+}
+
+func (p *ArcticShiftPage) add(item Item) {
+	p.Items = append(p.Items, item)
+	p.Cursor.advance(item.ID, item.CreatedUTC)
+}
+
+// finish marks the page as saturated when the search returned a full page, and skips the rest of
+// the cursor's second when a full page did not get past it.
+func (p *ArcticShiftPage) finish(after ArcticShiftCursor, returned int) {
+	p.Saturated = returned >= arcticShiftPageSize
+	if p.Saturated && !p.Descending && p.Cursor.Created == after.Created {
+		p.Cursor = ArcticShiftCursor{Created: after.Created + 1}
+	}
+}
+
+// searchURL builds the search for the items created at or after the cursor's second. The search
+// only supports an exclusive bound, so it starts one second earlier.
+func (s *ArcticShiftSource) searchURL(endpoint, fields string, after ArcticShiftCursor, before int64) string {
+	query := neturl.Values{}
+	query.Set("limit", fmt.Sprint(arcticShiftPageSize))
+	query.Set("fields", fields)
+	if after.Created <= 0 {
+		query.Set("sort", "desc")
+		return fmt.Sprintf("%s/%s/search?%s", arcticShiftBaseURL, endpoint, query.Encode())
 	}
-	h.lastCommentCreated = maxCreatedUTC
 
-	h.am.CommentBatch(processedComments, time.Duration(requestMs)*time.Millisecond, processingStart, newestCommentUTC)
-	return true
+	query.Set("sort", "asc")
+	query.Set("after", time.Unix(after.Created-1, 0).UTC().Format(time.RFC3339))
+	if before > 0 {
+		query.Set("before", time.Unix(before, 0).UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s/%s/search?%s", arcticShiftBaseURL, endpoint, query.Encode())
 }
 
-func (h *ArcticShiftPoller) fetchArcticShift(url string, dest any) (int64, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (s *ArcticShiftSource) fetchArcticShift(ctx context.Context, url string, dest any) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return 0, err
 	}
 
 	req.Header.Set("User-Agent", "feedgrep")
 	start := time.Now()
-	resp, err := h.client.Do(req)
+	resp, err := s.client.Do(req)
 	requestMs := time.Since(start).Milliseconds()
 	if err != nil {
-		return requestMs, fmt.Errorf("(%dms) %w", requestMs, err)
+		return requestMs, err
 	}
 	defer resp.Body.Close()
 
@@ -256,46 +469,43 @@ func (h *ArcticShiftPoller) fetchArcticShift(url string, dest any) (int64, error
 	return requestMs, nil
 }
 
-func (h *ArcticShiftPoller) makePostMatch(post models.ArcticShiftPost, sub keywordSubscription) (data.Match, error) {
-	permalink := buildArcticShiftPostPermalink(post.Subreddit, post.ID)
-	redditData := data.RedditData{
-		Keyword:   sub.keyword,
-		Subreddit: post.Subreddit,
-		Author:    post.Author,
-		Title:     post.Title,
-		Body:      post.Selftext,
-		IsComment: false,
-		Permalink: permalink,
-	}
-	matchHash := buildMatchHash(sub.userID, sub.id, enums.SourceArcticShift, permalink)
-	return data.NewMatch(
-		sub.userID,
-		sub.id,
-		enums.SourceArcticShift,
-		matchHash,
-		redditData,
-	)
-}
-
-func (h *ArcticShiftPoller) makeCommentMatch(comment models.ArcticShiftComment, sub keywordSubscription) (data.Match, error) {
-	permalink := buildArcticShiftCommentPermalink(comment.Subreddit, comment.LinkID, comment.ID)
-	redditData := data.RedditData{
-		Keyword:   sub.keyword,
-		Subreddit: comment.Subreddit,
-		Author:    comment.Author,
-		Title:     "",
-		Body:      comment.Body,
-		IsComment: true,
-		Permalink: permalink,
-	}
-	matchHash := buildMatchHash(sub.userID, sub.id, enums.SourceArcticShift, permalink)
-	return data.NewMatch(
-		sub.userID,
-		sub.id,
-		enums.SourceArcticShift,
-		matchHash,
-		redditData,
-	)
+func arcticShiftPostItem(post models.ArcticShiftPost) Item {
+	return Item{
+		Source:     enums.SourceArcticShift,
+		Kind:       ItemKindPost,
+		ID:         post.ID,
+		Title:      post.Title,
+		Body:       post.Selftext,
+		Subreddit:  post.Subreddit,
+		Author:     post.Author,
+		Permalink:  buildArcticShiftPostPermalink(post.Subreddit, post.ID),
+		CreatedUTC: post.CreatedUTC,
+		RedditPost: &RedditPostMeta{
+			Score:       post.Score,
+			NumComments: post.NumComments,
+			Flair:       post.LinkFlairText,
+			AuthorFlair: post.AuthorFlairText,
+			NSFW:        post.Over18,
+			IsSelf:      post.IsSelf,
+			URL:         post.URL,
+			Domain:      post.Domain,
+		},
+	}
+}
+
+func arcticShiftCommentItem(comment models.ArcticShiftComment) Item {
+	return Item{
+		Source:     enums.SourceArcticShift,
+		Kind:       ItemKindComment,
+		ID:         comment.ID,
+		Title:      "",
+		Body:       comment.Body,
+		Subreddit:  comment.Subreddit,
+		Author:     comment.Author,
+		Permalink:  buildArcticShiftCommentPermalink(comment.Subreddit, comment.LinkID, comment.ID),
+		CreatedUTC: comment.CreatedUTC,
+		ParentID:   redditPostID(comment.LinkID),
+	}
 }
 
 func buildArcticShiftPostPermalink(subreddit, postID string) string {
@@ -319,40 +529,6 @@ func buildArcticShiftCommentPermalink(subreddit, linkID, commentID string) strin
 	return fmt.Sprintf("/r/%s/comments/%s/_/%s", subreddit, postID, commentID)
 }
 
-func (h *ArcticShiftPoller) loadKeywords() {
-	keywords, err := h.keywordRepo.GetActiveKeywordsWithEmails()
-	if err != nil {
-		h.logger.Error("failed to refresh subscriptions", "error", err)
-		return
-	}
-
-	active := make([]keywordSubscription, 0, len(keywords))
-	for _, keyword := range keywords {
-		kw := strings.TrimSpace(strings.ToLower(keyword.Keyword))
-		email := strings.TrimSpace(keyword.Email)
-		if kw == "" || email == "" {
-			continue
-		}
-
-		active = append(active, keywordSubscription{
-			id:        keyword.ID,
-			userID:    keyword.UserID,
-			keyword:   kw,
-			matchMode: keyword.MatchMode,
-			filters:   keyword.Filters,
-		})
-	}
-
-	h.subscriptions = active
-	h.km.Active(len(h.subscriptions))
-}
-
-func buildMatchHash(userID uuid.UUID, keywordID int, source enums.Source, url string) string {
-	input := fmt.Sprintf("%s:%d:%s:%s", userID.String(), keywordID, source, url)
-	sum := sha256.Sum256([]byte(input))
-	return hex.EncodeToString(sum[:])
-}
-
 func truncateError(err error) error {
 	msg := err.Error()
 	if len(msg) > 300 {
@@ -360,90 +536,3 @@ func truncateError(err error) error {
 	}
 	return err
 }
-
-type keywordSubscription struct {
-	id        int
-	userID    uuid.UUID
-	keyword   string
-	matchMode enums.MatchMode
-	filters   data.KeywordFilters
-}
-
-func (h *ArcticShiftPoller) logSmartMatchResult(kind, itemID string, sub keywordSubscription, result *matchers.SmartMatchResult) {
-	if result == nil {
-		return
-	}
-	if !result.CandidateMatched {
-		return
-	}
-
-	h.logger.Debug(
-		"smart match evaluation",
-		"keyword_id", sub.id,
-		"matched", result.Matched,
-		"score", result.Score,
-		"matched_signals", result.MatchedSignals,
-		"signal_details", result.SignalDetails,
-		"rejected_by", result.RejectedBy,
-	)
-}
-
-func (s *keywordSubscription) Matches(title, body, subreddit string) (bool, *matchers.SmartMatchResult, error) {
-	text := strings.TrimSpace(strings.TrimSpace(title) + "\n" + strings.TrimSpace(body))
-	textLower := strings.ToLower(text)
-
-	if s.matchMode == enums.MatchModeInvalid {
-		return false, nil, errors.New(string("invalid match mode: " + s.matchMode))
-	}
-
-	switch s.matchMode {
-	case enums.MatchModeExact:
-		if !matchers.MatchesWholeWord(textLower, s.keyword) {
-			return false, nil, nil
-		}
-	case enums.MatchModeBroad:
-		if !matchers.MatchesPartially(textLower, s.keyword) {
-			return false, nil, nil
-		}
-	case enums.MatchModeSmart:
-		if s.filters.Smart == nil {
-			return false, nil, errors.New("smart match mode requires a smart filter")
-		}
-		result, err := matchers.EvaluateSmart(*s.filters.Smart, matchers.SmartInput{
-			Title:     title,
-			Body:      body,
-			Subreddit: subreddit,
-		})
-		if err != nil {
-			return false, nil, err
-		}
-		if !result.Matched {
-			return false, &result, nil
-		}
-		return true, &result, nil
-	default:
-		return false, nil, errors.New(string("invalid match mode: " + s.matchMode))
-	}
-
-	if s.filters.Reddit != nil {
-		match, err := matchers.MatchesSubreddit(*s.filters.Reddit, subreddit)
-		if err != nil {
-			return false, nil, err
-		}
-		if !match {
-			return false, nil, nil
-		}
-	}
-
-	if s.filters.Language != nil {
-		match, err := matchers.MatchesLanguage(*s.filters.Language, text)
-		if err != nil {
-			return false, nil, err
-		}
-		if !match {
-			return false, nil, nil
-		}
-	}
-
-	return true, nil, nil
-}
@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArcticShiftPage(t *testing.T) {
+	fill := func(after ArcticShiftCursor, items []Item, returned int) ArcticShiftPage {
+		page := newArcticShiftPage(after)
+		for _, item := range items {
+			if !after.seen(item.ID, item.CreatedUTC) {
+				page.add(item)
+			}
+		}
+		page.finish(after, returned)
+		return page
+	}
+
+	t.Run("it skips the items already read at the cursor's second", func(t *testing.T) {
+		after := ArcticShiftCursor{Created: 100, Seen: []string{"a", "b"}}
+		items := []Item{
+			{ID: "a", CreatedUTC: 100},
+			{ID: "b", CreatedUTC: 100},
+			{ID: "c", CreatedUTC: 100},
+			{ID: "d", CreatedUTC: 101},
+		}
+
+		page := fill(after, items, len(items))
+
+		assert.Equal(t, []string{"c", "d"}, []string{page.Items[0].ID, page.Items[1].ID})
+		assert.Equal(t, ArcticShiftCursor{Created: 101, Seen: []string{"d"}}, page.Cursor)
+		assert.Equal(t, []string{"a", "b"}, after.Seen)
+	})
+
+	t.Run("it continues from the newest second of a full page", func(t *testing.T) {
+		after := ArcticShiftCursor{Created: 100}
+		items := make([]Item, 0, arcticShiftPageSize)
+		for i := range arcticShiftPageSize {
+			items = append(items, Item{ID: testItemID(i), CreatedUTC: 100 + int64(i*2/arcticShiftPageSize)})
+		}
+
+		page := fill(after, items, len(items))
+
+		assert.True(t, page.Saturated)
+		assert.Len(t, page.Items, arcticShiftPageSize)
+		assert.Equal(t, int64(101), page.Cursor.Created)
+		assert.Len(t, page.Cursor.Seen, arcticShiftPageSize/2)
+	})
+
+	t.Run("it skips the rest of a second that does not fit in a page", func(t *testing.T) {
+		after := ArcticShiftCursor{Created: 100, Seen: []string{"a"}}
+		items := make([]Item, 0, arcticShiftPageSize)
+		for i := range arcticShiftPageSize {
+			items = append(items, Item{ID: testItemID(i), CreatedUTC: 100})
+		}
+
+		page := fill(after, items, len(items))
+
+		assert.True(t, page.Saturated)
+		assert.Equal(t, ArcticShiftCursor{Created: 101}, page.Cursor)
+	})
+
+	t.Run("it starts from the latest page without a cursor", func(t *testing.T) {
+		items := []Item{{ID: "b", CreatedUTC: 200}, {ID: "a", CreatedUTC: 199}, {ID: "c", CreatedUTC: 200}}
+
+		page := fill(ArcticShiftCursor{}, items, arcticShiftPageSize)
+
+		assert.True(t, page.Descending)
+		assert.Equal(t, ArcticShiftCursor{Created: 200, Seen: []string{"b", "c"}}, page.Cursor)
+	})
+}
+
+func testItemID(i int) string {
+	return string(rune('a'+i%26)) + string(rune('a'+i/26))
+}
@@ -0,0 +1,138 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	neturl "net/url"
+	"strconv"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	jetstreamPostCollection = "app.bsky.feed.post"
+	jetstreamKindCommit     = "commit"
+	jetstreamOpCreate       = "create"
+	// jetstreamReadTimeout drops connections that stop delivering events, which the firehose never does when healthy.
+	jetstreamReadTimeout = 30 * time.Second
+	blueskyPostURL       = "https://bsky.app/profile/%s/post/%s"
+)
+
+// JetstreamSource consumes new Bluesky posts from a Jetstream firehose over a websocket.
+// Its cursor is the Jetstream time_us of the latest event.
+type JetstreamSource struct {
+	url string
+}
+
+func NewJetstreamSource(url string) *JetstreamSource {
+	return &JetstreamSource{url: url}
+}
+
+func (s *JetstreamSource) Name() string {
+	return string(enums.SourceBluesky)
+}
+
+func (s *JetstreamSource) Stream(ctx context.Context, cursor int64, events chan<- StreamEvent) error {
+	location, err := neturl.Parse(s.url)
+	if err != nil {
+		return fmt.Errorf("parse jetstream url: %w", err)
+	}
+	query := location.Query()
+	query.Set("wantedCollections", jetstreamPostCollection)
+	if cursor > 0 {
+		query.Set("cursor", strconv.FormatInt(cursor, 10))
+	}
+	location.RawQuery = query.Encode()
+
+	wsConfig, err := websocket.NewConfig(location.String(), "http://localhost")
+	if err != nil {
+		return fmt.Errorf("configure jetstream: %w", err)
+	}
+	wsConfig.Header.Set("User-Agent", "feedgrep")
+
+	conn, err := wsConfig.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("dial jetstream: %w", err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(jetstreamReadTimeout)); err != nil {
+			return err
+		}
+
+		var msg []byte
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("receive jetstream event: %w", err)
+		}
+
+		var event models.JetstreamEvent
+		if err := json.Unmarshal(msg, &event); err != nil || event.TimeUS <= cursor {
+			continue
+		}
+
+		streamEvent := StreamEvent{Cursor: event.TimeUS}
+		if item, ok := jetstreamPostItem(event); ok {
+			streamEvent.Item = &item
+		}
+
+		select {
+		case events <- streamEvent:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func jetstreamPostItem(event models.JetstreamEvent) (Item, bool) {
+	commit := event.Commit
+	if event.Kind != jetstreamKindCommit || commit == nil || commit.Record == nil {
+		return Item{}, false
+	}
+	if commit.Operation != jetstreamOpCreate || commit.Collection != jetstreamPostCollection {
+		return Item{}, false
+	}
+	if event.DID == "" || commit.RKey == "" {
+		return Item{}, false
+	}
+
+	kind := ItemKindPost
+	if commit.Record.Reply != nil {
+		kind = ItemKindComment
+	}
+	permalink := fmt.Sprintf(blueskyPostURL, event.DID, commit.RKey)
+	blueskyData := data.BlueskyData{
+		DID:       event.DID,
+		RKey:      commit.RKey,
+		Body:      commit.Record.Text,
+		Permalink: permalink,
+		IsReply:   kind == ItemKindComment,
+	}
+
+	return Item{
+		Source:     enums.SourceBluesky,
+		Kind:       kind,
+		ID:         "at://" + event.DID + "/" + jetstreamPostCollection + "/" + commit.RKey,
+		Body:       commit.Record.Text,
+		Author:     event.DID,
+		Permalink:  permalink,
+		CreatedUTC: event.TimeUS / int64(time.Second/time.Microsecond),
+		MatchData: func(keyword string) any {
+			payload := blueskyData
+			payload.Keyword = keyword
+			return payload
+		},
+	}, true
+}
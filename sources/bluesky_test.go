@@ -0,0 +1,237 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// jetstreamReplay is a local Jetstream stand-in that replays recorded events, honoring the cursor
+// and dropping the connection after the configured number of events.
+type jetstreamReplay struct {
+	events      []recordedJetstreamEvent
+	dropAfter   int
+	mu          sync.Mutex
+	connections []string
+}
+
+type recordedJetstreamEvent struct {
+	timeUS int64
+	raw    string
+}
+
+func newJetstreamReplay(t *testing.T, dropAfter int) (*jetstreamReplay, *httptest.Server) {
+	t.Helper()
+
+	file, err := os.Open("testdata/jetstream_events.jsonl")
+	require.NoError(t, err)
+	defer file.Close()
+
+	replay := &jetstreamReplay{dropAfter: dropAfter}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event struct {
+			TimeUS int64 `json:"time_us"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		replay.events = append(replay.events, recordedJetstreamEvent{timeUS: event.TimeUS, raw: line})
+	}
+	require.NoError(t, scanner.Err())
+
+	server := httptest.NewServer(websocket.Handler(replay.serve))
+	t.Cleanup(server.Close)
+	return replay, server
+}
+
+func (r *jetstreamReplay) serve(conn *websocket.Conn) {
+	query := conn.Request().URL.Query()
+	cursor, _ := strconv.ParseInt(query.Get("cursor"), 10, 64)
+
+	r.mu.Lock()
+	r.connections = append(r.connections, query.Get("cursor"))
+	r.mu.Unlock()
+
+	sent := 0
+	for _, event := range r.events {
+		if cursor > 0 && event.timeUS < cursor {
+			continue
+		}
+		if r.dropAfter > 0 && sent >= r.dropAfter {
+			return
+		}
+		if err := websocket.Message.Send(conn, event.raw); err != nil {
+			return
+		}
+		sent++
+	}
+
+	// Keep the connection open like the live firehose until the client goes away.
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+func (r *jetstreamReplay) cursors() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.connections...)
+}
+
+func wsURL(server *httptest.Server) string {
+	return "ws" + server.URL[len("http"):] + "/subscribe"
+}
+
+type memoryCursorRepo struct {
+	mu      sync.Mutex
+	cursors map[string]int64
+}
+
+func (r *memoryCursorRepo) GetCursor(source string) (*data.SourceCursor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cursor, ok := r.cursors[source]
+	if !ok {
+		return nil, nil
+	}
+	return &data.SourceCursor{Source: source, Cursor: cursor}, nil
+}
+
+func (r *memoryCursorRepo) SaveCursor(source string, cursor int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cursors[source] = cursor
+	return nil
+}
+
+func (r *memoryCursorRepo) get(source string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cursors[source]
+}
+
+func TestJetstreamSource(t *testing.T) {
+	t.Run("it emits created posts and advances the cursor on every event", func(t *testing.T) {
+		replay, server := newJetstreamReplay(t, 0)
+		src := NewJetstreamSource(wsURL(server))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan StreamEvent, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- src.Stream(ctx, 0, events)
+		}()
+
+		received := make([]StreamEvent, 0, 5)
+		for len(received) < 5 {
+			select {
+			case event := <-events:
+				received = append(received, event)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for events")
+			}
+		}
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+
+		items := make([]Item, 0, 3)
+		for _, event := range received {
+			if event.Item != nil {
+				items = append(items, *event.Item)
+			}
+		}
+		require.Len(t, items, 3)
+		assert.Equal(t, int64(1749556800000005), received[4].Cursor)
+
+		assert.Equal(t, enums.SourceBluesky, items[0].Source)
+		assert.Equal(t, ItemKindPost, items[0].Kind)
+		assert.Equal(t, "Looking for an open source alternative to Notion", items[0].Body)
+		assert.Equal(t, "did:plc:alice", items[0].Author)
+		assert.Equal(t, "https://bsky.app/profile/did:plc:alice/post/3lpost1", items[0].Permalink)
+		assert.Equal(t, int64(1749556800), items[0].CreatedUTC)
+		assert.Equal(t, ItemKindComment, items[1].Kind)
+
+		payload, ok := items[1].MatchData("outline").(data.BlueskyData)
+		require.True(t, ok)
+		assert.Equal(t, "outline", payload.Keyword)
+		assert.True(t, payload.IsReply)
+
+		assert.Equal(t, []string{""}, replay.cursors())
+	})
+
+	t.Run("it resumes from the cursor and skips events at or before it", func(t *testing.T) {
+		replay, server := newJetstreamReplay(t, 0)
+		src := NewJetstreamSource(wsURL(server))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events := make(chan StreamEvent, 10)
+		go func() {
+			_ = src.Stream(ctx, 1749556800000003, events)
+		}()
+
+		var received []StreamEvent
+		for len(received) < 2 {
+			select {
+			case event := <-events:
+				received = append(received, event)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for events")
+			}
+		}
+
+		assert.Equal(t, int64(1749556800000004), received[0].Cursor)
+		assert.Nil(t, received[0].Item)
+		require.NotNil(t, received[1].Item)
+		assert.Equal(t, "Shipping a self-hosted wiki today", received[1].Item.Body)
+		assert.Equal(t, []string{"1749556800000003"}, replay.cursors())
+	})
+}
+
+func TestStreamer(t *testing.T) {
+	t.Run("it reconnects from the last persisted cursor after the connection drops", func(t *testing.T) {
+		replay, server := newJetstreamReplay(t, 2)
+		src := NewJetstreamSource(wsURL(server))
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		repo := &memoryCursorRepo{cursors: map[string]int64{}}
+
+		streamer := &Streamer{
+			logger:        logger,
+			src:           src,
+			pipeline:      NewPipeline(logger, nil, nil, nil, nil, nil),
+			cursors:       newCursorStore(logger, repo),
+			sm:            monitor.NewSourceMonitor(),
+			maxCatchUp:    100 * 365 * 24 * time.Hour,
+			flushInterval: 10 * time.Millisecond,
+			minBackoff:    10 * time.Millisecond,
+			maxBackoff:    50 * time.Millisecond,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go streamer.StartStreaming(ctx)
+
+		assert.Eventually(t, func() bool {
+			return repo.get(src.Name()) == 1749556800000005
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cursors := replay.cursors()
+		require.GreaterOrEqual(t, len(cursors), 3)
+		assert.Equal(t, "", cursors[0])
+		assert.Equal(t, "1749556800000002", cursors[1])
+		assert.Equal(t, "1749556800000003", cursors[2])
+	})
+}
@@ -0,0 +1,56 @@
+package sources
+
+import (
+	"log/slog"
+
+	"github.com/kova98/feedgrep.api/data"
+)
+
+// cursorRepo is the persistence used by cursorStore, implemented by repos.SourceCursorRepo.
+type cursorRepo interface {
+	GetCursor(source string) (*data.SourceCursor, error)
+	SaveCursor(source string, cursor int64) error
+}
+
+// cursorStore persists source cursors so pollers resume where they stopped after a restart.
+type cursorStore struct {
+	logger *slog.Logger
+	repo   cursorRepo
+}
+
+func newCursorStore(logger *slog.Logger, repo cursorRepo) cursorStore {
+	return cursorStore{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// load returns the stored cursor for source, clamped to oldest so a long outage
+// does not trigger an unbounded catch-up. When the cursor was clamped, the stored
+// cursor is returned as gap so the skipped range can be backfilled.
+// It returns 0 when nothing is stored.
+func (c cursorStore) load(source string, oldest int64) (cursor int64, gap int64) {
+	stored, err := c.repo.GetCursor(source)
+	if err != nil {
+		c.logger.Error("failed to load source cursor", "source", source, "error", err)
+		return 0, 0
+	}
+	if stored == nil {
+		return 0, 0
+	}
+
+	if stored.Cursor < oldest {
+		c.logger.Warn("source cursor exceeds catch-up window", "source", source, "cursor", stored.Cursor, "resume_from", oldest)
+		return oldest, stored.Cursor
+	}
+	return stored.Cursor, 0
+}
+
+func (c cursorStore) save(source string, cursor int64) {
+	if cursor <= 0 {
+		return
+	}
+	if err := c.repo.SaveCursor(source, cursor); err != nil {
+		c.logger.Error("failed to save source cursor", "source", source, "error", err)
+	}
+}
@@ -0,0 +1,181 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	engagementCheckInterval  = 1 * time.Minute
+	engagementCheckBatchSize = 500
+	engagementPostFields     = "id,score,num_comments"
+	engagementSourceName     = "arcticshift_engagement"
+)
+
+// pendingRepo is the persistence used by EngagementChecker, implemented by repos.PendingMatchRepo.
+type pendingRepo interface {
+	DeleteExpiredPendingMatches(now time.Time) (int64, error)
+	GetDuePendingMatches(now time.Time, limit int) ([]data.PendingMatch, error)
+	PromotePendingMatches(matches []data.Match, ids []int64) error
+	RescheduleChecks(ids []int64, next time.Time) error
+}
+
+// EngagementChecker re-checks pending matches against Arctic Shift and promotes the ones whose
+// post crossed its keyword's engagement threshold. Pending matches past their window are dropped.
+// Promoted matches are collapsed into the user's recent near-duplicates like any other match.
+type EngagementChecker struct {
+	logger   *slog.Logger
+	repo     pendingRepo
+	pipeline *Pipeline
+	client   *SourceClient
+	sm       *monitor.SourceMonitor
+	baseURL  string
+}
+
+func NewEngagementChecker(logger *slog.Logger, pendingMatchRepo *repos.PendingMatchRepo, pipeline *Pipeline, sourceMonitor *monitor.SourceMonitor) *EngagementChecker {
+	return &EngagementChecker{
+		logger:   logger,
+		repo:     pendingMatchRepo,
+		pipeline: pipeline,
+		client:   NewSourceClient(engagementSourceName, &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		sm:       sourceMonitor,
+		baseURL:  arcticShiftBaseURL,
+	}
+}
+
+func (c *EngagementChecker) StartChecking(ctx context.Context) {
+	c.logger.Info("starting engagement checks", "interval", engagementCheckInterval.Seconds())
+
+	ticker := time.NewTicker(engagementCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("stopping engagement checks")
+			return
+		case <-ticker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+func (c *EngagementChecker) check(ctx context.Context) {
+	now := time.Now()
+	expired, err := c.repo.DeleteExpiredPendingMatches(now)
+	if err != nil {
+		c.logger.Error("failed to delete expired pending matches", "error", err)
+	} else if expired > 0 {
+		c.logger.Debug("expired pending matches", "count", expired)
+	}
+
+	pending, err := c.repo.GetDuePendingMatches(now, engagementCheckBatchSize)
+	if err != nil {
+		c.logger.Error("failed to load pending matches", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	engagement, err := c.fetchEngagement(ctx, pending)
+	if err != nil {
+		c.sm.RequestError(engagementSourceName)
+		c.logger.Info("check engagement", "error", truncateError(err))
+		return
+	}
+
+	promoted := make([]data.Match, 0)
+	promotedIDs := make([]int64, 0)
+	waitingIDs := make([]int64, 0, len(pending))
+	for _, pm := range pending {
+		post, ok := engagement[pm.PostID]
+		threshold := data.EngagementThreshold{MinScore: pm.MinScore, MinComments: pm.MinComments}
+		if !ok || !threshold.Met(post.score, post.comments) {
+			waitingIDs = append(waitingIDs, pm.ID)
+			continue
+		}
+
+		match, err := promotedMatch(pm, post)
+		if err != nil {
+			c.logger.Error("failed to promote pending match", "pending_id", pm.ID, "error", err)
+			waitingIDs = append(waitingIDs, pm.ID)
+			continue
+		}
+		promoted = append(promoted, match)
+		promotedIDs = append(promotedIDs, pm.ID)
+	}
+
+	if promoted, err = c.pipeline.collapseDuplicates(promoted); err != nil {
+		c.logger.Error("failed to collapse promoted matches", "error", err)
+		promotedIDs = promotedIDs[:0]
+	}
+	if err := c.repo.PromotePendingMatches(promoted, promotedIDs); err != nil {
+		c.logger.Error("failed to store promoted matches", "error", err)
+	}
+	if err := c.repo.RescheduleChecks(waitingIDs, now.Add(engagementRecheckInterval)); err != nil {
+		c.logger.Error("failed to reschedule pending matches", "error", err)
+	}
+	c.sm.Batch(engagementSourceName, int64(len(pending)), now, 0)
+	if len(promoted) > 0 {
+		c.logger.Info("promoted pending matches", "count", len(promoted), "checked", len(pending))
+	}
+}
+
+type postEngagement struct {
+	score    int
+	comments int
+}
+
+func (c *EngagementChecker) fetchEngagement(ctx context.Context, pending []data.PendingMatch) (map[string]postEngagement, error) {
+	ids := make([]string, 0, len(pending))
+	seen := make(map[string]struct{}, len(pending))
+	for _, pm := range pending {
+		if _, ok := seen[pm.PostID]; ok {
+			continue
+		}
+		seen[pm.PostID] = struct{}{}
+		ids = append(ids, pm.PostID)
+	}
+
+	engagement := make(map[string]postEngagement, len(ids))
+	for start := 0; start < len(ids); start += parentLookupBatchSize {
+		batch := ids[start:min(start+parentLookupBatchSize, len(ids))]
+		posts, err := fetchArcticShiftPostsByID(ctx, c.client, c.baseURL, batch, engagementPostFields)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			engagement[post.ID] = postEngagement{score: post.Score, comments: post.NumComments}
+		}
+	}
+	return engagement, nil
+}
+
+// promotedMatch turns the pending match into a match, recording the engagement it reached.
+func promotedMatch(pm data.PendingMatch, post postEngagement) (data.Match, error) {
+	var payload data.RedditData
+	if err := json.Unmarshal(pm.DataRaw, &payload); err != nil {
+		return data.Match{}, err
+	}
+	payload.Score = post.score
+	payload.NumComments = post.comments
+	match, err := data.NewMatch(pm.UserID, pm.KeywordID, pm.Source, pm.Hash, payload)
+	if err != nil {
+		return match, err
+	}
+	match.Fingerprint = pm.Fingerprint
+	match.Location = data.MatchLocation{
+		Source:    pm.Source,
+		Subreddit: payload.Subreddit,
+		Permalink: payload.Permalink,
+	}
+	return match, nil
+}
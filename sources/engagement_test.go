@@ -0,0 +1,120 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePendingRepo struct {
+	due         []data.PendingMatch
+	promoted    []data.Match
+	promotedIDs []int64
+	rescheduled []int64
+}
+
+func (r *fakePendingRepo) DeleteExpiredPendingMatches(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakePendingRepo) GetDuePendingMatches(time.Time, int) ([]data.PendingMatch, error) {
+	return r.due, nil
+}
+
+func (r *fakePendingRepo) PromotePendingMatches(matches []data.Match, ids []int64) error {
+	r.promoted = append(r.promoted, matches...)
+	r.promotedIDs = append(r.promotedIDs, ids...)
+	return nil
+}
+
+func (r *fakePendingRepo) RescheduleChecks(ids []int64, _ time.Time) error {
+	r.rescheduled = append(r.rescheduled, ids...)
+	return nil
+}
+
+func TestEngagementChecker(t *testing.T) {
+	posts := map[string]models.ArcticShiftPost{
+		"hot":  {ID: "hot", Score: 150, NumComments: 3},
+		"cold": {ID: "cold", Score: 2, NumComments: 1},
+	}
+	newChecker := func(t *testing.T, status int) (*EngagementChecker, *fakePendingRepo) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			var resp models.ArcticShiftSearchResponse[models.ArcticShiftPost]
+			for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+				if post, ok := posts[id]; ok {
+					resp.Data = append(resp.Data, post)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		}))
+		t.Cleanup(server.Close)
+
+		payload, err := json.Marshal(data.RedditData{Keyword: "golang", Subreddit: "golang", Permalink: "/r/golang/comments/hot/"})
+		require.NoError(t, err)
+		fingerprint := int64(42)
+		repo := &fakePendingRepo{due: []data.PendingMatch{
+			{ID: 1, UserID: uuid.New(), Source: enums.SourceArcticShift, Hash: "a", PostID: "hot", DataRaw: payload, MinScore: 100, Fingerprint: &fingerprint},
+			{ID: 2, UserID: uuid.New(), Source: enums.SourceArcticShift, Hash: "b", PostID: "cold", DataRaw: payload, MinScore: 100},
+			{ID: 3, UserID: uuid.New(), Source: enums.SourceArcticShift, Hash: "c", PostID: "gone", DataRaw: payload, MinComments: 1},
+		}}
+		sm := monitor.NewSourceMonitor()
+		checker := &EngagementChecker{
+			logger:   slog.Default(),
+			repo:     repo,
+			pipeline: &Pipeline{duplicates: newDuplicateIndex(nil, 0)},
+			client:   NewSourceClient("test", http.DefaultClient, sm),
+			sm:       sm,
+			baseURL:  server.URL,
+		}
+		checker.client.maxAttempts = 1
+		return checker, repo
+	}
+
+	t.Run("it promotes the matches whose post crossed the threshold", func(t *testing.T) {
+		checker, repo := newChecker(t, http.StatusOK)
+
+		checker.check(context.Background())
+
+		assert.Equal(t, []int64{1}, repo.promotedIDs)
+		require.Len(t, repo.promoted, 1)
+		var payload data.RedditData
+		require.NoError(t, json.Unmarshal(repo.promoted[0].DataRaw, &payload))
+		assert.Equal(t, 150, payload.Score)
+		assert.Equal(t, int64(42), *repo.promoted[0].Fingerprint)
+		assert.Equal(t, "/r/golang/comments/hot/", repo.promoted[0].Location.Permalink)
+	})
+
+	t.Run("it reschedules the matches that are still below the threshold", func(t *testing.T) {
+		checker, repo := newChecker(t, http.StatusOK)
+
+		checker.check(context.Background())
+
+		assert.Equal(t, []int64{2, 3}, repo.rescheduled)
+	})
+
+	t.Run("it leaves the pending matches due when the lookup fails", func(t *testing.T) {
+		checker, repo := newChecker(t, http.StatusBadGateway)
+
+		checker.check(context.Background())
+
+		assert.Empty(t, repo.promotedIDs)
+		assert.Empty(t, repo.rescheduled)
+	})
+}
@@ -0,0 +1,425 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	feedMaxBodyBytes = 5 << 20
+	feedFetchWorkers = 4
+)
+
+var (
+	errUnsupportedFeed = errors.New("unsupported feed format")
+	errInvalidFeed     = errors.New("invalid feed")
+)
+
+// feedStatusError is returned when a feed responds with an unexpected status code.
+type feedStatusError int
+
+func (e feedStatusError) Error() string {
+	return fmt.Sprintf("status %d", int(e))
+}
+
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC822Z,
+	time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+}
+
+// FeedPoller periodically fetches every active user-registered RSS/Atom feed.
+type FeedPoller struct {
+	logger   *slog.Logger
+	feedRepo *repos.FeedRepo
+	pipeline *Pipeline
+	sm       *monitor.SourceMonitor
+	client   *SourceClient
+
+	interval   time.Duration
+	maxCatchUp time.Duration
+}
+
+func NewFeedPoller(logger *slog.Logger, feedRepo *repos.FeedRepo, pipeline *Pipeline, sourceMonitor *monitor.SourceMonitor) *FeedPoller {
+	return &FeedPoller{
+		logger:     logger,
+		feedRepo:   feedRepo,
+		pipeline:   pipeline,
+		sm:         sourceMonitor,
+		client:     NewSourceClient(string(enums.SourceFeed), NewPublicHTTPClient(20*time.Second), sourceMonitor),
+		interval:   time.Duration(config.Config.FeedPollIntervalMs) * time.Millisecond,
+		maxCatchUp: time.Duration(config.Config.MaxCatchUpMinutes) * time.Minute,
+	}
+}
+
+func (p *FeedPoller) StartPolling(ctx context.Context) {
+	p.logger.Info("starting feed polling", "interval", p.interval.Seconds())
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("stopping feed polling")
+			return
+		case <-ticker.C:
+			p.pollFeeds(ctx)
+		}
+	}
+}
+
+func (p *FeedPoller) pollFeeds(ctx context.Context) {
+	feeds, err := p.feedRepo.GetActiveFeeds()
+	if err != nil {
+		p.logger.Error("failed to load feeds", "error", err)
+		return
+	}
+
+	jobs := make(chan data.Feed)
+	var wg sync.WaitGroup
+	for range feedFetchWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				p.pollFeed(ctx, feed)
+			}
+		}()
+	}
+
+	for _, feed := range feeds {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- feed
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (p *FeedPoller) pollFeed(ctx context.Context, feed data.Feed) {
+	src := NewFeedSource(p.client, feed)
+	oldest := time.Now().Add(-p.maxCatchUp).Unix()
+
+	items, cursor, err := src.Fetch(ctx, max(feed.Cursor, oldest))
+	if errors.Is(err, ErrCircuitOpen) {
+		// Feeds share one breaker, so a feed that was not even requested is not marked as failing.
+		return
+	}
+	processingStart := time.Now()
+	state := src.Feed()
+	now := time.Now()
+	state.LastFetchedAt = &now
+	if err != nil {
+		p.sm.RequestError(string(enums.SourceFeed))
+		p.logger.Info("poll feed", "feed_id", feed.ID, "error", truncateError(err))
+		state.LastError = feedErrorMessage(err)
+		state.ConsecutiveFailures++
+	} else if err := p.process(items, processingStart); err != nil {
+		// The validators and cursor of the failed fetch are not kept, so the entries are fetched again.
+		p.logger.Error("failed to store matches", "feed_id", feed.ID, "error", err)
+		state = feed
+		state.LastFetchedAt = &now
+	} else {
+		state.Cursor = max(state.Cursor, cursor)
+		state.LastSuccessAt = &now
+		state.LastError = ""
+		state.ConsecutiveFailures = 0
+	}
+
+	if err := p.feedRepo.UpdateFetchState(state); err != nil {
+		p.logger.Error("failed to update feed state", "feed_id", feed.ID, "error", err)
+	}
+}
+
+func (p *FeedPoller) process(items []Item, processingStart time.Time) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := p.pipeline.Process(items, nil); err != nil {
+		return err
+	}
+	p.sm.Batch(string(enums.SourceFeed), int64(len(items)), processingStart, newestCreatedUTC(items))
+	return nil
+}
+
+// FeedSource fetches a single RSS or Atom feed using conditional GET.
+type FeedSource struct {
+	client *SourceClient
+	feed   data.Feed
+}
+
+func NewFeedSource(client *SourceClient, feed data.Feed) *FeedSource {
+	return &FeedSource{
+		client: client,
+		feed:   feed,
+	}
+}
+
+func (s *FeedSource) Name() string {
+	return string(enums.SourceFeed) + "_" + strconv.Itoa(s.feed.ID)
+}
+
+// Feed returns the feed with the title and conditional GET validators from the latest fetch.
+func (s *FeedSource) Feed() data.Feed {
+	return s.feed
+}
+
+// Fetch returns the entries published after cursor. Entries without a publication date are always returned
+// and rely on match deduplication.
+func (s *FeedSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.feed.URL, nil)
+	if err != nil {
+		return nil, cursor, err
+	}
+	req.Header.Set("User-Agent", "feedgrep")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8")
+	if s.feed.ETag != "" {
+		req.Header.Set("If-None-Match", s.feed.ETag)
+	}
+	if s.feed.LastModified != "" {
+		req.Header.Set("If-Modified-Since", s.feed.LastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, cursor, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, cursor, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, cursor, feedStatusError(resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, feedMaxBodyBytes))
+	if err != nil {
+		return nil, cursor, fmt.Errorf("read feed: %w", err)
+	}
+
+	title, entries, err := parseFeed(body)
+	if err != nil {
+		return nil, cursor, fmt.Errorf("%w: %w", errInvalidFeed, err)
+	}
+
+	s.feed.ETag = resp.Header.Get("ETag")
+	s.feed.LastModified = resp.Header.Get("Last-Modified")
+	if title != "" {
+		s.feed.Title = title
+	}
+
+	newest := cursor
+	items := make([]Item, 0, len(entries))
+	for _, entry := range entries {
+		if entry.published > 0 && entry.published <= cursor {
+			continue
+		}
+		if entry.link == "" && entry.id == "" {
+			continue
+		}
+		items = append(items, s.item(entry))
+		if entry.published > newest {
+			newest = entry.published
+		}
+	}
+
+	return items, newest, nil
+}
+
+func (s *FeedSource) item(entry feedEntry) Item {
+	permalink := entry.link
+	if permalink == "" {
+		permalink = entry.id
+	}
+	id := entry.id
+	if id == "" {
+		id = permalink
+	}
+	createdUTC := entry.published
+	if createdUTC == 0 {
+		createdUTC = time.Now().Unix()
+	}
+
+	feedData := data.FeedData{
+		FeedID:    s.feed.ID,
+		FeedTitle: s.feed.Title,
+		Author:    entry.author,
+		Title:     entry.title,
+		Body:      entry.body,
+		Permalink: permalink,
+	}
+
+	return Item{
+		Source:     enums.SourceFeed,
+		Kind:       ItemKindPost,
+		ID:         id,
+		Title:      entry.title,
+		Body:       entry.body,
+		Author:     entry.author,
+		Permalink:  permalink,
+		CreatedUTC: createdUTC,
+		Owner:      s.feed.UserID,
+		MatchData: func(keyword string) any {
+			payload := feedData
+			payload.Keyword = keyword
+			return payload
+		},
+	}
+}
+
+type feedEntry struct {
+	id        string
+	title     string
+	body      string
+	link      string
+	author    string
+	published int64
+}
+
+// parseFeed parses an RSS 2.0 or Atom document into its title and entries.
+func parseFeed(body []byte) (string, []feedEntry, error) {
+	root, err := feedRootElement(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch root {
+	case "rss":
+		var feed models.RSSFeed
+		if err := xml.Unmarshal(body, &feed); err != nil {
+			return "", nil, fmt.Errorf("decode rss: %w", err)
+		}
+		entries := make([]feedEntry, 0, len(feed.Channel.Items))
+		for _, item := range feed.Channel.Items {
+			content := item.Content
+			if strings.TrimSpace(content) == "" {
+				content = item.Description
+			}
+			author := item.Creator
+			if author == "" {
+				author = item.Author
+			}
+			entries = append(entries, feedEntry{
+				id:        strings.TrimSpace(item.GUID),
+				title:     stripHTML(item.Title),
+				body:      stripHTML(content),
+				link:      strings.TrimSpace(item.Link),
+				author:    strings.TrimSpace(author),
+				published: parseFeedTime(item.PubDate),
+			})
+		}
+		return strings.TrimSpace(feed.Channel.Title), entries, nil
+	case "feed":
+		var feed models.AtomFeed
+		if err := xml.Unmarshal(body, &feed); err != nil {
+			return "", nil, fmt.Errorf("decode atom: %w", err)
+		}
+		entries := make([]feedEntry, 0, len(feed.Entries))
+		for _, entry := range feed.Entries {
+			content := entry.Content
+			if strings.TrimSpace(content) == "" {
+				content = entry.Summary
+			}
+			published := parseFeedTime(entry.Published)
+			if published == 0 {
+				published = parseFeedTime(entry.Updated)
+			}
+			entries = append(entries, feedEntry{
+				id:        strings.TrimSpace(entry.ID),
+				title:     stripHTML(entry.Title),
+				body:      stripHTML(content),
+				link:      atomEntryLink(entry.Links),
+				author:    strings.TrimSpace(entry.Author.Name),
+				published: published,
+			})
+		}
+		return strings.TrimSpace(feed.Title), entries, nil
+	default:
+		return "", nil, errUnsupportedFeed
+	}
+}
+
+func feedRootElement(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", errUnsupportedFeed
+			}
+			return "", fmt.Errorf("decode feed: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func atomEntryLink(links []models.AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func parseFeedTime(value string) int64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix()
+		}
+	}
+	return 0
+}
+
+// feedErrorMessage describes a failed fetch to the feed's owner. The error itself is only logged,
+// as it can reveal details of the network the feed was fetched from.
+func feedErrorMessage(err error) string {
+	var status feedStatusError
+	switch {
+	case errors.Is(err, ErrPrivateAddress):
+		return "Feed URL does not point to a public address."
+	case errors.As(err, &status):
+		return fmt.Sprintf("Feed server responded with status %d.", int(status))
+	case errors.Is(err, errInvalidFeed):
+		return "Feed is not a valid RSS or Atom document."
+	default:
+		return "Feed could not be fetched."
+	}
+}
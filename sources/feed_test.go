@@ -0,0 +1,227 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Example Blog</title>
+    <item>
+      <title>Newer post</title>
+      <link>https://example.com/newer</link>
+      <guid>newer</guid>
+      <dc:creator>alice</dc:creator>
+      <pubDate>Tue, 10 Jun 2025 12:00:00 +0000</pubDate>
+      <description>short summary</description>
+      <content:encoded><![CDATA[<p>Hello <b>world</b></p>]]></content:encoded>
+    </item>
+    <item>
+      <title>Older post</title>
+      <link>https://example.com/older</link>
+      <guid>older</guid>
+      <pubDate>Mon, 09 Jun 2025 12:00:00 +0000</pubDate>
+      <description>old news</description>
+    </item>
+  </channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Atom</title>
+  <entry>
+    <id>urn:entry:1</id>
+    <title>Atom entry</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link rel="alternate" href="https://example.com/entry"/>
+    <author><name>bob</name></author>
+    <published>2025-06-10T12:00:00Z</published>
+    <summary type="html">&lt;p&gt;Atom &lt;i&gt;body&lt;/i&gt;&lt;/p&gt;</summary>
+  </entry>
+</feed>`
+
+func newTestFeedSource(url string, feed data.Feed) *FeedSource {
+	feed.URL = url
+	return NewFeedSource(NewSourceClient("test", http.DefaultClient, monitor.NewSourceMonitor()), feed)
+}
+
+func TestFeedSource(t *testing.T) {
+	t.Run("it parses rss entries and strips html from the body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testRSSFeed))
+		}))
+		defer server.Close()
+
+		owner := uuid.New()
+		src := newTestFeedSource(server.URL, data.Feed{ID: 7, UserID: owner})
+
+		items, cursor, err := src.Fetch(context.Background(), 0)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+
+		assert.Equal(t, enums.SourceFeed, items[0].Source)
+		assert.Equal(t, "Newer post", items[0].Title)
+		assert.Equal(t, "Hello world", items[0].Body)
+		assert.Equal(t, "alice", items[0].Author)
+		assert.Equal(t, "https://example.com/newer", items[0].Permalink)
+		assert.Equal(t, owner, items[0].Owner)
+		assert.Equal(t, "old news", items[1].Body)
+		assert.Equal(t, int64(1749556800), cursor)
+		assert.Equal(t, "Example Blog", src.Feed().Title)
+
+		payload, ok := items[0].MatchData("world").(data.FeedData)
+		require.True(t, ok)
+		assert.Equal(t, "world", payload.Keyword)
+		assert.Equal(t, 7, payload.FeedID)
+		assert.Equal(t, "Example Blog", payload.FeedTitle)
+	})
+
+	t.Run("it parses atom entries and prefers the alternate link", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testAtomFeed))
+		}))
+		defer server.Close()
+
+		src := newTestFeedSource(server.URL, data.Feed{ID: 1})
+
+		items, _, err := src.Fetch(context.Background(), 0)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		assert.Equal(t, "urn:entry:1", items[0].ID)
+		assert.Equal(t, "Atom entry", items[0].Title)
+		assert.Equal(t, "Atom body", items[0].Body)
+		assert.Equal(t, "bob", items[0].Author)
+		assert.Equal(t, "https://example.com/entry", items[0].Permalink)
+		assert.Equal(t, "Example Atom", src.Feed().Title)
+	})
+
+	t.Run("it only returns entries published after the cursor", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testRSSFeed))
+		}))
+		defer server.Close()
+
+		src := newTestFeedSource(server.URL, data.Feed{ID: 1})
+
+		items, cursor, err := src.Fetch(context.Background(), 1749470400)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "newer", items[0].ID)
+		assert.Equal(t, int64(1749556800), cursor)
+	})
+
+	t.Run("it sends conditional get validators and returns nothing when not modified", func(t *testing.T) {
+		var ifNoneMatch, ifModifiedSince string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ifNoneMatch = r.Header.Get("If-None-Match")
+			ifModifiedSince = r.Header.Get("If-Modified-Since")
+			w.WriteHeader(http.StatusNotModified)
+		}))
+		defer server.Close()
+
+		src := newTestFeedSource(server.URL, data.Feed{
+			ID:           1,
+			ETag:         `"abc"`,
+			LastModified: "Tue, 10 Jun 2025 12:00:00 GMT",
+		})
+
+		items, cursor, err := src.Fetch(context.Background(), 42)
+		require.NoError(t, err)
+		assert.Empty(t, items)
+		assert.Equal(t, int64(42), cursor)
+		assert.Equal(t, `"abc"`, ifNoneMatch)
+		assert.Equal(t, "Tue, 10 Jun 2025 12:00:00 GMT", ifModifiedSince)
+		assert.Equal(t, `"abc"`, src.Feed().ETag)
+	})
+
+	t.Run("it stores the validators returned by the server", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Last-Modified", "Wed, 11 Jun 2025 08:00:00 GMT")
+			_, _ = w.Write([]byte(testAtomFeed))
+		}))
+		defer server.Close()
+
+		src := newTestFeedSource(server.URL, data.Feed{ID: 1})
+
+		_, _, err := src.Fetch(context.Background(), 0)
+		require.NoError(t, err)
+		assert.Equal(t, `"v2"`, src.Feed().ETag)
+		assert.Equal(t, "Wed, 11 Jun 2025 08:00:00 GMT", src.Feed().LastModified)
+	})
+
+	t.Run("it returns an error for non-ok responses and unsupported documents", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+
+		_, _, err := newTestFeedSource(failing.URL, data.Feed{ID: 1}).Fetch(context.Background(), 0)
+		assert.Error(t, err)
+
+		html := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<html><body>not a feed</body></html>"))
+		}))
+		defer html.Close()
+
+		_, _, err = newTestFeedSource(html.URL, data.Feed{ID: 1}).Fetch(context.Background(), 0)
+		assert.ErrorIs(t, err, errUnsupportedFeed)
+	})
+}
+
+func TestPublicHTTPClient(t *testing.T) {
+	t.Run("it refuses to connect to addresses that are not public", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(testRSSFeed))
+		}))
+		defer server.Close()
+
+		src := NewFeedSource(NewSourceClient("test", NewPublicHTTPClient(time.Second), monitor.NewSourceMonitor()), data.Feed{ID: 1, URL: server.URL})
+
+		_, _, err := src.Fetch(context.Background(), 0)
+		assert.ErrorIs(t, err, ErrPrivateAddress)
+		assert.Equal(t, "Feed URL does not point to a public address.", feedErrorMessage(err))
+	})
+
+	t.Run("it classifies addresses", func(t *testing.T) {
+		cases := map[string]bool{
+			"93.184.216.34":          true,
+			"2606:4700:4700::1111":   true,
+			"127.0.0.1":              false,
+			"10.1.2.3":               false,
+			"172.16.0.1":             false,
+			"192.168.1.1":            false,
+			"169.254.169.254":        false,
+			"100.64.0.1":             false,
+			"198.18.0.1":             false,
+			"198.19.255.254":         false,
+			"198.20.0.1":             true,
+			"64:ff9b::a01:203":       false,
+			"64:ff9b::5db8:d822":     false,
+			"0.0.0.0":                false,
+			"::1":                    false,
+			"fd00::1":                false,
+			"fe80::1":                false,
+			"::ffff:127.0.0.1":       false,
+			"::ffff:169.254.169.254": false,
+		}
+
+		for address, public := range cases {
+			assert.Equal(t, public, IsPublicAddr(netip.MustParseAddr(address)), address)
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package sources
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+)
+
+const (
+	// simhashMinTokens is the shortest text that gets a fingerprint. Short texts such as "thanks!"
+	// are identical far too often to be treated as the same content.
+	simhashMinTokens = 6
+	// simhashMaxDistance is the number of differing bits up to which two fingerprints are
+	// considered near-duplicates.
+	simhashMaxDistance = 6
+)
+
+// simhash returns a 64-bit SimHash of the words in text, so texts that differ in a few
+// words get fingerprints that differ in a few bits.
+func simhash(text string) (uint64, bool) {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(tokens) < simhashMinTokens {
+		return 0, false
+	}
+
+	// Words and word pairs are both features: words keep short texts stable when a few words
+	// are added, pairs keep texts with the same vocabulary in a different order apart.
+	var weights [64]int
+	for i := range tokens {
+		addSimhashFeature(&weights, tokens[i])
+		if i+1 < len(tokens) {
+			addSimhashFeature(&weights, tokens[i]+" "+tokens[i+1])
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+func addSimhashFeature(weights *[64]int, feature string) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	for bit := range weights {
+		if sum&(1<<bit) != 0 {
+			weights[bit]++
+		} else {
+			weights[bit]--
+		}
+	}
+}
+
+func nearDuplicate(a, b int64) bool {
+	return bits.OnesCount64(uint64(a)^uint64(b)) <= simhashMaxDistance
+}
+
+// fingerprintRepo is the persistence used by duplicateIndex, implemented by repos.MatchRepo.
+type fingerprintRepo interface {
+	GetRecentFingerprints(userIDs []uuid.UUID, since time.Time) ([]data.MatchFingerprint, error)
+	AddAlsoPostedIn(id int, location data.MatchLocation) error
+}
+
+const (
+	// simhashBands is the number of bands a fingerprint is split into for lookups. It is larger
+	// than simhashMaxDistance, so near-duplicates always share at least one band.
+	simhashBands    = 8
+	simhashBandBits = 64 / simhashBands
+	// fingerprintSyncOverlap is how far back each sync reaches before the previous one, so
+	// matches committed after the previous sync but created before it are not missed.
+	fingerprintSyncOverlap = 1 * time.Minute
+	// fingerprintPruneInterval is how often users without recent matches are dropped.
+	fingerprintPruneInterval = 10 * time.Minute
+)
+
+// seenFingerprint is a match a new match can collapse into. It is either stored, identified by
+// storedID, or part of the current batch at batchIndex.
+type seenFingerprint struct {
+	fingerprint int64
+	keywordID   int
+	permalink   string
+	createdAt   time.Time
+	storedID    int
+	batchIndex  int
+}
+
+// fingerprintBuckets finds near-duplicates by only comparing fingerprints that share a band.
+type fingerprintBuckets map[uint16][]*seenFingerprint
+
+func simhashBandKeys(fingerprint int64) [simhashBands]uint16 {
+	var keys [simhashBands]uint16
+	for band := range keys {
+		value := uint16(uint64(fingerprint)>>(band*simhashBandBits)) & (1<<simhashBandBits - 1)
+		keys[band] = uint16(band)<<simhashBandBits | value
+	}
+	return keys
+}
+
+func (b fingerprintBuckets) add(s *seenFingerprint) {
+	for _, key := range simhashBandKeys(s.fingerprint) {
+		b[key] = append(b[key], s)
+	}
+}
+
+// find returns a near-duplicate of the fingerprint matched by the same keyword at a different
+// permalink created after since, or nil.
+func (b fingerprintBuckets) find(fingerprint int64, keywordID int, permalink string, since time.Time) *seenFingerprint {
+	for _, key := range simhashBandKeys(fingerprint) {
+		for _, s := range b[key] {
+			if s.keywordID == keywordID && s.createdAt.After(since) && s.permalink != permalink && nearDuplicate(s.fingerprint, fingerprint) {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// userFingerprints are the stored fingerprints of a user's matches from the duplicate window.
+type userFingerprints struct {
+	syncedAt time.Time
+	ids      map[int]struct{}
+	buckets  fingerprintBuckets
+}
+
+func newUserFingerprints() *userFingerprints {
+	return &userFingerprints{ids: make(map[int]struct{}), buckets: make(fingerprintBuckets)}
+}
+
+func (u *userFingerprints) add(stored data.MatchFingerprint) {
+	if _, ok := u.ids[stored.ID]; ok {
+		return
+	}
+	u.ids[stored.ID] = struct{}{}
+	u.buckets.add(&seenFingerprint{
+		fingerprint: stored.Fingerprint,
+		keywordID:   stored.KeywordID,
+		permalink:   stored.Permalink,
+		createdAt:   stored.CreatedAt,
+		storedID:    stored.ID,
+		batchIndex:  -1,
+	})
+}
+
+// compact drops the fingerprints created before since once they make up most of the user's.
+func (u *userFingerprints) compact(since time.Time) {
+	live := make(map[int]*seenFingerprint, len(u.ids))
+	for _, bucket := range u.buckets {
+		for _, s := range bucket {
+			if s.createdAt.After(since) {
+				live[s.storedID] = s
+			}
+		}
+	}
+	if len(live)*2 >= len(u.ids) {
+		return
+	}
+
+	u.ids = make(map[int]struct{}, len(live))
+	u.buckets = make(fingerprintBuckets)
+	for id, s := range live {
+		u.ids[id] = struct{}{}
+		u.buckets.add(s)
+	}
+}
+
+// duplicateIndex caches the fingerprints of each user's matches from the duplicate window. Each
+// batch only loads the matches stored since the user's last sync, including ones stored by other
+// instances, instead of the whole window.
+type duplicateIndex struct {
+	repo   fingerprintRepo
+	window time.Duration
+
+	mu       sync.Mutex
+	users    map[uuid.UUID]*userFingerprints
+	prunedAt time.Time
+}
+
+func newDuplicateIndex(repo fingerprintRepo, window time.Duration) *duplicateIndex {
+	return &duplicateIndex{
+		repo:   repo,
+		window: window,
+		users:  make(map[uuid.UUID]*userFingerprints),
+	}
+}
+
+// sync loads the users' fingerprints stored since their last sync.
+func (d *duplicateIndex) sync(userIDs []uuid.UUID, now time.Time) error {
+	windowStart := now.Add(-d.window)
+	known := make([]uuid.UUID, 0, len(userIDs))
+	unknown := make([]uuid.UUID, 0, len(userIDs))
+	knownSince := now
+
+	d.mu.Lock()
+	for _, userID := range userIDs {
+		user, ok := d.users[userID]
+		if !ok {
+			unknown = append(unknown, userID)
+			continue
+		}
+		known = append(known, userID)
+		if user.syncedAt.Before(knownSince) {
+			knownSince = user.syncedAt
+		}
+	}
+	d.mu.Unlock()
+
+	knownSince = knownSince.Add(-fingerprintSyncOverlap)
+	if knownSince.Before(windowStart) {
+		knownSince = windowStart
+	}
+	loaded := make([]data.MatchFingerprint, 0)
+	for _, load := range []struct {
+		userIDs []uuid.UUID
+		since   time.Time
+	}{{known, knownSince}, {unknown, windowStart}} {
+		if len(load.userIDs) == 0 {
+			continue
+		}
+		stored, err := d.repo.GetRecentFingerprints(load.userIDs, load.since)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, stored...)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, userID := range userIDs {
+		if _, ok := d.users[userID]; !ok {
+			d.users[userID] = newUserFingerprints()
+		}
+		d.users[userID].syncedAt = now
+	}
+	for _, stored := range loaded {
+		d.users[stored.UserID].add(stored)
+	}
+	if now.Sub(d.prunedAt) >= fingerprintPruneInterval {
+		d.prune(now)
+	}
+	return nil
+}
+
+// prune drops the users that were not synced within the window, since all of their matches have
+// expired, and compacts the rest.
+func (d *duplicateIndex) prune(now time.Time) {
+	windowStart := now.Add(-d.window)
+	for userID, user := range d.users {
+		if user.syncedAt.Before(windowStart) {
+			delete(d.users, userID)
+			continue
+		}
+		user.compact(windowStart)
+	}
+	d.prunedAt = now
+}
+
+// collapse drops matches that are near-duplicates of another match of the same keyword from the
+// duplicate window or from this batch, recording their location on that match instead. Matches
+// of the same permalink, e.g. an item that is matched again, are never collapsed.
+func (d *duplicateIndex) collapse(matches []data.Match, now time.Time) ([]data.Match, error) {
+	if len(matches) == 0 || d.window <= 0 {
+		return matches, nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(matches))
+	seenUsers := make(map[uuid.UUID]bool)
+	for _, match := range matches {
+		if match.Fingerprint != nil && !seenUsers[match.UserID] {
+			seenUsers[match.UserID] = true
+			userIDs = append(userIDs, match.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return matches, nil
+	}
+	if err := d.sync(userIDs, now); err != nil {
+		return nil, err
+	}
+
+	type storedDuplicate struct {
+		id       int
+		location data.MatchLocation
+	}
+	windowStart := now.Add(-d.window)
+	batch := make(map[uuid.UUID]fingerprintBuckets, len(userIDs))
+	duplicates := make([]storedDuplicate, 0)
+	kept := make([]data.Match, 0, len(matches))
+
+	d.mu.Lock()
+	for _, match := range matches {
+		if match.Fingerprint == nil {
+			kept = append(kept, match)
+			continue
+		}
+
+		original := batch[match.UserID].find(*match.Fingerprint, match.KeywordID, match.Location.Permalink, windowStart)
+		if original == nil {
+			if user, ok := d.users[match.UserID]; ok {
+				original = user.buckets.find(*match.Fingerprint, match.KeywordID, match.Location.Permalink, windowStart)
+			}
+		}
+		switch {
+		case original == nil:
+			if batch[match.UserID] == nil {
+				batch[match.UserID] = make(fingerprintBuckets)
+			}
+			batch[match.UserID].add(&seenFingerprint{
+				fingerprint: *match.Fingerprint,
+				keywordID:   match.KeywordID,
+				permalink:   match.Location.Permalink,
+				createdAt:   now,
+				batchIndex:  len(kept),
+			})
+			kept = append(kept, match)
+		case original.batchIndex >= 0:
+			kept[original.batchIndex].AlsoPostedIn = appendLocation(kept[original.batchIndex].AlsoPostedIn, match.Location)
+		default:
+			duplicates = append(duplicates, storedDuplicate{id: original.storedID, location: match.Location})
+		}
+	}
+	d.mu.Unlock()
+
+	for _, duplicate := range duplicates {
+		if err := d.repo.AddAlsoPostedIn(duplicate.id, duplicate.location); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+func appendLocation(locations []data.MatchLocation, location data.MatchLocation) []data.MatchLocation {
+	for _, l := range locations {
+		if l.Permalink == location.Permalink {
+			return locations
+		}
+	}
+	return append(locations, location)
+}
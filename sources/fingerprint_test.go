@@ -0,0 +1,192 @@
+package sources
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimhash(t *testing.T) {
+	const announcement = "We just released version 2.0 of our open source database with faster queries, a new storage engine and native vector search."
+
+	t.Run("it gives near-duplicates fingerprints within the distance", func(t *testing.T) {
+		a, ok := simhash(announcement)
+		require.True(t, ok)
+		b, ok := simhash("[Crosspost] " + announcement + " Feedback welcome!")
+		require.True(t, ok)
+
+		assert.True(t, nearDuplicate(int64(a), int64(b)))
+	})
+
+	t.Run("it ignores case and punctuation", func(t *testing.T) {
+		a, _ := simhash(announcement)
+		b, _ := simhash("we JUST released version 2.0 of our open-source database, with faster queries; a new storage engine and native vector search")
+
+		assert.Equal(t, a, b)
+	})
+
+	t.Run("it keeps unrelated texts apart", func(t *testing.T) {
+		a, _ := simhash(announcement)
+		b, _ := simhash("Does anyone have recommendations for a lightweight CRM that integrates with our existing helpdesk software?")
+
+		assert.False(t, nearDuplicate(int64(a), int64(b)))
+	})
+
+	t.Run("it does not fingerprint short texts", func(t *testing.T) {
+		_, ok := simhash("thanks, this helped!")
+		assert.False(t, ok)
+	})
+}
+
+type fakeFingerprintRepo struct {
+	stored  []data.MatchFingerprint
+	loads   []time.Time
+	addedTo map[int][]data.MatchLocation
+}
+
+func (r *fakeFingerprintRepo) GetRecentFingerprints(userIDs []uuid.UUID, since time.Time) ([]data.MatchFingerprint, error) {
+	r.loads = append(r.loads, since)
+	fingerprints := make([]data.MatchFingerprint, 0)
+	for _, s := range r.stored {
+		if slices.Contains(userIDs, s.UserID) && s.CreatedAt.After(since) {
+			fingerprints = append(fingerprints, s)
+		}
+	}
+	return fingerprints, nil
+}
+
+func (r *fakeFingerprintRepo) AddAlsoPostedIn(id int, location data.MatchLocation) error {
+	if r.addedTo == nil {
+		r.addedTo = make(map[int][]data.MatchLocation)
+	}
+	r.addedTo[id] = append(r.addedTo[id], location)
+	return nil
+}
+
+func TestDuplicateIndex(t *testing.T) {
+	const window = 24 * time.Hour
+	now := time.Now()
+	userID := uuid.New()
+	original := int64(0b1010)
+	nearDuplicate := int64(0b1010_0111)
+	unrelated := int64(-1)
+	const keywordID = 3
+
+	newMatch := func(userID uuid.UUID, fingerprint int64, permalink string) data.Match {
+		return data.Match{
+			UserID:      userID,
+			KeywordID:   keywordID,
+			Fingerprint: &fingerprint,
+			Location:    data.MatchLocation{Source: enums.SourceArcticShift, Permalink: permalink},
+		}
+	}
+
+	t.Run("it records a near-duplicate of a stored match on that match", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{stored: []data.MatchFingerprint{
+			{ID: 7, UserID: userID, KeywordID: keywordID, Fingerprint: original, Permalink: "/a", CreatedAt: now.Add(-time.Hour)},
+		}}
+		index := newDuplicateIndex(repo, window)
+
+		kept, err := index.collapse([]data.Match{newMatch(userID, nearDuplicate, "/b")}, now)
+
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+		require.Len(t, repo.addedTo[7], 1)
+		assert.Equal(t, "/b", repo.addedTo[7][0].Permalink)
+	})
+
+	t.Run("it records a near-duplicate from the same batch on the first match", func(t *testing.T) {
+		index := newDuplicateIndex(&fakeFingerprintRepo{}, window)
+
+		kept, err := index.collapse([]data.Match{
+			newMatch(userID, original, "/a"),
+			newMatch(userID, nearDuplicate, "/b"),
+			newMatch(userID, unrelated, "/c"),
+		}, now)
+
+		require.NoError(t, err)
+		require.Len(t, kept, 2)
+		assert.Equal(t, []data.MatchLocation{{Source: enums.SourceArcticShift, Permalink: "/b"}}, kept[0].AlsoPostedIn)
+		assert.Empty(t, kept[1].AlsoPostedIn)
+	})
+
+	t.Run("it keeps matches of the same permalink and of other users", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{stored: []data.MatchFingerprint{
+			{ID: 7, UserID: userID, KeywordID: keywordID, Fingerprint: original, Permalink: "/a", CreatedAt: now.Add(-time.Hour)},
+		}}
+		index := newDuplicateIndex(repo, window)
+
+		kept, err := index.collapse([]data.Match{
+			newMatch(userID, nearDuplicate, "/a"),
+			newMatch(uuid.New(), nearDuplicate, "/b"),
+		}, now)
+
+		require.NoError(t, err)
+		assert.Len(t, kept, 2)
+		assert.Empty(t, repo.addedTo)
+	})
+
+	t.Run("it keeps near-duplicates matched by another keyword", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{stored: []data.MatchFingerprint{
+			{ID: 7, UserID: userID, KeywordID: keywordID, Fingerprint: original, Permalink: "/a", CreatedAt: now.Add(-time.Hour)},
+		}}
+		index := newDuplicateIndex(repo, window)
+		other := newMatch(userID, nearDuplicate, "/b")
+		other.KeywordID = keywordID + 1
+
+		kept, err := index.collapse([]data.Match{newMatch(userID, original, "/c"), other}, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, []data.Match{other}, kept)
+		assert.Len(t, repo.addedTo[7], 1)
+	})
+
+	t.Run("it ignores matches older than the window", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{stored: []data.MatchFingerprint{
+			{ID: 7, UserID: userID, KeywordID: keywordID, Fingerprint: original, Permalink: "/a", CreatedAt: now.Add(-time.Hour)},
+		}}
+		index := newDuplicateIndex(repo, window)
+		_, err := index.collapse([]data.Match{newMatch(userID, unrelated, "/c")}, now)
+		require.NoError(t, err)
+
+		kept, err := index.collapse([]data.Match{newMatch(userID, nearDuplicate, "/b")}, now.Add(window))
+
+		require.NoError(t, err)
+		assert.Len(t, kept, 1)
+		assert.Empty(t, repo.addedTo)
+	})
+
+	t.Run("it only loads the matches stored since the previous batch", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{}
+		index := newDuplicateIndex(repo, window)
+		_, err := index.collapse([]data.Match{newMatch(userID, unrelated, "/c")}, now)
+		require.NoError(t, err)
+		repo.stored = append(repo.stored, data.MatchFingerprint{ID: 8, UserID: userID, KeywordID: keywordID, Fingerprint: original, Permalink: "/a", CreatedAt: now.Add(time.Second)})
+
+		kept, err := index.collapse([]data.Match{newMatch(userID, nearDuplicate, "/b")}, now.Add(time.Minute))
+
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+		assert.Len(t, repo.addedTo[8], 1)
+		require.Len(t, repo.loads, 2)
+		assert.Equal(t, now.Add(-window), repo.loads[0])
+		assert.Equal(t, now.Add(-fingerprintSyncOverlap), repo.loads[1])
+	})
+
+	t.Run("it keeps matches without a fingerprint", func(t *testing.T) {
+		repo := &fakeFingerprintRepo{}
+		index := newDuplicateIndex(repo, window)
+
+		kept, err := index.collapse([]data.Match{{UserID: userID}, {UserID: userID}}, now)
+
+		require.NoError(t, err)
+		assert.Len(t, kept, 2)
+		assert.Empty(t, repo.loads)
+	})
+}
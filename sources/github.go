@@ -0,0 +1,390 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	githubAPIURL           = "https://api.github.com"
+	githubEventsPageSize   = 100
+	githubMaxPagesPerPoll  = 3
+	githubDiscussionsLimit = 50
+	githubTypeIssue        = "issue"
+	githubTypePullRequest  = "pull_request"
+	githubTypeDiscussion   = "discussion"
+	githubDiscussionsQuery = `query($owner: String!, $name: String!, $first: Int!) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: $first, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { number title body url closed createdAt author { login } labels(first: 20) { nodes { name } } }
+    }
+  }
+}`
+)
+
+var errGitHubRateLimited = errors.New("github rate limit exhausted")
+
+// GitHubClient performs GitHub API requests and tracks the X-RateLimit-Remaining budget shared by all watched repositories.
+type GitHubClient struct {
+	client  *SourceClient
+	token   string
+	baseURL string
+
+	mu            sync.Mutex
+	rateRemaining int
+	rateResetAt   time.Time
+}
+
+func NewGitHubClient(token string, sourceMonitor *monitor.SourceMonitor) *GitHubClient {
+	return &GitHubClient{
+		client:        NewSourceClient(string(enums.SourceGitHub), &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		token:         token,
+		baseURL:       githubAPIURL,
+		rateRemaining: -1,
+	}
+}
+
+// do sends the request and returns the response with a 200 or 304 status. It fails fast with
+// errGitHubRateLimited while the rate limit is exhausted instead of blocking the poller.
+func (c *GitHubClient) do(req *http.Request) (*http.Response, error) {
+	if wait := c.rateLimitWait(); wait > 0 {
+		return nil, fmt.Errorf("%w, resets in %s", errGitHubRateLimited, wait.Round(time.Second))
+	}
+
+	req.Header.Set("User-Agent", "feedgrep")
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.updateRateLimit(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotModified:
+		return resp, nil
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		resp.Body.Close()
+		if c.rateLimitWait() > 0 {
+			return nil, errGitHubRateLimited
+		}
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+func (c *GitHubClient) rateLimitWait() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rateRemaining != 0 {
+		return 0
+	}
+	return max(time.Until(c.rateResetAt), 0)
+}
+
+// updateRateLimit records the X-RateLimit-Remaining and X-RateLimit-Reset headers.
+func (c *GitHubClient) updateRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateRemaining = remaining
+	c.rateResetAt = time.Unix(reset, 0)
+}
+
+// GitHubSource watches a single repository for new issues, pull requests, comments and discussions.
+// Issues, pull requests and their comments come from the repository events API. Discussions are only
+// exposed through GraphQL, which requires a token, so they are skipped without one.
+type GitHubSource struct {
+	client *GitHubClient
+	repo   string
+	etag   string
+}
+
+func NewGitHubSource(client *GitHubClient, repo string) *GitHubSource {
+	return &GitHubSource{
+		client: client,
+		repo:   strings.Trim(strings.TrimSpace(repo), "/"),
+	}
+}
+
+func (s *GitHubSource) Name() string {
+	return string(enums.SourceGitHub) + "_" + s.repo
+}
+
+func (s *GitHubSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	items, newest, etag, err := s.fetchEvents(ctx, cursor)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	if s.client.token != "" {
+		discussions, err := s.fetchDiscussions(ctx, cursor)
+		if err != nil {
+			return nil, cursor, err
+		}
+		for _, item := range discussions {
+			items = append(items, item)
+			newest = max(newest, item.CreatedUTC)
+		}
+	}
+
+	// The validator is only kept once everything was fetched, so a failed poll is retried in full.
+	s.etag = etag
+	return items, newest, nil
+}
+
+// fetchEvents walks the newest-first events feed until it reaches events at or before cursor.
+// Without a cursor only the latest page is read.
+func (s *GitHubSource) fetchEvents(ctx context.Context, cursor int64) ([]Item, int64, string, error) {
+	newest := cursor
+	etag := s.etag
+	items := make([]Item, 0)
+	for page := 1; page <= githubMaxPagesPerPoll; page++ {
+		url := fmt.Sprintf("%s/repos/%s/events?per_page=%d&page=%d", s.client.baseURL, s.repo, githubEventsPageSize, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, cursor, etag, err
+		}
+		if page == 1 && s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+
+		resp, err := s.client.do(req)
+		if err != nil {
+			return nil, cursor, etag, err
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return items, newest, etag, nil
+		}
+		if page == 1 {
+			etag = resp.Header.Get("ETag")
+		}
+
+		var events []models.GitHubEvent
+		err = json.NewDecoder(resp.Body).Decode(&events)
+		resp.Body.Close()
+		if err != nil {
+			return nil, cursor, etag, fmt.Errorf("decode events: %w", err)
+		}
+
+		reachedCursor := false
+		for _, event := range events {
+			createdUTC := event.CreatedAt.Unix()
+			if createdUTC <= cursor {
+				reachedCursor = true
+				continue
+			}
+			newest = max(newest, createdUTC)
+			if item, ok := s.eventItem(event); ok {
+				items = append(items, item)
+			}
+		}
+
+		if cursor <= 0 || reachedCursor || len(events) < githubEventsPageSize {
+			break
+		}
+	}
+
+	return items, newest, etag, nil
+}
+
+func (s *GitHubSource) fetchDiscussions(ctx context.Context, cursor int64) ([]Item, error) {
+	owner, name, _ := strings.Cut(s.repo, "/")
+	body, err := json.Marshal(models.GitHubGraphQLRequest{
+		Query: githubDiscussionsQuery,
+		Variables: map[string]any{
+			"owner": owner,
+			"name":  name,
+			"first": githubDiscussionsLimit,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.client.baseURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result models.GitHubDiscussionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode discussions: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("query discussions: %s", result.Errors[0].Message)
+	}
+
+	items := make([]Item, 0)
+	for _, discussion := range result.Data.Repository.Discussions.Nodes {
+		if discussion.CreatedAt.Unix() <= cursor {
+			continue
+		}
+		items = append(items, s.discussionItem(discussion))
+	}
+	return items, nil
+}
+
+// eventItem maps opened issues and pull requests and newly created comments on them.
+func (s *GitHubSource) eventItem(event models.GitHubEvent) (Item, bool) {
+	payload := event.Payload
+	switch event.Type {
+	case "IssuesEvent":
+		if payload.Action != "opened" || payload.Issue == nil {
+			return Item{}, false
+		}
+		return s.issueItem(*payload.Issue, nil, event.CreatedAt), true
+	case "PullRequestEvent":
+		if payload.Action != "opened" || payload.PullRequest == nil {
+			return Item{}, false
+		}
+		pr := *payload.PullRequest
+		pr.PullRequest = &struct{}{}
+		return s.issueItem(pr, nil, event.CreatedAt), true
+	case "IssueCommentEvent":
+		if payload.Action != "created" || payload.Issue == nil || payload.Comment == nil {
+			return Item{}, false
+		}
+		return s.issueItem(*payload.Issue, payload.Comment, event.CreatedAt), true
+	case "PullRequestReviewCommentEvent":
+		if payload.Action != "created" || payload.PullRequest == nil || payload.Comment == nil {
+			return Item{}, false
+		}
+		pr := *payload.PullRequest
+		pr.PullRequest = &struct{}{}
+		return s.issueItem(pr, payload.Comment, event.CreatedAt), true
+	default:
+		return Item{}, false
+	}
+}
+
+// issueItem builds an item for an issue or pull request, or for a comment on one when comment is set.
+func (s *GitHubSource) issueItem(issue models.GitHubIssue, comment *models.GitHubComment, createdAt time.Time) Item {
+	githubData := data.GitHubData{
+		Repo:      s.repo,
+		Number:    issue.Number,
+		Type:      githubTypeIssue,
+		Title:     issue.Title,
+		Body:      issue.Body,
+		Author:    issue.User.Login,
+		Labels:    githubLabelNames(issue.Labels),
+		State:     issue.State,
+		Permalink: issue.HTMLURL,
+	}
+	if issue.PullRequest != nil {
+		githubData.Type = githubTypePullRequest
+	}
+
+	item := Item{
+		Source:     enums.SourceGitHub,
+		Kind:       ItemKindPost,
+		ID:         issue.HTMLURL,
+		Title:      issue.Title,
+		Body:       issue.Body,
+		Author:     issue.User.Login,
+		Labels:     githubData.Labels,
+		Permalink:  issue.HTMLURL,
+		CreatedUTC: createdAt.Unix(),
+	}
+	if comment != nil {
+		githubData.Body = comment.Body
+		githubData.Author = comment.User.Login
+		githubData.Permalink = comment.HTMLURL
+		githubData.IsComment = true
+
+		item.Kind = ItemKindComment
+		item.ID = comment.HTMLURL
+		item.Title = ""
+		item.Body = comment.Body
+		item.Author = comment.User.Login
+		item.Permalink = comment.HTMLURL
+	}
+
+	item.MatchData = githubMatchData(githubData)
+	return item
+}
+
+func (s *GitHubSource) discussionItem(discussion models.GitHubDiscussion) Item {
+	author := ""
+	if discussion.Author != nil {
+		author = discussion.Author.Login
+	}
+	state := "open"
+	if discussion.Closed {
+		state = "closed"
+	}
+
+	githubData := data.GitHubData{
+		Repo:      s.repo,
+		Number:    discussion.Number,
+		Type:      githubTypeDiscussion,
+		Title:     discussion.Title,
+		Body:      discussion.Body,
+		Author:    author,
+		Labels:    githubLabelNames(discussion.Labels.Nodes),
+		State:     state,
+		Permalink: discussion.URL,
+	}
+
+	return Item{
+		Source:     enums.SourceGitHub,
+		Kind:       ItemKindPost,
+		ID:         discussion.URL,
+		Title:      discussion.Title,
+		Body:       discussion.Body,
+		Author:     author,
+		Labels:     githubData.Labels,
+		Permalink:  discussion.URL,
+		CreatedUTC: discussion.CreatedAt.Unix(),
+		MatchData:  githubMatchData(githubData),
+	}
+}
+
+func githubMatchData(githubData data.GitHubData) func(keyword string) any {
+	return func(keyword string) any {
+		payload := githubData
+		payload.Keyword = keyword
+		return payload
+	}
+}
+
+func githubLabelNames(labels []models.GitHubLabel) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
@@ -0,0 +1,231 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	hackerNewsSearchURL  = "https://hn.algolia.com/api/v1/search_by_date"
+	hackerNewsItemURL    = "https://news.ycombinator.com/item?id="
+	hackerNewsPageSize   = 100
+	hackerNewsMaxPages   = 5
+	hackerNewsTagStory   = "story"
+	hackerNewsTagComment = "comment"
+	hackerNewsSearchTags = "(story,comment)"
+)
+
+// HackerNewsSource fetches new stories and comments through the Algolia Hacker News search API.
+type HackerNewsSource struct {
+	client  *SourceClient
+	baseURL string
+
+	// seen holds the ids already returned at the second of the latest cursor. created_at_i only
+	// has a resolution of one second, so that second is read again on the next fetch.
+	seenAt int64
+	seen   []string
+
+	// Search results are newest first, so a walk cut short by hackerNewsMaxPages leaves the items
+	// between the cursor and the oldest item read unread. Until that gap is read, newest first
+	// down to gapEnd, the cursor stays put and the position to move it to is kept in resumeAt.
+	gapEnd     int64
+	gapSeen    []string
+	resumeAt   int64
+	resumeSeen []string
+}
+
+func NewHackerNewsSource(sourceMonitor *monitor.SourceMonitor) *HackerNewsSource {
+	return &HackerNewsSource{
+		client:  NewSourceClient(string(enums.SourceHackerNews), &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		baseURL: hackerNewsSearchURL,
+	}
+}
+
+func (s *HackerNewsSource) Name() string {
+	return string(enums.SourceHackerNews)
+}
+
+// Fetch returns the items created at or after cursor, leaving out the ones already returned at the
+// cursor's second. Without a cursor only the latest page is read.
+func (s *HackerNewsSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	if cursor != s.seenAt {
+		// The cursor was reloaded, so the items at its second rely on match deduplication.
+		s.seenAt, s.seen = cursor, nil
+		s.gapEnd, s.gapSeen = 0, nil
+	}
+
+	hits, truncated, err := s.walk(ctx, cursor, s.gapEnd)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	items := make([]Item, 0, len(hits))
+	inGap := s.gapEnd > 0
+	if !inGap {
+		s.resumeAt, s.resumeSeen = cursor, slices.Clone(s.seen)
+	}
+	for _, hit := range hits {
+		switch {
+		case hit.CreatedAtI < cursor,
+			hit.CreatedAtI == cursor && slices.Contains(s.seen, hit.ObjectID),
+			inGap && hit.CreatedAtI == s.gapEnd && slices.Contains(s.gapSeen, hit.ObjectID):
+			continue
+		}
+		item, ok := hackerNewsItem(hit)
+		if !ok {
+			continue
+		}
+		items = append(items, item)
+		if !inGap {
+			trackSeen(&s.resumeAt, &s.resumeSeen, hit.CreatedAtI, hit.ObjectID)
+		}
+	}
+
+	if truncated {
+		// Hits are newest first, so the last one is the oldest read.
+		oldest := hits[len(hits)-1].CreatedAtI
+		if oldest != s.gapEnd {
+			s.gapSeen = nil
+		}
+		s.gapEnd = oldest
+		for _, hit := range hits {
+			if hit.CreatedAtI == oldest {
+				s.gapSeen = append(s.gapSeen, hit.ObjectID)
+			}
+		}
+		return items, cursor, nil
+	}
+
+	s.gapEnd, s.gapSeen = 0, nil
+	s.seenAt, s.seen = s.resumeAt, s.resumeSeen
+	return items, s.seenAt, nil
+}
+
+// walk reads the hits created from cursor up to until, newest first, and reports whether pages were
+// left unread. until is 0 for no upper bound.
+func (s *HackerNewsSource) walk(ctx context.Context, cursor, until int64) ([]models.HackerNewsHit, bool, error) {
+	hits := make([]models.HackerNewsHit, 0, hackerNewsPageSize)
+	for page := 0; page < hackerNewsMaxPages; page++ {
+		var resp models.HackerNewsSearchResponse
+		if err := s.search(ctx, cursor, until, page, &resp); err != nil {
+			return nil, false, err
+		}
+		hits = append(hits, resp.Hits...)
+
+		// Without a cursor only the latest page is read.
+		if cursor <= 0 || page+1 >= resp.NbPages || len(resp.Hits) == 0 {
+			return hits, false, nil
+		}
+	}
+	return hits, true, nil
+}
+
+func (s *HackerNewsSource) search(ctx context.Context, cursor, until int64, page int, dest *models.HackerNewsSearchResponse) error {
+	query := neturl.Values{}
+	query.Set("tags", hackerNewsSearchTags)
+	query.Set("hitsPerPage", strconv.Itoa(hackerNewsPageSize))
+	query.Set("page", strconv.Itoa(page))
+	if cursor > 0 {
+		filters := fmt.Sprintf("created_at_i>=%d", cursor)
+		if until > 0 {
+			filters += fmt.Sprintf(",created_at_i<=%d", until)
+		}
+		query.Set("numericFilters", filters)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "feedgrep")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// trackSeen moves the position at/seen forward to an item, keeping the ids read at its second.
+func trackSeen(at *int64, seen *[]string, created int64, id string) {
+	if created > *at {
+		*at, *seen = created, nil
+	}
+	if created == *at {
+		*seen = append(*seen, id)
+	}
+}
+
+func hackerNewsItem(hit models.HackerNewsHit) (Item, bool) {
+	itemID, err := strconv.ParseInt(hit.ObjectID, 10, 64)
+	if err != nil || itemID <= 0 {
+		return Item{}, false
+	}
+
+	isStory := slices.Contains(hit.Tags, hackerNewsTagStory)
+	isComment := slices.Contains(hit.Tags, hackerNewsTagComment)
+	if !isStory && !isComment {
+		return Item{}, false
+	}
+
+	hnData := data.HackerNewsData{
+		ItemID:     itemID,
+		StoryID:    itemID,
+		StoryTitle: hit.Title,
+		Author:     hit.Author,
+		Title:      hit.Title,
+		Body:       stripHTML(hit.StoryText),
+		URL:        hit.URL,
+		Permalink:  hackerNewsItemURL + hit.ObjectID,
+	}
+	if hit.Points != nil {
+		hnData.Points = *hit.Points
+	}
+
+	kind := ItemKindPost
+	if isComment {
+		kind = ItemKindComment
+		hnData.IsComment = true
+		hnData.Title = ""
+		hnData.Body = stripHTML(hit.CommentText)
+		hnData.StoryTitle = hit.StoryTitle
+		hnData.URL = hit.StoryURL
+		hnData.StoryID = 0
+		if hit.StoryID != nil {
+			hnData.StoryID = *hit.StoryID
+		}
+	}
+
+	return Item{
+		Source:     enums.SourceHackerNews,
+		Kind:       kind,
+		ID:         hit.ObjectID,
+		Title:      hnData.Title,
+		Body:       hnData.Body,
+		Author:     hit.Author,
+		Permalink:  hnData.Permalink,
+		CreatedUTC: hit.CreatedAtI,
+		MatchData: func(keyword string) any {
+			payload := hnData
+			payload.Keyword = keyword
+			return payload
+		},
+	}, true
+}
@@ -0,0 +1,141 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHackerNewsSearch serves hits like search_by_date: newest first, filtered by created_at_i
+// and paged.
+func fakeHackerNewsSearch(t *testing.T, hits *[]models.HackerNewsHit) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matching := make([]models.HackerNewsHit, 0)
+		for _, hit := range *hits {
+			if hackerNewsFiltersMatch(t, r.URL.Query().Get("numericFilters"), hit.CreatedAtI) {
+				matching = append(matching, hit)
+			}
+		}
+		sort.SliceStable(matching, func(i, j int) bool { return matching[i].CreatedAtI > matching[j].CreatedAtI })
+
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("hitsPerPage"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		resp := models.HackerNewsSearchResponse{Page: page, NbPages: (len(matching) + pageSize - 1) / pageSize}
+		if start := page * pageSize; start < len(matching) {
+			resp.Hits = matching[start:min(start+pageSize, len(matching))]
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func hackerNewsFiltersMatch(t *testing.T, filters string, created int64) bool {
+	if filters == "" {
+		return true
+	}
+	for _, filter := range strings.Split(filters, ",") {
+		var value int64
+		switch {
+		case strings.HasPrefix(filter, "created_at_i>="):
+			_, err := fmt.Sscanf(filter, "created_at_i>=%d", &value)
+			require.NoError(t, err)
+			if created < value {
+				return false
+			}
+		case strings.HasPrefix(filter, "created_at_i<="):
+			_, err := fmt.Sscanf(filter, "created_at_i<=%d", &value)
+			require.NoError(t, err)
+			if created > value {
+				return false
+			}
+		default:
+			t.Fatalf("unexpected filter %q", filter)
+		}
+	}
+	return true
+}
+
+func hackerNewsStory(id int, created int64) models.HackerNewsHit {
+	return models.HackerNewsHit{ObjectID: strconv.Itoa(id), CreatedAtI: created, Title: "story", Tags: []string{hackerNewsTagStory}}
+}
+
+func TestHackerNewsSourceFetch(t *testing.T) {
+	ids := func(items []Item) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		sort.Strings(out)
+		return out
+	}
+	newSource := func(t *testing.T, hits *[]models.HackerNewsHit) *HackerNewsSource {
+		src := NewHackerNewsSource(monitor.NewSourceMonitor())
+		src.baseURL = fakeHackerNewsSearch(t, hits).URL
+		return src
+	}
+
+	t.Run("it reads the cursor's second again and skips the items already returned", func(t *testing.T) {
+		hits := []models.HackerNewsHit{hackerNewsStory(1, 100), hackerNewsStory(2, 101)}
+		src := newSource(t, &hits)
+
+		items, cursor, err := src.Fetch(context.Background(), 100)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, ids(items))
+		assert.Equal(t, int64(101), cursor)
+
+		hits = append(hits, hackerNewsStory(3, 101), hackerNewsStory(4, 102))
+		items, cursor, err = src.Fetch(context.Background(), cursor)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"3", "4"}, ids(items))
+		assert.Equal(t, int64(102), cursor)
+	})
+
+	t.Run("it reads the rest of a truncated walk before moving the cursor", func(t *testing.T) {
+		limit := hackerNewsPageSize * hackerNewsMaxPages
+		hits := make([]models.HackerNewsHit, 0, limit+50)
+		for i := range limit + 50 {
+			hits = append(hits, hackerNewsStory(i+1, int64(1000+i)))
+		}
+		src := newSource(t, &hits)
+
+		first, cursor, err := src.Fetch(context.Background(), 1000)
+		require.NoError(t, err)
+		assert.Len(t, first, limit)
+		assert.Equal(t, int64(1000), cursor)
+
+		second, cursor, err := src.Fetch(context.Background(), cursor)
+		require.NoError(t, err)
+		assert.Len(t, second, 50)
+		assert.Equal(t, int64(1000+limit+49), cursor)
+
+		all := append(ids(first), ids(second)...)
+		sort.Strings(all)
+		assert.Equal(t, ids(hitsToItems(hits)), all)
+
+		third, _, err := src.Fetch(context.Background(), cursor)
+		require.NoError(t, err)
+		assert.Empty(t, third)
+	})
+}
+
+func hitsToItems(hits []models.HackerNewsHit) []Item {
+	items := make([]Item, 0, len(hits))
+	for _, hit := range hits {
+		item, _ := hackerNewsItem(hit)
+		items = append(items, item)
+	}
+	return items
+}
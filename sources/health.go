@@ -0,0 +1,108 @@
+package sources
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/models"
+)
+
+const (
+	LoopStatePending  = "pending"
+	LoopStateHealthy  = "healthy"
+	LoopStateDegraded = "degraded"
+	LoopStateFailing  = "failing"
+
+	// loopFailingAfter is the number of consecutive failures after which a loop is reported as failing.
+	loopFailingAfter = 3
+)
+
+// loopHealth records the outcome of every run of a polling loop so it can be inspected at runtime.
+type loopHealth struct {
+	mu                  sync.RWMutex
+	name                string
+	interval            time.Duration
+	cursor              int64
+	lastSuccess         time.Time
+	lastError           time.Time
+	lastErrorMessage    string
+	consecutiveFailures int
+	nextPoll            time.Time
+}
+
+func newLoopHealth(name string, interval time.Duration) *loopHealth {
+	return &loopHealth{
+		name:     name,
+		interval: interval,
+	}
+}
+
+func (h *loopHealth) success(cursor int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cursor = cursor
+	h.lastSuccess = time.Now()
+	h.consecutiveFailures = 0
+}
+
+// failure records the error and returns the number of consecutive failures including this one.
+func (h *loopHealth) failure(err error) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastError = time.Now()
+	h.lastErrorMessage = truncateError(err).Error()
+	h.consecutiveFailures++
+	return h.consecutiveFailures
+}
+
+func (h *loopHealth) scheduled(next time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextPoll = next
+}
+
+func (h *loopHealth) Status() models.PollLoopStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := models.PollLoopStatus{
+		Name:                h.name,
+		IntervalMs:          h.interval.Milliseconds(),
+		Cursor:              h.cursor,
+		LastSuccessAt:       optionalTime(h.lastSuccess),
+		LastErrorAt:         optionalTime(h.lastError),
+		LastError:           h.lastErrorMessage,
+		ConsecutiveFailures: h.consecutiveFailures,
+		NextPollAt:          optionalTime(h.nextPoll),
+	}
+	switch {
+	case h.consecutiveFailures >= loopFailingAfter:
+		status.State = LoopStateFailing
+	case h.consecutiveFailures > 0:
+		status.State = LoopStateDegraded
+	case h.lastSuccess.IsZero():
+		status.State = LoopStatePending
+	default:
+		status.State = LoopStateHealthy
+	}
+	return status
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// jitteredBackoff doubles minDelay for every consecutive failure up to maxDelay, then picks a
+// random delay between half and all of it so loops that failed together do not retry in lockstep.
+func jitteredBackoff(failures int, minDelay, maxDelay time.Duration) time.Duration {
+	delay := maxDelay
+	if shift := failures - 1; shift < 30 && minDelay<<shift < maxDelay {
+		delay = minDelay << shift
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,35 @@
+package sources
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// stripHTML converts an HTML fragment into plain text, keeping paragraph and line breaks.
+func stripHTML(fragment string) string {
+	if !strings.ContainsAny(fragment, "<&") {
+		return strings.TrimSpace(fragment)
+	}
+
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(builder.String())
+		case html.TextToken:
+			builder.Write(tokenizer.Text())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "p":
+				if builder.Len() > 0 {
+					builder.WriteString("\n\n")
+				}
+			case "br":
+				builder.WriteString("\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	sourceClientMaxAttempts      = 3
+	sourceClientMinBackoff       = 500 * time.Millisecond
+	sourceClientMaxBackoff       = 10 * time.Second
+	sourceClientMaxRetryAfter    = 30 * time.Second
+	sourceClientFailureThreshold = 5
+	sourceClientOpenDuration     = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without making a request while the upstream is considered down.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// SourceClient wraps an http.Client for upstream source APIs. Failed requests are retried with
+// exponential backoff, 429 and 503 responses honor Retry-After, and a circuit breaker stops
+// calling an upstream that keeps failing until it has had time to recover.
+type SourceClient struct {
+	name    string
+	client  *http.Client
+	sm      *monitor.SourceMonitor
+	breaker *circuitBreaker
+
+	maxAttempts   int
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	maxRetryAfter time.Duration
+	sleep         func(ctx context.Context, d time.Duration) error
+}
+
+// NewSourceClient wraps client, which sets the timeout and transport used for the requests.
+func NewSourceClient(name string, client *http.Client, sourceMonitor *monitor.SourceMonitor) *SourceClient {
+	c := &SourceClient{
+		name:          name,
+		client:        client,
+		sm:            sourceMonitor,
+		maxAttempts:   sourceClientMaxAttempts,
+		minBackoff:    sourceClientMinBackoff,
+		maxBackoff:    sourceClientMaxBackoff,
+		maxRetryAfter: sourceClientMaxRetryAfter,
+		sleep:         sleepContext,
+	}
+	c.breaker = newCircuitBreaker(sourceClientFailureThreshold, sourceClientOpenDuration, func(state string) {
+		sourceMonitor.BreakerState(name, state)
+	})
+	return c
+}
+
+// Do sends the request, retrying network errors, 408, 429 and 5xx responses. When every attempt
+// fails the last response is returned to the caller so it can report the status as usual.
+func (c *SourceClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.client.Do(requestAttempt(req, attempt))
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			c.breaker.success()
+			return resp, nil
+		}
+		if req.Context().Err() != nil || errors.Is(err, ErrPrivateAddress) {
+			// Neither a cancelled request nor a refused address says anything about the upstream.
+			c.breaker.cancel()
+			return resp, err
+		}
+
+		delay := jitteredBackoff(attempt, c.minBackoff, c.maxBackoff)
+		retryAfter, hasRetryAfter := time.Duration(0), false
+		if err == nil {
+			retryAfter, hasRetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		if hasRetryAfter {
+			delay = retryAfter
+		}
+
+		if attempt >= c.maxAttempts || delay > c.maxRetryAfter {
+			if hasRetryAfter {
+				c.breaker.holdOpen(time.Now().Add(retryAfter))
+			} else {
+				c.breaker.failure()
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			drainAndClose(resp)
+		}
+		c.sm.RequestRetry(c.name)
+		if err := c.sleep(req.Context(), delay); err != nil {
+			c.breaker.cancel()
+			return nil, err
+		}
+	}
+}
+
+// requestAttempt returns the request to send on the given attempt, rewinding the body for retries.
+func requestAttempt(req *http.Request, attempt int) *http.Request {
+	if attempt == 1 || req.GetBody == nil {
+		return req
+	}
+	retry := req.Clone(req.Context())
+	if body, err := req.GetBody(); err == nil {
+		retry.Body = body
+	}
+	return retry
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// parseRetryAfter reads a Retry-After value given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func drainAndClose(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateHalfOpen = "half_open"
+	BreakerStateOpen     = "open"
+)
+
+// circuitBreaker opens after threshold consecutive failures. Once the open period has passed a
+// single probe request is let through; its outcome closes the breaker or opens it again.
+type circuitBreaker struct {
+	mu            sync.Mutex
+	threshold     int
+	openDuration  time.Duration
+	onStateChange func(state string)
+
+	state     string
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration, onStateChange func(state string)) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold:     threshold,
+		openDuration:  openDuration,
+		onStateChange: onStateChange,
+		state:         BreakerStateClosed,
+	}
+	onStateChange(b.state)
+	return b
+}
+
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerStateOpen:
+		if time.Now().Before(b.openUntil) {
+			return fmt.Errorf("%w until %s", ErrCircuitOpen, b.openUntil.UTC().Format(time.RFC3339))
+		}
+		b.setState(BreakerStateHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerStateHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w: probe in flight", ErrCircuitOpen)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.setState(BreakerStateClosed)
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerStateHalfOpen || b.failures >= b.threshold {
+		b.open(time.Now().Add(b.openDuration))
+	}
+}
+
+// cancel gives up a probe that was abandoned without an outcome, so the next request can probe again.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// holdOpen opens the breaker until the upstream asked to be called again.
+func (b *circuitBreaker) holdOpen(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.open(until)
+}
+
+func (b *circuitBreaker) open(until time.Time) {
+	b.probing = false
+	b.openUntil = until
+	b.setState(BreakerStateOpen)
+}
+
+func (b *circuitBreaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	b.onStateChange(state)
+}
+
+func (b *circuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
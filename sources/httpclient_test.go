@@ -0,0 +1,212 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSourceClient returns a client that records its backoff delays instead of sleeping.
+func newTestSourceClient() (*SourceClient, *[]time.Duration) {
+	client := NewSourceClient("test", &http.Client{Timeout: 5 * time.Second}, monitor.NewSourceMonitor())
+	var mu sync.Mutex
+	delays := make([]time.Duration, 0)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return client, &delays
+}
+
+func getStatus(t *testing.T, client *SourceClient, url string) (int, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// statusSequence serves the given statuses in order and repeats the last one.
+func statusSequence(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1)) - 1
+		status := statuses[min(call, len(statuses)-1)]
+		for key, values := range headers {
+			if status != http.StatusOK {
+				w.Header()[key] = values
+			}
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestSourceClient(t *testing.T) {
+	t.Run("it retries server errors with growing backoff", func(t *testing.T) {
+		server, calls := statusSequence(t, nil, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+		client, delays := newTestSourceClient()
+
+		status, err := getStatus(t, client, server.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int32(3), calls.Load())
+		require.Len(t, *delays, 2)
+		assert.GreaterOrEqual(t, (*delays)[0], sourceClientMinBackoff/2)
+		assert.LessOrEqual(t, (*delays)[0], sourceClientMinBackoff)
+		assert.GreaterOrEqual(t, (*delays)[1], sourceClientMinBackoff)
+		assert.LessOrEqual(t, (*delays)[1], 2*sourceClientMinBackoff)
+	})
+
+	t.Run("it does not retry client errors", func(t *testing.T) {
+		server, calls := statusSequence(t, nil, http.StatusNotFound)
+		client, delays := newTestSourceClient()
+
+		status, err := getStatus(t, client, server.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Empty(t, *delays)
+		assert.Equal(t, BreakerStateClosed, client.breaker.State())
+	})
+
+	t.Run("it does not retry addresses the dialer refuses", func(t *testing.T) {
+		server, calls := statusSequence(t, nil, http.StatusOK)
+		client, delays := newTestSourceClient()
+		client.client = NewPublicHTTPClient(time.Second)
+
+		_, err := getStatus(t, client, server.URL)
+
+		assert.ErrorIs(t, err, ErrPrivateAddress)
+		assert.Equal(t, int32(0), calls.Load())
+		assert.Empty(t, *delays)
+		assert.Equal(t, BreakerStateClosed, client.breaker.State())
+	})
+
+	t.Run("it waits for retry-after on 429", func(t *testing.T) {
+		server, calls := statusSequence(t, http.Header{"Retry-After": {"7"}}, http.StatusTooManyRequests, http.StatusOK)
+		client, delays := newTestSourceClient()
+
+		status, err := getStatus(t, client, server.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, []time.Duration{7 * time.Second}, *delays)
+	})
+
+	t.Run("it opens the breaker when retry-after exceeds the retry limit", func(t *testing.T) {
+		server, calls := statusSequence(t, http.Header{"Retry-After": {"3600"}}, http.StatusTooManyRequests)
+		client, delays := newTestSourceClient()
+
+		status, err := getStatus(t, client, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.Empty(t, *delays)
+
+		_, err = getStatus(t, client, server.URL)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("it trips the breaker after repeated failures and fails fast", func(t *testing.T) {
+		server, calls := statusSequence(t, nil, http.StatusInternalServerError)
+		client, _ := newTestSourceClient()
+
+		for range sourceClientFailureThreshold {
+			status, err := getStatus(t, client, server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusInternalServerError, status)
+		}
+		assert.Equal(t, BreakerStateOpen, client.breaker.State())
+		requests := calls.Load()
+		assert.Equal(t, int32(sourceClientFailureThreshold*sourceClientMaxAttempts), requests)
+
+		_, err := getStatus(t, client, server.URL)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, requests, calls.Load())
+	})
+
+	t.Run("it closes the breaker after a successful probe", func(t *testing.T) {
+		var healthy atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if healthy.Load() {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(server.Close)
+		client, _ := newTestSourceClient()
+		client.breaker.openDuration = 10 * time.Millisecond
+
+		for range sourceClientFailureThreshold {
+			_, err := getStatus(t, client, server.URL)
+			require.NoError(t, err)
+		}
+		require.Equal(t, BreakerStateOpen, client.breaker.State())
+
+		healthy.Store(true)
+		time.Sleep(20 * time.Millisecond)
+		status, err := getStatus(t, client, server.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, BreakerStateClosed, client.breaker.State())
+	})
+
+	t.Run("it reopens the breaker when the probe fails", func(t *testing.T) {
+		server, _ := statusSequence(t, nil, http.StatusInternalServerError)
+		client, _ := newTestSourceClient()
+		client.breaker.openDuration = 10 * time.Millisecond
+
+		for range sourceClientFailureThreshold {
+			_, err := getStatus(t, client, server.URL)
+			require.NoError(t, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, err := getStatus(t, client, server.URL)
+		require.NoError(t, err)
+
+		assert.Equal(t, BreakerStateOpen, client.breaker.State())
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("it parses seconds", func(t *testing.T) {
+		delay, ok := parseRetryAfter("120", now)
+		assert.True(t, ok)
+		assert.Equal(t, 2*time.Minute, delay)
+	})
+
+	t.Run("it parses http dates", func(t *testing.T) {
+		delay, ok := parseRetryAfter("Tue, 10 Jun 2025 12:00:30 GMT", now)
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, delay)
+	})
+
+	t.Run("it ignores invalid values", func(t *testing.T) {
+		_, ok := parseRetryAfter("soon", now)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,243 @@
+package sources
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/matchers"
+)
+
+// subscriptionIndex selects the subscriptions an item can match in a single pass over its text,
+// instead of checking every keyword against every item. Subscriptions reducible to phrases are
+// selected when one of their phrases occurs; the rest are evaluated for every item.
+type subscriptionIndex struct {
+	patterns *matchers.PatternIndex
+	// targets lists the subscriptions selected by each pattern.
+	targets [][]int
+	// always lists the subscriptions that cannot be reduced to phrases.
+	always []int
+}
+
+func newSubscriptionIndex(subscriptions []keywordSubscription) *subscriptionIndex {
+	idx := &subscriptionIndex{}
+	patternIDs := make(map[string]int)
+	patterns := make([]string, 0, len(subscriptions))
+
+	for i := range subscriptions {
+		phrases := subscriptions[i].prefilterPhrases()
+		if len(phrases) == 0 {
+			idx.always = append(idx.always, i)
+			continue
+		}
+		for _, phrase := range phrases {
+			phrase = strings.ToLower(strings.TrimSpace(phrase))
+			id, ok := patternIDs[phrase]
+			if !ok {
+				id = len(patterns)
+				patternIDs[phrase] = id
+				patterns = append(patterns, phrase)
+				idx.targets = append(idx.targets, nil)
+			}
+			targets := idx.targets[id]
+			if len(targets) == 0 || targets[len(targets)-1] != i {
+				idx.targets[id] = append(targets, i)
+			}
+		}
+	}
+
+	idx.patterns = matchers.NewPatternIndex(patterns)
+	return idx
+}
+
+// candidates returns the positions of the subscriptions worth evaluating against the item, in
+// subscription order.
+func (idx *subscriptionIndex) candidates(item Item) []int {
+	text := strings.ToLower(strings.TrimSpace(item.Title) + "\n" + strings.TrimSpace(item.Body))
+
+	selected := make(map[int]struct{})
+	idx.patterns.Scan(text, func(pattern, end int) {
+		for _, sub := range idx.targets[pattern] {
+			selected[sub] = struct{}{}
+		}
+	})
+	if len(selected) == 0 {
+		return idx.always
+	}
+
+	candidates := make([]int, 0, len(idx.always)+len(selected))
+	candidates = append(candidates, idx.always...)
+	for sub := range selected {
+		candidates = append(candidates, sub)
+	}
+	sort.Ints(candidates)
+	return candidates
+}
+
+// prefilterPhrases returns phrases of which every matching item contains at least one in its
+// title or body, so items can be narrowed down before the subscription is evaluated. It returns
+// nil when the subscription cannot be reduced to phrases.
+func (s *keywordSubscription) prefilterPhrases() []string {
+	switch s.matchMode {
+	case enums.MatchModeExact, enums.MatchModeBroad:
+		return []string{s.keyword}
+	case enums.MatchModeSmart:
+		if s.filters.Smart == nil {
+			return nil
+		}
+		candidate := s.filters.Smart.Candidate
+		if !searchesTitleAndBody(candidate.Where) {
+			return nil
+		}
+		phrases, ok := conditionPhrases(candidate.Condition)
+		if !ok {
+			return nil
+		}
+		return phrases
+	case enums.MatchModeQuery:
+		if s.query == nil {
+			return nil
+		}
+		phrases, ok := queryPhrases(s.query.Root)
+		if !ok {
+			return nil
+		}
+		return phrases
+	default:
+		return nil
+	}
+}
+
+func searchesTitleAndBody(where []string) bool {
+	for _, field := range where {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "title", "body":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// conditionPhrases mirrors the evaluation order of matchers.EvaluateSmart: a condition is decided
+// by its first non-empty branch. Conditions that can match without one of the phrases, such as
+// regexes and negations, are reported as not reducible.
+func conditionPhrases(condition data.SmartCondition) ([]string, bool) {
+	var children []data.SmartCondition
+	switch {
+	case len(condition.Any) > 0:
+		children = condition.Any
+	case len(condition.All) > 0:
+		// Every child must match, so children that are not reducible can be left out as long as
+		// one of them is.
+		var phrases []string
+		found := false
+		for _, child := range condition.All {
+			if childPhrases, ok := conditionPhrases(child); ok {
+				phrases = append(phrases, childPhrases...)
+				found = true
+			}
+		}
+		return phrases, found
+	case len(condition.None) > 0:
+		return nil, false
+	case condition.Near != nil:
+		// Every match contains every term, so the longest word of any term will do.
+		longest := ""
+		for _, term := range condition.Near.Terms {
+			for _, word := range strings.FieldsFunc(term, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+			}) {
+				if len(word) > len(longest) {
+					longest = word
+				}
+			}
+		}
+		return []string{longest}, longest != ""
+	case condition.CountAtLeast != nil:
+		// A match contains at least one of the phrases.
+		return conditionPhrases(data.SmartCondition{AnyPhrase: condition.CountAtLeast.Phrases})
+	case len(condition.Regex) > 0:
+		return nil, false
+	case len(condition.AnyPhrase) > 0:
+		phrases := make([]string, 0, len(condition.AnyPhrase))
+		for _, phrase := range condition.AnyPhrase {
+			phrase = strings.TrimSpace(phrase)
+			if phrase == "" {
+				return nil, false
+			}
+			phrases = append(phrases, phrase)
+		}
+		return phrases, true
+	default:
+		return nil, false
+	}
+
+	var phrases []string
+	for _, child := range children {
+		childPhrases, ok := conditionPhrases(child)
+		if !ok {
+			return nil, false
+		}
+		phrases = append(phrases, childPhrases...)
+	}
+	return phrases, true
+}
+
+// queryPhrases returns phrases of which every item matching the query node contains one. All
+// children of an AND must match, so the child with the fewest phrases is enough; every child of
+// an OR must be reducible. Quoted phrases are reduced to their longest word. Negations, subreddit
+// terms and wildcards without a literal part are not reducible.
+func queryPhrases(node *matchers.QueryNode) ([]string, bool) {
+	switch node.Op {
+	case matchers.QueryAnd:
+		var best []string
+		found := false
+		for _, child := range node.Children {
+			phrases, ok := queryPhrases(child)
+			if ok && (!found || len(phrases) < len(best)) {
+				best, found = phrases, true
+			}
+		}
+		return best, found
+	case matchers.QueryOr:
+		var phrases []string
+		for _, child := range node.Children {
+			childPhrases, ok := queryPhrases(child)
+			if !ok {
+				return nil, false
+			}
+			phrases = append(phrases, childPhrases...)
+		}
+		return phrases, true
+	case matchers.QueryTerm:
+		if node.Field == matchers.QueryFieldSubreddit {
+			return nil, false
+		}
+		if node.Phrase {
+			// A phrase matches across any whitespace, so only its words are certain to occur.
+			longest := ""
+			for _, word := range strings.Fields(node.Value) {
+				if len(word) > len(longest) {
+					longest = word
+				}
+			}
+			return []string{longest}, true
+		}
+		if !node.Wildcard {
+			return []string{node.Value}, true
+		}
+		// Any literal part of a wildcard term occurs in a match; the longest is the most selective.
+		longest := ""
+		for _, part := range strings.Split(node.Value, "*") {
+			if len(part) > len(longest) {
+				longest = part
+			}
+		}
+		return []string{longest}, longest != ""
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionIndex(t *testing.T) {
+	userID := uuid.New()
+	subscriptions := []keywordSubscription{
+		testSubscription(t, 1, userID, "postgres", enums.MatchModeBroad, data.KeywordFilters{}),
+		testSubscription(t, 2, userID, "crm", enums.MatchModeExact, data.KeywordFilters{}),
+		testSubscription(t, 3, userID, "helpdesk", enums.MatchModeSmart, data.KeywordFilters{Smart: &data.SmartFilter{
+			Candidate: data.SmartRule{Condition: data.SmartCondition{AnyPhrase: []string{"Help Desk", "crm"}}},
+		}}),
+		testSubscription(t, 4, userID, "regex", enums.MatchModeSmart, data.KeywordFilters{Smart: &data.SmartFilter{
+			Candidate: data.SmartRule{Condition: data.SmartCondition{Regex: []string{`go(lang)?`}}},
+		}}),
+	}
+	idx := newSubscriptionIndex(subscriptions)
+
+	t.Run("it selects the subscriptions whose phrases occur in the item", func(t *testing.T) {
+		item := Item{Title: "Which CRM?", Body: "We run everything on Postgres."}
+
+		assert.Equal(t, []int{0, 1, 2, 3}, idx.candidates(item))
+	})
+
+	t.Run("it matches smart phrases regardless of case", func(t *testing.T) {
+		item := Item{Title: "Moving our HELP DESK"}
+
+		assert.Equal(t, []int{2, 3}, idx.candidates(item))
+	})
+
+	t.Run("it always selects subscriptions that cannot be reduced to phrases", func(t *testing.T) {
+		item := Item{Title: "Nothing relevant here"}
+
+		assert.Equal(t, []int{3}, idx.candidates(item))
+	})
+
+	t.Run("it matches the same items as evaluating every subscription", func(t *testing.T) {
+		pipeline := NewPipeline(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, nil, nil, nil)
+		pipeline.subscriptions = subscriptions
+		pipeline.index = idx
+
+		items := []Item{
+			{Source: enums.SourceHackerNews, ID: "1", Title: "Which CRM?", Body: "We run everything on Postgres.", Permalink: "a"},
+			{Source: enums.SourceHackerNews, ID: "2", Title: "crmsystem", Body: "golang help desk", Permalink: "b"},
+			{Source: enums.SourceHackerNews, ID: "3", Title: "Nothing relevant here", Permalink: "c"},
+		}
+
+		var naive MatchSet
+		for _, item := range items {
+			for _, sub := range subscriptions {
+				pipeline.matchItem(&naive, item, pipeline.detector.Lazy(itemText(item)), sub, nil)
+			}
+		}
+
+		assert.Equal(t, naive.Matches, pipeline.Match(items, nil).Matches)
+	})
+
+	t.Run("it selects query phrases split across lines", func(t *testing.T) {
+		phrases := newSubscriptionIndex([]keywordSubscription{
+			testSubscription(t, 5, userID, `"help desk" OR crm`, enums.MatchModeQuery, data.KeywordFilters{}),
+		})
+		item := Item{Body: "Looking for a help\ndesk tool"}
+
+		assert.Equal(t, []int{0}, phrases.candidates(item))
+	})
+}
+
+func BenchmarkPipelineMatch(b *testing.B) {
+	rng := rand.New(rand.NewSource(7))
+	words := strings.Fields(benchmarkItemText)
+	items := make([]Item, 50)
+	for i := range items {
+		rng.Shuffle(len(words), func(i, j int) { words[i], words[j] = words[j], words[i] })
+		items[i] = Item{Source: enums.SourceHackerNews, ID: fmt.Sprint(i), Body: strings.Join(words, " ")}
+	}
+
+	for _, n := range []int{1000, 10000, 100000} {
+		subscriptions := make([]keywordSubscription, n)
+		for i := range subscriptions {
+			subscriptions[i] = testSubscription(b, i, uuid.New(), benchmarkKeyword(rng), enums.MatchModeBroad, data.KeywordFilters{})
+		}
+		pipeline := NewPipeline(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, nil, nil, nil)
+		pipeline.subscriptions = subscriptions
+		pipeline.index = newSubscriptionIndex(subscriptions)
+
+		b.Run(fmt.Sprintf("keywords=%d/index", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pipeline.Match(items, nil)
+			}
+		})
+		b.Run(fmt.Sprintf("keywords=%d/scan", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var set MatchSet
+				for _, item := range items {
+					for _, sub := range subscriptions {
+						pipeline.matchItem(&set, item, pipeline.detector.Lazy(itemText(item)), sub, nil)
+					}
+				}
+			}
+		})
+	}
+}
+
+func testSubscription(tb testing.TB, id int, userID uuid.UUID, keyword string, matchMode enums.MatchMode, filters data.KeywordFilters) keywordSubscription {
+	tb.Helper()
+	sub, err := newKeywordSubscription(id, userID, keyword, matchMode, filters)
+	require.NoError(tb, err)
+	return sub
+}
+
+const benchmarkItemText = "looking for recommendations on a lightweight crm that integrates with our helpdesk " +
+	"we are a small team of five and currently track everything in spreadsheets which is getting painful " +
+	"ideally it has a decent api email sync and does not cost a fortune per seat any experiences welcome"
+
+func benchmarkKeyword(rng *rand.Rand) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	keyword := make([]byte, 5+rng.Intn(6))
+	for i := range keyword {
+		keyword[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(keyword)
+}
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+)
+
+// IngestedItem converts an item pushed through the ingest API into a pipeline item. Pushed items are
+// private to the user who sent them, so only that user's subscriptions are matched.
+func IngestedItem(owner uuid.UUID, in models.IngestItem) Item {
+	permalink := in.URL
+	if permalink == "" {
+		// The permalink is part of the match hash, so items without a URL need a unique stand-in.
+		permalink = "ingest://" + in.Source + "/" + in.ID
+	}
+	createdUTC := time.Now().Unix()
+	if in.CreatedAt != nil {
+		createdUTC = in.CreatedAt.Unix()
+	}
+
+	ingestData := data.IngestData{
+		Source:    in.Source,
+		ItemID:    in.ID,
+		Title:     in.Title,
+		Body:      in.Body,
+		Author:    in.Author,
+		Permalink: in.URL,
+		Metadata:  in.Metadata,
+	}
+
+	return Item{
+		Source:     enums.SourceIngest,
+		Kind:       ItemKindPost,
+		ID:         in.Source + "/" + in.ID,
+		Title:      in.Title,
+		Body:       in.Body,
+		Author:     in.Author,
+		Permalink:  permalink,
+		CreatedUTC: createdUTC,
+		Owner:      owner,
+		MatchData: func(keyword string) any {
+			payload := ingestData
+			payload.Keyword = keyword
+			return payload
+		},
+	}
+}
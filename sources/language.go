@@ -0,0 +1,8 @@
+package sources
+
+import "strings"
+
+// itemText is the text keywords and language filters are matched against.
+func itemText(item Item) string {
+	return strings.TrimSpace(strings.TrimSpace(item.Title) + "\n" + strings.TrimSpace(item.Body))
+}
@@ -0,0 +1,47 @@
+package sources
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemLanguage(t *testing.T) {
+	userID := uuid.New()
+	pipeline := NewPipeline(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, nil, nil, nil, nil)
+	item := Item{Source: enums.SourceHackerNews, ID: "1", Title: "Which CRM do you use?", Body: "We are a small team looking for a simple CRM.", Permalink: "a"}
+
+	t.Run("it does not detect the language when no subscription filters on it", func(t *testing.T) {
+		language := pipeline.detector.Lazy(itemText(item))
+		var set MatchSet
+
+		pipeline.matchItem(&set, item, language, testSubscription(t, 1, userID, "crm", enums.MatchModeBroad, data.KeywordFilters{}), nil)
+
+		assert.Len(t, set.Matches, 1)
+		assert.False(t, language.Detected())
+	})
+
+	t.Run("it shares the detected language between subscriptions", func(t *testing.T) {
+		language := pipeline.detector.Lazy(itemText(item))
+		english := testSubscription(t, 1, userID, "crm", enums.MatchModeBroad, data.KeywordFilters{
+			Language: &data.LanguageFilters{Languages: []string{"en"}},
+		})
+		notEnglish := testSubscription(t, 2, userID, "crm", enums.MatchModeSmart, data.KeywordFilters{Smart: &data.SmartFilter{
+			Scope:     data.SmartScope{Language: data.SmartScopeList{Exclude: []string{"english"}}},
+			Candidate: data.SmartRule{Condition: data.SmartCondition{AnyPhrase: []string{"crm"}}},
+		}})
+		var set MatchSet
+
+		pipeline.matchItem(&set, item, language, english, nil)
+		assert.True(t, language.Detected())
+		pipeline.matchItem(&set, item, language, notEnglish, nil)
+
+		assert.Len(t, set.Matches, 1)
+		assert.Equal(t, 1, set.Matches[0].KeywordID)
+	})
+}
@@ -0,0 +1,45 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	t.Run("it evicts the least recently used entry", func(t *testing.T) {
+		cache := newLRUCache[string, int](2)
+		cache.Add("a", 1)
+		cache.Add("b", 2)
+		_, _ = cache.Get("a")
+
+		cache.Add("c", 3)
+
+		assert.Equal(t, 2, cache.Len())
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		value, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+	})
+
+	t.Run("it replaces the value of an existing key", func(t *testing.T) {
+		cache := newLRUCache[string, int](2)
+		cache.Add("a", 1)
+
+		cache.Add("a", 2)
+
+		value, _ := cache.Get("a")
+		assert.Equal(t, 2, value)
+		assert.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("it keeps at least one entry", func(t *testing.T) {
+		cache := newLRUCache[string, int](0)
+
+		cache.Add("a", 1)
+
+		_, ok := cache.Get("a")
+		assert.True(t, ok)
+	})
+}
@@ -0,0 +1,194 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	mastodonPageSize        = 40
+	mastodonMaxPagesPerPoll = 5
+)
+
+// MastodonSource polls the public timeline of a Mastodon instance, or its hashtag timelines when hashtags are configured.
+type MastodonSource struct {
+	client   *SourceClient
+	instance string
+	baseURL  string
+	hashtags []string
+}
+
+func NewMastodonSource(instance string, hashtags []string, sourceMonitor *monitor.SourceMonitor) *MastodonSource {
+	instance = strings.ToLower(strings.TrimSpace(instance))
+	return &MastodonSource{
+		client:   NewSourceClient(string(enums.SourceMastodon)+"_"+instance, &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		instance: instance,
+		baseURL:  "https://" + instance,
+		hashtags: hashtags,
+	}
+}
+
+func (s *MastodonSource) Name() string {
+	return string(enums.SourceMastodon) + "_" + s.instance
+}
+
+// Fetch returns the statuses created after cursor, oldest pages first. Mastodon status IDs are
+// snowflakes derived from the creation time, so the unix cursor is converted into a min_id.
+// Without a cursor only the latest page is read.
+func (s *MastodonSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	newest := cursor
+	items := make([]Item, 0, mastodonPageSize)
+	minID := ""
+	if cursor > 0 {
+		minID = strconv.FormatInt(mastodonSnowflake(cursor+1)-1, 10)
+	}
+
+	for page := 0; page < mastodonMaxPagesPerPoll; page++ {
+		statuses, err := s.timeline(ctx, minID)
+		if err != nil {
+			if len(items) > 0 {
+				break
+			}
+			return nil, cursor, err
+		}
+
+		var maxID int64
+		for _, status := range statuses {
+			if id, err := strconv.ParseInt(status.ID, 10, 64); err == nil && id > maxID {
+				maxID = id
+			}
+			item, ok := s.item(status)
+			if !ok {
+				continue
+			}
+			items = append(items, item)
+			if item.CreatedUTC > newest {
+				newest = item.CreatedUTC
+			}
+		}
+
+		if cursor <= 0 || len(statuses) < mastodonPageSize || maxID == 0 {
+			break
+		}
+		minID = strconv.FormatInt(maxID, 10)
+	}
+
+	return items, newest, nil
+}
+
+func (s *MastodonSource) timeline(ctx context.Context, minID string) ([]models.MastodonStatus, error) {
+	path := "/api/v1/timelines/public"
+	query := neturl.Values{}
+	query.Set("limit", strconv.Itoa(mastodonPageSize))
+	if len(s.hashtags) > 0 {
+		path = "/api/v1/timelines/tag/" + neturl.PathEscape(s.hashtags[0])
+		for _, tag := range s.hashtags[1:] {
+			query.Add("any[]", tag)
+		}
+	}
+	if minID != "" {
+		query.Set("min_id", minID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "feedgrep")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var statuses []models.MastodonStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, fmt.Errorf("decode timeline: %w", err)
+	}
+	return statuses, nil
+}
+
+// item normalizes a status. The content warning becomes the title so it is matched and shown
+// ahead of the hidden content.
+func (s *MastodonSource) item(status models.MastodonStatus) (Item, bool) {
+	if status.Reblog != nil || status.ID == "" {
+		return Item{}, false
+	}
+
+	permalink := status.URL
+	if permalink == "" {
+		permalink = status.URI
+	}
+	id := status.URI
+	if id == "" {
+		id = permalink
+	}
+	if id == "" {
+		return Item{}, false
+	}
+
+	account, instance := mastodonAccount(status.Account.Acct, s.instance)
+	contentWarning := strings.TrimSpace(status.SpoilerText)
+	body := stripHTML(status.Content)
+
+	mastodonData := data.MastodonData{
+		StatusID:       status.ID,
+		Instance:       instance,
+		Account:        account,
+		ContentWarning: contentWarning,
+		Body:           body,
+		Permalink:      permalink,
+	}
+
+	return Item{
+		Source:     enums.SourceMastodon,
+		Kind:       ItemKindPost,
+		ID:         id,
+		Title:      contentWarning,
+		Body:       body,
+		Instance:   instance,
+		Author:     account,
+		Permalink:  permalink,
+		CreatedUTC: status.CreatedAt.Unix(),
+		MatchData: func(keyword string) any {
+			payload := mastodonData
+			payload.Keyword = keyword
+			return payload
+		},
+	}, true
+}
+
+// mastodonAccount returns the fully qualified user@instance handle and the author's home instance.
+// Local accounts are reported without a domain by the instance that hosts them.
+func mastodonAccount(acct, localInstance string) (string, string) {
+	acct = strings.TrimPrefix(strings.TrimSpace(acct), "@")
+	if acct == "" {
+		return "", localInstance
+	}
+	if _, domain, ok := strings.Cut(acct, "@"); ok && domain != "" {
+		return acct, strings.ToLower(domain)
+	}
+	return acct + "@" + localInstance, localInstance
+}
+
+// mastodonSnowflake returns the smallest status ID Mastodon can assign at the given unix second.
+func mastodonSnowflake(unix int64) int64 {
+	return (unix * 1000) << 16
+}
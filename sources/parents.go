@@ -0,0 +1,180 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	// parentLookupBatchSize is the number of post ids requested per Arctic Shift lookup.
+	parentLookupBatchSize = 100
+	// maxParentBodyLength bounds the parent body stored with every comment match.
+	maxParentBodyLength = 2000
+	// parentLookupTimeout bounds how long a page of comments waits for its parent posts.
+	parentLookupTimeout = 5 * time.Second
+	parentResolverName  = "arcticshift_parents"
+)
+
+type parentPost struct {
+	title string
+	body  string
+	meta  RedditPostMeta
+}
+
+// ParentResolver adds the title, the metadata the post filters need and optionally the body of the
+// post a Reddit comment belongs to.
+// Posts are looked up on Arctic Shift in batches and kept in an LRU cache, since busy threads
+// produce many comments between polls. Lookups are best effort: they go through a client with its
+// own circuit breaker and are not retried, so they cannot slow down or trip the comment polling.
+type ParentResolver struct {
+	logger      *slog.Logger
+	client      *SourceClient
+	baseURL     string
+	includeBody bool
+	cache       *lruCache[string, parentPost]
+}
+
+func NewParentResolver(logger *slog.Logger, sourceMonitor *monitor.SourceMonitor, cacheSize int, includeBody bool) *ParentResolver {
+	client := NewSourceClient(parentResolverName, &http.Client{Timeout: parentLookupTimeout}, sourceMonitor)
+	client.maxAttempts = 1
+
+	return &ParentResolver{
+		logger:      logger,
+		client:      client,
+		baseURL:     arcticShiftBaseURL,
+		includeBody: includeBody,
+		cache:       newLRUCache[string, parentPost](cacheSize),
+	}
+}
+
+// Resolve fills in the parent post of every comment in items. Comments whose parent cannot be
+// found are left as they are; a failed lookup never fails the fetch.
+func (r *ParentResolver) Resolve(ctx context.Context, items []Item) {
+	missing := make([]string, 0)
+	seen := make(map[string]struct{})
+	for i := range items {
+		item := &items[i]
+		if !item.IsComment() || item.ParentID == "" || item.ParentPost != nil {
+			continue
+		}
+		if parent, ok := r.cache.Get(item.ParentID); ok {
+			r.apply(item, parent)
+			continue
+		}
+		if _, ok := seen[item.ParentID]; !ok {
+			seen[item.ParentID] = struct{}{}
+			missing = append(missing, item.ParentID)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	for start := 0; start < len(missing); start += parentLookupBatchSize {
+		batch := missing[start:min(start+parentLookupBatchSize, len(missing))]
+		if err := r.lookup(ctx, batch); err != nil {
+			r.logger.Info("resolve parent posts", "count", len(batch), "error", truncateError(err))
+			return
+		}
+	}
+
+	for i := range items {
+		item := &items[i]
+		if !item.IsComment() || item.ParentID == "" || item.ParentPost != nil {
+			continue
+		}
+		if parent, ok := r.cache.Get(item.ParentID); ok {
+			r.apply(item, parent)
+		}
+	}
+}
+
+func (r *ParentResolver) apply(item *Item, parent parentPost) {
+	item.ParentTitle = parent.title
+	meta := parent.meta
+	item.ParentPost = &meta
+	if r.includeBody {
+		item.ParentBody = parent.body
+	}
+}
+
+func (r *ParentResolver) lookup(ctx context.Context, ids []string) error {
+	fields := "id,title,over_18,link_flair_text,is_self,domain"
+	if r.includeBody {
+		fields += ",selftext"
+	}
+	posts, err := fetchArcticShiftPostsByID(ctx, r.client, r.baseURL, ids, fields)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		if post.ID == "" {
+			continue
+		}
+		r.cache.Add(post.ID, parentPost{
+			title: post.Title,
+			body:  truncateText(post.Selftext, maxParentBodyLength),
+			meta: RedditPostMeta{
+				Flair:  post.LinkFlairText,
+				NSFW:   post.Over18,
+				IsSelf: post.IsSelf,
+				Domain: post.Domain,
+			},
+		})
+	}
+	return nil
+}
+
+// fetchArcticShiftPostsByID looks up the given post ids on Arctic Shift. Posts it does not know are left out.
+func fetchArcticShiftPostsByID(ctx context.Context, client *SourceClient, baseURL string, ids []string, fields string) ([]models.ArcticShiftPost, error) {
+	query := neturl.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("fields", fields)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/posts/ids?%s", baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "feedgrep")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var body models.ArcticShiftSearchResponse[models.ArcticShiftPost]
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// redditPostID strips the t3_ type prefix from a Reddit link id.
+func redditPostID(linkID string) string {
+	return strings.TrimPrefix(linkID, "t3_")
+}
+
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
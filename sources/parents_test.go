@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParentResolver(t *testing.T) {
+	posts := map[string]models.ArcticShiftPost{
+		"p1": {ID: "p1", Title: "First post", Selftext: "First body", Over18: true, LinkFlairText: "News"},
+		"p2": {ID: "p2", Title: "Second post", Selftext: "Second body"},
+	}
+	newResolver := func(t *testing.T, includeBody bool, status int) (*ParentResolver, *atomic.Int32) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			var resp models.ArcticShiftSearchResponse[models.ArcticShiftPost]
+			for _, id := range strings.Split(r.URL.Query().Get("ids"), ",") {
+				if post, ok := posts[id]; ok {
+					resp.Data = append(resp.Data, post)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		}))
+		t.Cleanup(server.Close)
+
+		resolver := NewParentResolver(slog.Default(), monitor.NewSourceMonitor(), 10, includeBody)
+		resolver.baseURL = server.URL
+		return resolver, &calls
+	}
+	comments := func() []Item {
+		return []Item{
+			{Kind: ItemKindComment, ID: "c1", ParentID: "p1"},
+			{Kind: ItemKindComment, ID: "c2", ParentID: "p1"},
+			{Kind: ItemKindComment, ID: "c3", ParentID: "p2"},
+			{Kind: ItemKindComment, ID: "c4", ParentID: "missing"},
+			{Kind: ItemKindPost, ID: "p3"},
+		}
+	}
+
+	t.Run("it fills in the parent titles with a single lookup", func(t *testing.T) {
+		resolver, calls := newResolver(t, false, http.StatusOK)
+		items := comments()
+
+		resolver.Resolve(context.Background(), items)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, "First post", items[0].ParentTitle)
+		assert.Equal(t, "First post", items[1].ParentTitle)
+		assert.Equal(t, "Second post", items[2].ParentTitle)
+		assert.Empty(t, items[3].ParentTitle)
+		assert.Empty(t, items[4].ParentTitle)
+		assert.Empty(t, items[0].ParentBody)
+		assert.Equal(t, &RedditPostMeta{NSFW: true, Flair: "News"}, items[0].ParentPost)
+		assert.Nil(t, items[3].ParentPost)
+	})
+
+	t.Run("it serves cached parents without a lookup", func(t *testing.T) {
+		resolver, calls := newResolver(t, false, http.StatusOK)
+		resolver.Resolve(context.Background(), comments()[:3])
+		items := comments()[:3]
+
+		resolver.Resolve(context.Background(), items)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, "Second post", items[2].ParentTitle)
+	})
+
+	t.Run("it includes the parent body when configured", func(t *testing.T) {
+		resolver, _ := newResolver(t, true, http.StatusOK)
+		items := comments()
+
+		resolver.Resolve(context.Background(), items)
+
+		assert.Equal(t, "First body", items[0].ParentBody)
+	})
+
+	t.Run("it leaves the comments as they are when the lookup fails", func(t *testing.T) {
+		resolver, calls := newResolver(t, false, http.StatusBadGateway)
+		items := comments()
+
+		resolver.Resolve(context.Background(), items)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, comments(), items)
+	})
+}
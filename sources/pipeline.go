@@ -0,0 +1,503 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/matchers"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+// EvaluationObserver is called after every subscription has been evaluated against an item.
+type EvaluationObserver func(item Item, matchMode enums.MatchMode, start time.Time)
+
+// engagementRecheckInterval is how long a pending match waits between engagement checks.
+const engagementRecheckInterval = 15 * time.Minute
+
+// MatchSet is the outcome of matching a batch of items. Pending holds matches of keywords with an
+// engagement threshold whose post has not crossed it yet. Items are the evaluated items that are
+// kept in the recent items buffer.
+type MatchSet struct {
+	Matches []data.Match
+	Pending []data.PendingMatch
+	Items   []Item
+}
+
+func (s MatchSet) Len() int {
+	return len(s.Matches) + len(s.Pending)
+}
+
+// Pipeline matches items from any Source against the active keyword subscriptions and stores the matches.
+type Pipeline struct {
+	logger           *slog.Logger
+	keywordRepo      *repos.KeywordRepo
+	matchRepo        *repos.MatchRepo
+	pendingMatchRepo *repos.PendingMatchRepo
+	recentItemRepo   *repos.RecentItemRepo
+	km               *monitor.KeywordMonitor
+	recentWindow     time.Duration
+	duplicates       *duplicateIndex
+	detector         *matchers.LanguageDetector
+
+	mu            sync.RWMutex
+	subscriptions []keywordSubscription
+	// keywordSet fingerprints the keywords the subscriptions were built from, so they are only
+	// rebuilt when a keyword changes.
+	keywordSet uint64
+	index      *subscriptionIndex
+}
+
+func NewPipeline(logger *slog.Logger, keywordRepo *repos.KeywordRepo, matchRepo *repos.MatchRepo, pendingMatchRepo *repos.PendingMatchRepo, recentItemRepo *repos.RecentItemRepo, keywordMonitor *monitor.KeywordMonitor) *Pipeline {
+	return &Pipeline{
+		logger:           logger,
+		keywordRepo:      keywordRepo,
+		matchRepo:        matchRepo,
+		pendingMatchRepo: pendingMatchRepo,
+		recentItemRepo:   recentItemRepo,
+		km:               keywordMonitor,
+		recentWindow:     time.Duration(config.Config.RecentItemsHours) * time.Hour,
+		duplicates:       newDuplicateIndex(matchRepo, time.Duration(config.Config.DuplicateWindowHours)*time.Hour),
+		detector:         matchers.NewLanguageDetector(),
+	}
+}
+
+// Start refreshes the active keywords every minute until ctx is cancelled.
+func (p *Pipeline) Start(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.LoadKeywords()
+		}
+	}
+}
+
+func (p *Pipeline) LoadKeywords() {
+	keywords, err := p.keywordRepo.GetActiveKeywordsWithEmails()
+	if err != nil {
+		p.logger.Error("failed to refresh subscriptions", "error", err)
+		return
+	}
+
+	keywordSet := keywordSetHash(keywords)
+	p.mu.RLock()
+	unchanged := p.index != nil && p.keywordSet == keywordSet
+	p.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	active := make([]keywordSubscription, 0, len(keywords))
+	for _, keyword := range keywords {
+		sub, err := newKeywordSubscription(keyword.ID, keyword.UserID, keyword.Keyword, keyword.MatchMode, keyword.Filters)
+		if err != nil {
+			p.logger.Warn("skipping keyword with invalid filters", "keyword_id", keyword.ID, "error", err)
+			continue
+		}
+		if len(sub.smartProblems) > 0 {
+			p.logger.Warn("ignoring invalid parts of smart filter", "keyword_id", keyword.ID, "problems", sub.smartProblems)
+		}
+		if sub.keyword == "" || strings.TrimSpace(keyword.Email) == "" {
+			continue
+		}
+		active = append(active, sub)
+	}
+
+	index := newSubscriptionIndex(active)
+
+	p.mu.Lock()
+	p.subscriptions = active
+	p.keywordSet = keywordSet
+	p.index = index
+	p.mu.Unlock()
+	p.km.Active(len(active))
+}
+
+// keywordSetHash returns a hash of everything the subscriptions are built from.
+func keywordSetHash(keywords []data.KeywordNotification) uint64 {
+	h := fnv.New64a()
+	for _, keyword := range keywords {
+		fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%t\x00", keyword.ID, keyword.UserID, keyword.Keyword, keyword.MatchMode, strings.TrimSpace(keyword.Email) != "")
+		h.Write(keyword.FiltersRaw)
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// Match evaluates every item against the active subscriptions the index selects for it and
+// returns the resulting matches.
+func (p *Pipeline) Match(items []Item, observe EvaluationObserver) MatchSet {
+	p.mu.RLock()
+	subscriptions := p.subscriptions
+	index := p.index
+	p.mu.RUnlock()
+
+	set := MatchSet{Matches: make([]data.Match, 0, 32)}
+	if index == nil {
+		index = newSubscriptionIndex(subscriptions)
+	}
+	for _, item := range items {
+		candidates := index.candidates(item)
+		if len(candidates) == 0 {
+			continue
+		}
+		// The language is detected at most once per item, and only if a candidate filters on it.
+		language := p.detector.Lazy(itemText(item))
+		for _, i := range candidates {
+			p.matchItem(&set, item, language, subscriptions[i], observe)
+		}
+	}
+	if p.recentWindow > 0 {
+		set.Items = items
+	}
+
+	return set
+}
+
+// matchItem evaluates a single item against a subscription and adds the outcome to set. language is
+// the item's language, shared between the subscriptions the item is evaluated against.
+func (p *Pipeline) matchItem(set *MatchSet, item Item, language *matchers.DetectedLanguage, sub keywordSubscription, observe EvaluationObserver) {
+	if item.Owner != uuid.Nil && item.Owner != sub.userID {
+		return
+	}
+	matchStart := time.Now()
+	subMatches, smartResult, err := sub.Matches(item, language, p.logger.Enabled(context.Background(), slog.LevelDebug))
+	if observe != nil {
+		observe(item, sub.matchMode, matchStart)
+	}
+	if err != nil {
+		p.logger.Error("failed to check match", "error", err, "source", item.Source, "kind", item.Kind, "item_id", item.ID)
+		return
+	}
+	p.logSmartMatchResult(item, sub, smartResult)
+	if !subMatches {
+		return
+	}
+
+	threshold := sub.filters.Engagement
+	if item.RedditPost == nil {
+		// Engagement thresholds only apply to Reddit posts, which are the only items that can be
+		// re-checked. Every other item matches right away.
+		threshold = nil
+	}
+
+	match, err := makeMatch(item, sub)
+	if err != nil {
+		p.logger.Error("failed to make match", "error", err, "source", item.Source, "kind", item.Kind, "item_id", item.ID)
+		return
+	}
+	if threshold != nil && !threshold.Met(item.RedditPost.Score, item.RedditPost.NumComments) {
+		set.Pending = append(set.Pending, makePendingMatch(match, item, *threshold))
+		return
+	}
+	set.Matches = append(set.Matches, match)
+}
+
+// Persist stores the matches and pending matches, ignoring ones that were already stored, and adds
+// the evaluated items to the recent items buffer. Near-duplicates of a user's recent matches are
+// recorded on the earlier match instead of being stored.
+func (p *Pipeline) Persist(set MatchSet) error {
+	_, err := p.persist(set)
+	return err
+}
+
+// persist is Persist, returning the number of matches it created.
+func (p *Pipeline) persist(set MatchSet) (int64, error) {
+	matches, err := p.collapseDuplicates(set.Matches)
+	if err != nil {
+		return 0, err
+	}
+	var created int64
+	if len(matches) > 0 {
+		if created, err = p.matchRepo.CreateMatches(matches); err != nil {
+			return 0, err
+		}
+	}
+	if len(set.Pending) > 0 {
+		if err := p.pendingMatchRepo.CreatePendingMatches(set.Pending); err != nil {
+			return 0, err
+		}
+	}
+	if len(set.Items) > 0 {
+		// The buffer only feeds retroactive matching, so a failed write must not hold up the matches.
+		if err := p.rememberItems(set.Items); err != nil {
+			p.logger.Warn("failed to store recent items", "items", len(set.Items), "error", err)
+		}
+	}
+	return created, nil
+}
+
+// collapseDuplicates drops matches that are near-duplicates of the user's recent matches, see
+// duplicateIndex.collapse.
+func (p *Pipeline) collapseDuplicates(matches []data.Match) ([]data.Match, error) {
+	return p.duplicates.collapse(matches, time.Now())
+}
+
+// Process matches the items and stores the resulting matches.
+func (p *Pipeline) Process(items []Item, observe EvaluationObserver) error {
+	return p.Persist(p.Match(items, observe))
+}
+
+func (p *Pipeline) logSmartMatchResult(item Item, sub keywordSubscription, result *matchers.SmartMatchResult) {
+	if result == nil {
+		return
+	}
+	if !result.CandidateMatched {
+		return
+	}
+
+	p.logger.Debug(
+		"smart match evaluation",
+		"keyword_id", sub.id,
+		"source", item.Source,
+		"kind", item.Kind,
+		"item_id", item.ID,
+		"matched", result.Matched,
+		"score", result.Score,
+		"matched_signals", result.MatchedSignals,
+		"signal_details", result.SignalDetails,
+		"rejected_by", result.RejectedBy,
+	)
+}
+
+func makeMatch(item Item, sub keywordSubscription) (data.Match, error) {
+	var payload any
+	if item.MatchData != nil {
+		payload = item.MatchData(sub.keyword)
+	} else {
+		redditData := data.RedditData{
+			Keyword:   sub.keyword,
+			Subreddit: item.Subreddit,
+			Author:    item.Author,
+			Title:     item.Title,
+			Body:      item.Body,
+			IsComment: item.IsComment(),
+			Permalink: item.Permalink,
+
+			ParentTitle: item.ParentTitle,
+			ParentBody:  item.ParentBody,
+		}
+		if post := item.RedditPost; post != nil {
+			redditData.Score = post.Score
+			redditData.NumComments = post.NumComments
+			redditData.Flair = post.Flair
+			redditData.AuthorFlair = post.AuthorFlair
+			redditData.NSFW = post.NSFW
+			redditData.IsSelf = post.IsSelf
+			redditData.URL = post.URL
+			redditData.Domain = post.Domain
+		}
+		payload = redditData
+	}
+
+	matchHash := buildMatchHash(sub.userID, sub.id, dedupSource(item.Source), item.Permalink)
+	match, err := data.NewMatch(
+		sub.userID,
+		sub.id,
+		item.Source,
+		matchHash,
+		payload,
+	)
+	if err != nil {
+		return match, err
+	}
+
+	if fingerprint, ok := simhash(item.Title + "\n" + item.Body); ok {
+		signed := int64(fingerprint)
+		match.Fingerprint = &signed
+	}
+	match.Location = data.MatchLocation{
+		Source:    item.Source,
+		Subreddit: item.Subreddit,
+		Instance:  item.Instance,
+		Permalink: item.Permalink,
+	}
+	return match, nil
+}
+
+// dedupSource maps sources that carry the same content to a single source, so an item seen
+// through both the Reddit API and the Arctic Shift mirror produces one match.
+func dedupSource(source enums.Source) enums.Source {
+	if source == enums.SourceReddit {
+		return enums.SourceArcticShift
+	}
+	return source
+}
+
+// makePendingMatch parks a match until the post crosses the threshold or the window since the
+// post was created has passed.
+func makePendingMatch(match data.Match, item Item, threshold data.EngagementThreshold) data.PendingMatch {
+	expiresAt := time.Unix(item.CreatedUTC, 0).Add(time.Duration(threshold.WithinHours) * time.Hour)
+	return data.PendingMatch{
+		UserID:      match.UserID,
+		KeywordID:   match.KeywordID,
+		Source:      match.Source,
+		Hash:        match.Hash,
+		PostID:      item.ID,
+		DataRaw:     match.DataRaw,
+		MinScore:    threshold.MinScore,
+		MinComments: threshold.MinComments,
+		ExpiresAt:   expiresAt,
+		NextCheckAt: time.Now().Add(engagementRecheckInterval),
+		Fingerprint: match.Fingerprint,
+	}
+}
+
+func buildMatchHash(userID uuid.UUID, keywordID int, source enums.Source, url string) string {
+	input := fmt.Sprintf("%s:%d:%s:%s", userID.String(), keywordID, source, url)
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
+
+type keywordSubscription struct {
+	id        int
+	userID    uuid.UUID
+	keyword   string
+	matchMode enums.MatchMode
+	filters   data.KeywordFilters
+	// smart is the compiled smart filter of smart keywords.
+	smart *matchers.CompiledSmartFilter
+	// smartProblems are the invalid parts of the stored smart filter that were left out.
+	smartProblems []string
+	// query is the parsed query of query keywords.
+	query *matchers.Query
+}
+
+func newKeywordSubscription(id int, userID uuid.UUID, keyword string, matchMode enums.MatchMode, filters data.KeywordFilters) (keywordSubscription, error) {
+	sub := keywordSubscription{
+		id:        id,
+		userID:    userID,
+		keyword:   strings.TrimSpace(strings.ToLower(keyword)),
+		matchMode: matchMode,
+		filters:   filters,
+	}
+	if matchMode == enums.MatchModeSmart && filters.Smart != nil {
+		sub.smart, sub.smartProblems = matchers.CompileStoredSmartFilter(*filters.Smart)
+	}
+	if matchMode == enums.MatchModeQuery {
+		// Operators are upper case, so the query keeps the case it was written in.
+		sub.keyword = strings.TrimSpace(keyword)
+		query, err := matchers.ParseQuery(sub.keyword)
+		if err != nil {
+			return keywordSubscription{}, fmt.Errorf("parse query: %w", err)
+		}
+		sub.query = query
+	}
+	return sub, nil
+}
+
+// Matches reports whether the item matches the subscription. For smart keywords the evaluation
+// details are only collected when explain is set, since recording them allocates.
+func (s *keywordSubscription) Matches(item Item, language *matchers.DetectedLanguage, explain bool) (bool, *matchers.SmartMatchResult, error) {
+	if s.matchMode == enums.MatchModeInvalid {
+		return false, nil, errors.New(string("invalid match mode: " + s.matchMode))
+	}
+	if s.matchMode == enums.MatchModeSmart {
+		return s.matchesSmart(item, language, explain)
+	}
+
+	textLower := strings.ToLower(itemText(item))
+
+	switch s.matchMode {
+	case enums.MatchModeExact:
+		if !matchers.MatchesWholeWord(textLower, s.keyword) {
+			return false, nil, nil
+		}
+	case enums.MatchModeBroad:
+		if !matchers.MatchesPartially(textLower, s.keyword) {
+			return false, nil, nil
+		}
+	case enums.MatchModeQuery:
+		if !s.query.Matches(matchers.QueryInput{Title: item.Title, Body: item.Body, Subreddit: item.Subreddit}) {
+			return false, nil, nil
+		}
+	default:
+		return false, nil, errors.New(string("invalid match mode: " + s.matchMode))
+	}
+
+	if s.filters.Reddit != nil {
+		match, err := matchers.MatchesSubreddit(*s.filters.Reddit, item.Subreddit)
+		if err != nil {
+			return false, nil, err
+		}
+		if !match {
+			return false, nil, nil
+		}
+		if !matchesPostFilters(*s.filters.Reddit, item) {
+			return false, nil, nil
+		}
+	}
+
+	if s.filters.Language != nil {
+		match, err := matchers.MatchesDetectedLanguage(*s.filters.Language, language)
+		if err != nil {
+			return false, nil, err
+		}
+		if !match {
+			return false, nil, nil
+		}
+	}
+
+	return true, nil, nil
+}
+
+// matchesPostFilters applies the post filters to a Reddit post, or to the post a Reddit comment
+// belongs to. A comment whose post could not be resolved only matches when no post filter is set.
+func matchesPostFilters(f data.RedditFilters, item Item) bool {
+	post := item.RedditPost
+	if item.IsComment() {
+		post = item.ParentPost
+	}
+	if post == nil {
+		return !item.IsComment() || !isRedditSource(item.Source) || !matchers.HasPostFilters(f)
+	}
+	return matchers.MatchesRedditPost(f, matchers.RedditPost{
+		NSFW:   post.NSFW,
+		Flair:  post.Flair,
+		IsSelf: post.IsSelf,
+		Domain: post.Domain,
+	})
+}
+
+func isRedditSource(source enums.Source) bool {
+	return source == enums.SourceReddit || source == enums.SourceArcticShift
+}
+
+func (s *keywordSubscription) matchesSmart(item Item, language *matchers.DetectedLanguage, explain bool) (bool, *matchers.SmartMatchResult, error) {
+	if s.smart == nil {
+		return false, nil, errors.New("smart match mode requires a smart filter")
+	}
+	input := matchers.SmartInput{
+		Title:     item.Title,
+		Body:      item.Body,
+		Subreddit: item.Subreddit,
+		Instance:  item.Instance,
+		Labels:    item.Labels,
+		Tags:      item.Tags,
+		Language:  language,
+
+		ParentTitle: item.ParentTitle,
+	}
+	if !explain {
+		return s.smart.Matches(input), nil, nil
+	}
+	result := s.smart.Evaluate(input)
+	return result.Matched, &result, nil
+}
@@ -0,0 +1,140 @@
+package sources
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesPostFilters(t *testing.T) {
+	filters := data.RedditFilters{ExcludeNSFW: true}
+	nsfw := &RedditPostMeta{NSFW: true, IsSelf: true}
+	safe := &RedditPostMeta{IsSelf: true}
+
+	t.Run("it applies the post filters to posts", func(t *testing.T) {
+		assert.False(t, matchesPostFilters(filters, Item{Source: enums.SourceArcticShift, Kind: ItemKindPost, RedditPost: nsfw}))
+		assert.True(t, matchesPostFilters(filters, Item{Source: enums.SourceArcticShift, Kind: ItemKindPost, RedditPost: safe}))
+	})
+
+	t.Run("it applies the post filters to comments through their post", func(t *testing.T) {
+		assert.False(t, matchesPostFilters(filters, Item{Source: enums.SourceReddit, Kind: ItemKindComment, ParentPost: nsfw}))
+		assert.True(t, matchesPostFilters(filters, Item{Source: enums.SourceReddit, Kind: ItemKindComment, ParentPost: safe}))
+	})
+
+	t.Run("it drops reddit comments whose post is unknown only while a post filter is set", func(t *testing.T) {
+		comment := Item{Source: enums.SourceArcticShift, Kind: ItemKindComment}
+
+		assert.False(t, matchesPostFilters(filters, comment))
+		assert.True(t, matchesPostFilters(data.RedditFilters{Subreddits: []string{"golang"}}, comment))
+	})
+
+	t.Run("it ignores items from other sources", func(t *testing.T) {
+		assert.True(t, matchesPostFilters(filters, Item{Source: enums.SourceHackerNews, Kind: ItemKindComment}))
+	})
+}
+
+func TestMatchItemEngagementThreshold(t *testing.T) {
+	filters := data.KeywordFilters{Engagement: &data.EngagementThreshold{MinScore: 100, WithinHours: 24}}
+	sub, err := newKeywordSubscription(1, uuid.New(), "golang", enums.MatchModeBroad, filters)
+	require.NoError(t, err)
+	pipeline := &Pipeline{logger: slog.Default()}
+	match := func(item Item) MatchSet {
+		var set MatchSet
+		pipeline.matchItem(&set, item, nil, sub, nil)
+		return set
+	}
+
+	t.Run("it holds back posts below the threshold", func(t *testing.T) {
+		set := match(Item{
+			Source:     enums.SourceArcticShift,
+			Kind:       ItemKindPost,
+			ID:         "p1",
+			Title:      "Why golang channels block when nobody is receiving",
+			Permalink:  "/r/golang/comments/p1/",
+			CreatedUTC: time.Now().Unix(),
+			RedditPost: &RedditPostMeta{Score: 5},
+		})
+
+		assert.Empty(t, set.Matches)
+		require.Len(t, set.Pending, 1)
+		assert.NotNil(t, set.Pending[0].Fingerprint)
+	})
+
+	t.Run("it matches posts above the threshold right away", func(t *testing.T) {
+		set := match(Item{Source: enums.SourceArcticShift, Kind: ItemKindPost, ID: "p2", Title: "golang", Permalink: "/p2", RedditPost: &RedditPostMeta{Score: 500}})
+
+		assert.Len(t, set.Matches, 1)
+		assert.Empty(t, set.Pending)
+	})
+
+	t.Run("it matches items without engagement right away", func(t *testing.T) {
+		comment := match(Item{Source: enums.SourceArcticShift, Kind: ItemKindComment, ID: "c1", Body: "golang", Permalink: "/c1"})
+		story := match(Item{Source: enums.SourceHackerNews, Kind: ItemKindPost, ID: "h1", Title: "golang", Permalink: "/h1"})
+
+		assert.Len(t, comment.Matches, 1)
+		assert.Len(t, story.Matches, 1)
+	})
+}
+
+func TestKeywordSetHash(t *testing.T) {
+	keywords := func() []data.KeywordNotification {
+		return []data.KeywordNotification{
+			{ID: 1, UserID: uuid.New(), Keyword: "golang", MatchMode: enums.MatchModeBroad, Email: "a@example.com", FiltersRaw: []byte(`{}`)},
+			{ID: 2, UserID: uuid.New(), Keyword: "rust", MatchMode: enums.MatchModeBroad, Email: "b@example.com", FiltersRaw: []byte(`{}`)},
+		}
+	}
+
+	t.Run("it is stable for the same keywords", func(t *testing.T) {
+		a := keywords()
+		b := append([]data.KeywordNotification(nil), a...)
+
+		assert.Equal(t, keywordSetHash(a), keywordSetHash(b))
+	})
+
+	t.Run("it changes when a keyword changes", func(t *testing.T) {
+		base := keywords()
+		changes := map[string]func(k *data.KeywordNotification){
+			"keyword":    func(k *data.KeywordNotification) { k.Keyword = "golang jobs" },
+			"match mode": func(k *data.KeywordNotification) { k.MatchMode = enums.MatchModeSmart },
+			"filters":    func(k *data.KeywordNotification) { k.FiltersRaw = []byte(`{"language":{"include":["en"]}}`) },
+			"email":      func(k *data.KeywordNotification) { k.Email = "" },
+		}
+		for name, change := range changes {
+			changed := append([]data.KeywordNotification(nil), base...)
+			change(&changed[0])
+
+			assert.NotEqual(t, keywordSetHash(base), keywordSetHash(changed), name)
+		}
+	})
+
+	t.Run("it changes when a keyword is added or removed", func(t *testing.T) {
+		base := keywords()
+
+		assert.NotEqual(t, keywordSetHash(base), keywordSetHash(base[:1]))
+	})
+}
+
+func TestNewKeywordSubscriptionStoredSmartFilter(t *testing.T) {
+	t.Run("it keeps a keyword whose stored filter has an unknown where field", func(t *testing.T) {
+		filters := data.KeywordFilters{Smart: &data.SmartFilter{
+			Candidate: data.SmartRule{
+				Where:     []string{"title", "comments"},
+				Condition: data.SmartCondition{AnyPhrase: []string{"crm"}},
+			},
+		}}
+
+		sub, err := newKeywordSubscription(1, uuid.New(), "crm", enums.MatchModeSmart, filters)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{`candidate.where[1]: unknown field "comments"`}, sub.smartProblems)
+		matched, _, err := sub.Matches(Item{Title: "Looking for a CRM"}, nil, false)
+		require.NoError(t, err)
+		assert.True(t, matched)
+	})
+}
@@ -0,0 +1,97 @@
+package sources
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+// Poller drives a Source on a fixed interval. Every batch is handed to the match pool
+// and the cursor is persisted once the batch's matches have been stored.
+// Cursors are expected to be unix timestamps so the catch-up window can be applied.
+type Poller struct {
+	logger  *slog.Logger
+	src     Source
+	pool    *MatchPool
+	cursors cursorStore
+	sm      *monitor.SourceMonitor
+
+	interval   time.Duration
+	maxCatchUp time.Duration
+	cursor     int64
+}
+
+func NewPoller(logger *slog.Logger, src Source, pool *MatchPool, cursorRepo *repos.SourceCursorRepo, sourceMonitor *monitor.SourceMonitor, interval time.Duration) *Poller {
+	return &Poller{
+		logger:     logger,
+		src:        src,
+		pool:       pool,
+		cursors:    newCursorStore(logger, cursorRepo),
+		sm:         sourceMonitor,
+		interval:   interval,
+		maxCatchUp: time.Duration(config.Config.MaxCatchUpMinutes) * time.Minute,
+	}
+}
+
+func (p *Poller) StartPolling(ctx context.Context) {
+	p.logger.Info("starting source polling", "source", p.src.Name(), "interval", p.interval.Seconds())
+
+	p.resume()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("stopping source polling", "source", p.src.Name())
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// resume loads the persisted cursor, clamped to the catch-up window.
+func (p *Poller) resume() {
+	p.cursor, _ = p.cursors.load(p.src.Name(), time.Now().Add(-p.maxCatchUp).Unix())
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	items, cursor, err := p.src.Fetch(ctx, p.cursor)
+	processingStart := time.Now()
+	if err != nil {
+		p.sm.RequestError(p.src.Name())
+		p.logger.Info("poll source", "source", p.src.Name(), "error", truncateError(err))
+		return
+	}
+	if len(items) == 0 && cursor <= p.cursor {
+		return
+	}
+
+	// The next fetch continues from the new cursor right away, but it is only persisted
+	// once this batch and every batch before it have been stored.
+	commitCursor := int64(0)
+	if cursor > p.cursor {
+		p.cursor = cursor
+		commitCursor = cursor
+	}
+	name := p.src.Name()
+	err = p.pool.Submit(ctx, Batch{
+		Source: name,
+		Items:  items,
+		Commit: func() {
+			if len(items) > 0 {
+				p.sm.Batch(name, int64(len(items)), processingStart, newestCreatedUTC(items))
+			}
+			p.cursors.save(name, commitCursor)
+		},
+	})
+	if err != nil {
+		p.logger.Info("submit batch", "source", name, "error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package sources
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when a user-supplied URL resolves to an address that is not publicly routable.
+var ErrPrivateAddress = errors.New("address is not publicly routable")
+
+// nonPublicPrefixes are the ranges that are not publicly routable but are not covered by
+// netip.Addr.IsPrivate: the carrier-grade NAT range, the benchmarking range and the NAT64 prefix,
+// which maps to IPv4 addresses including internal ones.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicAddr reports whether addr is a publicly routable unicast address, i.e. not loopback,
+// private, link-local, shared, benchmarking, NAT64 or unspecified.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewPublicHTTPClient returns a client for fetching user-supplied URLs. The address is checked
+// after the host has been resolved, so neither a DNS record nor a redirect pointing at an
+// internal address can make the server reach it. Proxies are not used for the same reason.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   publicAddressControl,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// publicAddressControl rejects connections to addresses that are not publicly routable. It runs
+// for every resolved address the dialer tries.
+func publicAddressControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addr) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+)
+
+const (
+	// recentScanLimit and recentMatchBudget cap how many buffered items a single retroactive run
+	// evaluates and for how long, so creating or editing a keyword stays fast even when the buffer
+	// holds a lot of matching content.
+	recentScanLimit   = 2000
+	recentMatchBudget = 3 * time.Second
+	recentPruneEvery  = 10 * time.Minute
+)
+
+// recentItemDetails is the part of an Item that is stored as JSON in the recent items buffer.
+type recentItemDetails struct {
+	Subreddit   string          `json:"subreddit,omitempty"`
+	Instance    string          `json:"instance,omitempty"`
+	Labels      []string        `json:"labels,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Author      string          `json:"author,omitempty"`
+	Permalink   string          `json:"permalink,omitempty"`
+	CreatedUTC  int64           `json:"created_utc,omitempty"`
+	ParentID    string          `json:"parent_id,omitempty"`
+	ParentTitle string          `json:"parent_title,omitempty"`
+	ParentBody  string          `json:"parent_body,omitempty"`
+	RedditPost  *RedditPostMeta `json:"reddit_post,omitempty"`
+	ParentPost  *RedditPostMeta `json:"parent_post,omitempty"`
+	// MatchData is the match payload of items with a custom MatchData, built without a keyword.
+	MatchData json.RawMessage `json:"match_data,omitempty"`
+}
+
+// MatchRecent matches a keyword against the recent items buffer and stores the matches, so a new
+// or edited keyword shows results before new content arrives. It returns the number of matches
+// stored.
+func (p *Pipeline) MatchRecent(keyword data.Keyword) (int, error) {
+	if p.recentWindow <= 0 || !keyword.Active {
+		return 0, nil
+	}
+
+	sub, err := newKeywordSubscription(keyword.ID, keyword.UserID, keyword.Keyword, keyword.MatchMode, keyword.Filters)
+	if err != nil {
+		return 0, err
+	}
+	if sub.keyword == "" {
+		return 0, nil
+	}
+
+	since := time.Now().Add(-p.recentWindow)
+	recent, err := p.recentItemRepo.GetRecentItems(since, keyword.UserID, sub.prefilterPhrases(), recentScanLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	var set MatchSet
+	deadline := time.Now().Add(recentMatchBudget)
+	for _, r := range recent {
+		if time.Now().After(deadline) {
+			p.logger.Warn("stopped matching recent items at the time budget", "keyword_id", keyword.ID, "items", len(recent))
+			break
+		}
+		item, err := itemFromRecent(r)
+		if err != nil {
+			p.logger.Warn("failed to decode recent item", "error", err, "source", r.Source, "item_id", r.ItemID)
+			continue
+		}
+		p.matchItem(&set, item, p.detector.Lazy(itemText(item)), sub, nil)
+	}
+
+	created, err := p.persist(set)
+	if err != nil {
+		return 0, err
+	}
+	return int(created), nil
+}
+
+// StartPruning removes items that fell out of the recent items window until ctx is cancelled.
+func (p *Pipeline) StartPruning(ctx context.Context) {
+	if p.recentWindow <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(recentPruneEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := p.recentItemRepo.DeleteRecentItemsBefore(time.Now().Add(-p.recentWindow))
+			if err != nil {
+				p.logger.Error("failed to prune recent items", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				p.logger.Debug("pruned recent items", "deleted", deleted)
+			}
+		}
+	}
+}
+
+// rememberItems adds the items created within the recent window to the buffer. Older items, e.g.
+// from a backfill, are skipped.
+func (p *Pipeline) rememberItems(items []Item) error {
+	cutoff := time.Now().Add(-p.recentWindow).Unix()
+	recent := make([]data.RecentItem, 0, len(items))
+	for _, item := range items {
+		if item.CreatedUTC > 0 && item.CreatedUTC < cutoff {
+			continue
+		}
+		r, err := recentFromItem(item)
+		if err != nil {
+			return err
+		}
+		recent = append(recent, r)
+	}
+	if len(recent) == 0 {
+		return nil
+	}
+	return p.recentItemRepo.CreateRecentItems(recent)
+}
+
+func recentFromItem(item Item) (data.RecentItem, error) {
+	details := recentItemDetails{
+		Subreddit:   item.Subreddit,
+		Instance:    item.Instance,
+		Labels:      item.Labels,
+		Tags:        item.Tags,
+		Author:      item.Author,
+		Permalink:   item.Permalink,
+		CreatedUTC:  item.CreatedUTC,
+		ParentID:    item.ParentID,
+		ParentTitle: item.ParentTitle,
+		ParentBody:  item.ParentBody,
+		RedditPost:  item.RedditPost,
+		ParentPost:  item.ParentPost,
+	}
+	if item.MatchData != nil {
+		payload, err := json.Marshal(item.MatchData(""))
+		if err != nil {
+			return data.RecentItem{}, fmt.Errorf("marshal match data: %w", err)
+		}
+		details.MatchData = payload
+	}
+
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return data.RecentItem{}, fmt.Errorf("marshal recent item: %w", err)
+	}
+
+	recent := data.RecentItem{
+		Source:     item.Source,
+		Kind:       item.Kind,
+		ItemID:     item.ID,
+		Title:      item.Title,
+		Body:       item.Body,
+		DetailsRaw: raw,
+	}
+	if item.Owner != uuid.Nil {
+		owner := item.Owner
+		recent.OwnerID = &owner
+	}
+	return recent, nil
+}
+
+func itemFromRecent(r data.RecentItem) (Item, error) {
+	var details recentItemDetails
+	if err := json.Unmarshal(r.DetailsRaw, &details); err != nil {
+		return Item{}, err
+	}
+
+	item := Item{
+		Source:      r.Source,
+		Kind:        r.Kind,
+		ID:          r.ItemID,
+		Title:       r.Title,
+		Body:        r.Body,
+		Subreddit:   details.Subreddit,
+		Instance:    details.Instance,
+		Labels:      details.Labels,
+		Tags:        details.Tags,
+		Author:      details.Author,
+		Permalink:   details.Permalink,
+		CreatedUTC:  details.CreatedUTC,
+		ParentID:    details.ParentID,
+		ParentTitle: details.ParentTitle,
+		ParentBody:  details.ParentBody,
+		RedditPost:  details.RedditPost,
+		ParentPost:  details.ParentPost,
+	}
+	if r.OwnerID != nil {
+		item.Owner = *r.OwnerID
+	}
+	if len(details.MatchData) > 0 {
+		payload := details.MatchData
+		item.MatchData = func(keyword string) any {
+			return withKeyword(payload, keyword)
+		}
+	}
+	return item, nil
+}
+
+// withKeyword sets the keyword of a stored match payload. Every payload type carries it under "keyword".
+func withKeyword(payload json.RawMessage, keyword string) any {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return payload
+	}
+	encoded, _ := json.Marshal(keyword)
+	fields["keyword"] = encoded
+	return fields
+}
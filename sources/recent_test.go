@@ -0,0 +1,129 @@
+package sources
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentItems(t *testing.T) {
+	t.Run("it restores a reddit item from the buffer", func(t *testing.T) {
+		item := Item{
+			Source:     enums.SourceArcticShift,
+			Kind:       ItemKindPost,
+			ID:         "abc",
+			Title:      "Looking for a tool",
+			Body:       "any recommendations?",
+			Subreddit:  "golang",
+			Author:     "alice",
+			Permalink:  "https://reddit.com/r/golang/comments/abc",
+			CreatedUTC: 1700000000,
+			RedditPost: &RedditPostMeta{Score: 12, Flair: "Help", IsSelf: true},
+		}
+
+		recent, err := recentFromItem(item)
+		require.NoError(t, err)
+		assert.Nil(t, recent.OwnerID)
+
+		restored, err := itemFromRecent(recent)
+		require.NoError(t, err)
+		assert.Equal(t, item, restored)
+	})
+
+	t.Run("it restores the match payload with the matched keyword", func(t *testing.T) {
+		owner := uuid.New()
+		item := Item{
+			Source: enums.SourceFeed,
+			Kind:   ItemKindPost,
+			ID:     "entry",
+			Title:  "Release notes",
+			Owner:  owner,
+			MatchData: func(keyword string) any {
+				return data.FeedData{Keyword: keyword, FeedID: 7, Title: "Release notes"}
+			},
+		}
+
+		recent, err := recentFromItem(item)
+		require.NoError(t, err)
+		require.NotNil(t, recent.OwnerID)
+		assert.Equal(t, owner, *recent.OwnerID)
+
+		restored, err := itemFromRecent(recent)
+		require.NoError(t, err)
+		require.NotNil(t, restored.MatchData)
+
+		raw, err := json.Marshal(restored.MatchData("release"))
+		require.NoError(t, err)
+		var payload data.FeedData
+		require.NoError(t, json.Unmarshal(raw, &payload))
+		assert.Equal(t, data.FeedData{Keyword: "release", FeedID: 7, Title: "Release notes"}, payload)
+	})
+}
+
+func TestPrefilterPhrases(t *testing.T) {
+	smart := func(rule data.SmartRule) keywordSubscription {
+		return testSubscription(t, 1, uuid.New(), "smart", enums.MatchModeSmart, data.KeywordFilters{
+			Smart: &data.SmartFilter{Candidate: rule},
+		})
+	}
+
+	t.Run("it uses the keyword for broad and exact keywords", func(t *testing.T) {
+		sub := testSubscription(t, 1, uuid.New(), " Postgres ", enums.MatchModeBroad, data.KeywordFilters{})
+		assert.Equal(t, []string{"postgres"}, sub.prefilterPhrases())
+	})
+
+	t.Run("it collects the phrases of a smart candidate", func(t *testing.T) {
+		sub := smart(data.SmartRule{Condition: data.SmartCondition{All: []data.SmartCondition{
+			{AnyPhrase: []string{"crm", "helpdesk"}},
+			{Any: []data.SmartCondition{{AnyPhrase: []string{"recommend"}}}},
+		}}})
+		assert.Equal(t, []string{"crm", "helpdesk", "recommend"}, sub.prefilterPhrases())
+	})
+
+	t.Run("it leaves negations out of an all condition", func(t *testing.T) {
+		sub := smart(data.SmartRule{Condition: data.SmartCondition{All: []data.SmartCondition{
+			{Near: &data.SmartNear{Terms: []string{"pricing", "acme crm"}, Distance: 3}},
+			{None: []data.SmartCondition{{AnyPhrase: []string{"hiring"}}}},
+		}}})
+		assert.Equal(t, []string{"pricing"}, sub.prefilterPhrases())
+	})
+
+	t.Run("it scans without a prefilter when the candidate is a negation", func(t *testing.T) {
+		sub := smart(data.SmartRule{Condition: data.SmartCondition{None: []data.SmartCondition{{AnyPhrase: []string{"hiring"}}}}})
+		assert.Nil(t, sub.prefilterPhrases())
+	})
+
+	t.Run("it scans without a prefilter when the candidate has a regex", func(t *testing.T) {
+		sub := smart(data.SmartRule{Condition: data.SmartCondition{Any: []data.SmartCondition{
+			{AnyPhrase: []string{"crm"}},
+			{Regex: []string{`help\s*desk`}},
+		}}})
+		assert.Nil(t, sub.prefilterPhrases())
+	})
+
+	t.Run("it reduces a query to the phrases of its most selective branch", func(t *testing.T) {
+		sub := testSubscription(t, 1, uuid.New(), `(crm OR "Help Desk") AND postgr* AND NOT hiring`, enums.MatchModeQuery, data.KeywordFilters{})
+		assert.Equal(t, []string{"postgr"}, sub.prefilterPhrases())
+
+		sub = testSubscription(t, 1, uuid.New(), `subreddit:golang OR crm`, enums.MatchModeQuery, data.KeywordFilters{})
+		assert.Nil(t, sub.prefilterPhrases())
+	})
+
+	t.Run("it rejects a query keyword that does not parse", func(t *testing.T) {
+		_, err := newKeywordSubscription(1, uuid.New(), `crm AND`, enums.MatchModeQuery, data.KeywordFilters{})
+		assert.ErrorContains(t, err, "position 8")
+	})
+
+	t.Run("it scans without a prefilter when the candidate searches other fields", func(t *testing.T) {
+		sub := smart(data.SmartRule{
+			Where:     []string{"title", "subreddit"},
+			Condition: data.SmartCondition{AnyPhrase: []string{"crm"}},
+		})
+		assert.Nil(t, sub.prefilterPhrases())
+	})
+}
@@ -0,0 +1,406 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	redditTokenURL = "https://www.reddit.com/api/v1/access_token"
+	redditBaseURL  = "https://oauth.reddit.com"
+
+	redditPageSize        = 100
+	redditMaxPagesPerPoll = 3
+	// redditTokenExpiryMargin refreshes the access token slightly before Reddit expires it.
+	redditTokenExpiryMargin = time.Minute
+)
+
+var errRedditRateLimited = errors.New("reddit rate limit exhausted")
+
+// RedditPoller polls the official Reddit API for new posts and comments on r/all.
+// In failover mode it only polls while Arctic Shift lags behind by more than the configured threshold.
+type RedditPoller struct {
+	logger   *slog.Logger
+	am       *monitor.ArcticShiftMonitor
+	posts    *Poller
+	comments *Poller
+
+	mode        string
+	interval    time.Duration
+	failoverLag int64
+	active      bool
+}
+
+func NewRedditPoller(logger *slog.Logger, pool *MatchPool, cursorRepo *repos.SourceCursorRepo, parents *ParentResolver, sourceMonitor *monitor.SourceMonitor, arcticShiftMonitor *monitor.ArcticShiftMonitor) *RedditPoller {
+	interval := time.Duration(config.Config.RedditPollIntervalMs) * time.Millisecond
+	client := NewRedditClient(config.Config.RedditClientID, config.Config.RedditClientSecret, config.Config.RedditUserAgent, sourceMonitor)
+	comments := NewRedditSource(client, ItemKindComment)
+	comments.parents = parents
+
+	return &RedditPoller{
+		logger:      logger,
+		am:          arcticShiftMonitor,
+		posts:       NewPoller(logger, NewRedditSource(client, ItemKindPost), pool, cursorRepo, sourceMonitor, interval),
+		comments:    NewPoller(logger, comments, pool, cursorRepo, sourceMonitor, interval),
+		mode:        config.Config.RedditPollMode,
+		interval:    interval,
+		failoverLag: int64(config.Config.RedditFailoverLagSeconds),
+	}
+}
+
+func (p *RedditPoller) StartPolling(ctx context.Context) {
+	p.logger.Info("starting reddit polling", "mode", p.mode, "interval", p.interval.Seconds())
+	p.active = false
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("stopping reddit polling")
+			return
+		case <-ticker.C:
+			if !p.shouldPoll() {
+				continue
+			}
+			p.posts.poll(ctx)
+			p.comments.poll(ctx)
+		}
+	}
+}
+
+// shouldPoll reports whether the Reddit API should be polled on this tick. Cursors are
+// reloaded whenever polling resumes so a failover picks up from the catch-up window.
+func (p *RedditPoller) shouldPoll() bool {
+	active := p.mode == config.RedditPollModePrimary
+	if p.mode == config.RedditPollModeFailover {
+		lag := p.am.LagSeconds()
+		active = lag > p.failoverLag
+		if active && !p.active {
+			p.logger.Warn("arcticshift lag exceeded threshold, failing over to reddit api", "lag", lag, "threshold", p.failoverLag)
+		}
+		if !active && p.active {
+			p.logger.Info("arcticshift recovered, stopping reddit api failover", "lag", lag)
+		}
+	}
+
+	if active && !p.active {
+		p.posts.resume()
+		p.comments.resume()
+	}
+	p.active = active
+	return active
+}
+
+// RedditClient performs application-only OAuth requests against the Reddit API and
+// tracks the rate limit reported in the response headers.
+type RedditClient struct {
+	client       *SourceClient
+	clientID     string
+	clientSecret string
+	userAgent    string
+	tokenURL     string
+	baseURL      string
+
+	mu             sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+	rateRemaining  float64
+	rateResetAt    time.Time
+}
+
+func NewRedditClient(clientID, clientSecret, userAgent string, sourceMonitor *monitor.SourceMonitor) *RedditClient {
+	return &RedditClient{
+		client:        NewSourceClient(string(enums.SourceReddit), &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		userAgent:     userAgent,
+		tokenURL:      redditTokenURL,
+		baseURL:       redditBaseURL,
+		rateRemaining: -1,
+	}
+}
+
+// Listing fetches a listing at path. It fails fast with errRedditRateLimited while the
+// rate limit window is exhausted instead of blocking the poller.
+func (c *RedditClient) Listing(ctx context.Context, path string, query neturl.Values) (models.RedditListing, error) {
+	var listing models.RedditListing
+
+	if wait := c.rateLimitWait(); wait > 0 {
+		return listing, fmt.Errorf("%w, resets in %s", errRedditRateLimited, wait.Round(time.Second))
+	}
+
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return listing, fmt.Errorf("get reddit token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return listing, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Authorization", "bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return listing, err
+	}
+	defer resp.Body.Close()
+
+	c.updateRateLimit(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		c.invalidateToken()
+		return listing, fmt.Errorf("status %d", resp.StatusCode)
+	case http.StatusTooManyRequests:
+		c.exhaustRateLimit(resp)
+		return listing, errRedditRateLimited
+	default:
+		return listing, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return listing, fmt.Errorf("decode listing: %w", err)
+	}
+	return listing, nil
+}
+
+func (c *RedditClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiresAt) {
+		return c.token, nil
+	}
+
+	form := neturl.Values{}
+	form.Set("grant_type", "client_credentials")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var token models.RedditTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("empty access token")
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - redditTokenExpiryMargin)
+	return c.token, nil
+}
+
+func (c *RedditClient) invalidateToken() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+func (c *RedditClient) rateLimitWait() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rateRemaining < 0 || c.rateRemaining >= 1 {
+		return 0
+	}
+	return max(time.Until(c.rateResetAt), 0)
+}
+
+// updateRateLimit records the X-Ratelimit-Remaining and X-Ratelimit-Reset headers.
+func (c *RedditClient) updateRateLimit(resp *http.Response) {
+	remaining, err := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateRemaining = remaining
+	c.rateResetAt = time.Now().Add(time.Duration(reset) * time.Second)
+}
+
+func (c *RedditClient) exhaustRateLimit(resp *http.Response) {
+	reset, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil {
+		reset, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateRemaining = 0
+	c.rateResetAt = time.Now().Add(time.Duration(max(reset, 1)) * time.Second)
+}
+
+// RedditSource fetches either new posts or new comments across r/all from the official Reddit API.
+type RedditSource struct {
+	client *RedditClient
+	kind   string
+	// parents resolves the parent posts of comments. It is nil for the posts source.
+	parents *ParentResolver
+
+	// seen holds the ids already returned at the second of the latest cursor. Listing times only
+	// have a resolution of one second, so that second is read again on the next fetch.
+	seenAt int64
+	seen   []string
+
+	// A walk cut short by a failed page or redditMaxPagesPerPoll leaves the items between the
+	// cursor and the oldest item read unread. resumeAfter is the listing position the next poll
+	// continues the walk from, and the cursor only moves to resumeAt once the walk reaches it.
+	resumeAfter string
+	resumeAt    int64
+	resumeSeen  []string
+}
+
+func NewRedditSource(client *RedditClient, kind string) *RedditSource {
+	return &RedditSource{
+		client: client,
+		kind:   kind,
+	}
+}
+
+func (s *RedditSource) Name() string {
+	return string(enums.SourceReddit) + "_" + s.kind + "s"
+}
+
+// Fetch walks the newest-first listing until it reaches items before cursor, leaving out the ones
+// already returned at the cursor's second. Without a cursor only the latest page is read. When a
+// page fails or the page limit is hit first, the items are returned with the old cursor and the
+// next poll continues the walk where this one stopped, so the older items are not skipped.
+func (s *RedditSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	path := "/r/all/new"
+	if s.kind == ItemKindComment {
+		path = "/r/all/comments"
+	}
+	if cursor != s.seenAt {
+		// The cursor was reloaded, so the items at its second rely on match deduplication.
+		s.seenAt, s.seen = cursor, nil
+		s.resumeAfter = ""
+	}
+
+	after := s.resumeAfter
+	if after == "" {
+		s.resumeAt, s.resumeSeen = cursor, slices.Clone(s.seen)
+	}
+	items := make([]Item, 0, redditPageSize)
+	reachedCursor := false
+	for page := 0; page < redditMaxPagesPerPoll; page++ {
+		query := neturl.Values{}
+		query.Set("limit", strconv.Itoa(redditPageSize))
+		query.Set("raw_json", "1")
+		if after != "" {
+			query.Set("after", after)
+		}
+
+		listing, err := s.client.Listing(ctx, path, query)
+		if err != nil {
+			if len(items) > 0 {
+				// Keep what was fetched; the next poll retries this page.
+				break
+			}
+			return nil, cursor, err
+		}
+
+		for _, thing := range listing.Data.Children {
+			createdUTC := int64(thing.Data.CreatedUTC)
+			if createdUTC < cursor {
+				reachedCursor = true
+				continue
+			}
+			if thing.Data.ID == "" || (createdUTC == cursor && slices.Contains(s.seen, thing.Data.ID)) {
+				continue
+			}
+			items = append(items, redditItem(s.kind, thing.Data))
+			trackSeen(&s.resumeAt, &s.resumeSeen, createdUTC, thing.Data.ID)
+		}
+
+		after = listing.Data.After
+		if after == "" || cursor <= 0 {
+			// The listing ends here, or only the latest page is wanted.
+			reachedCursor = true
+		}
+		if reachedCursor {
+			break
+		}
+	}
+
+	if s.parents != nil {
+		s.parents.Resolve(ctx, items)
+	}
+
+	if !reachedCursor {
+		s.resumeAfter = after
+		return items, cursor, nil
+	}
+	s.resumeAfter = ""
+	s.seenAt, s.seen = s.resumeAt, s.resumeSeen
+	return items, s.seenAt, nil
+}
+
+func redditItem(kind string, thing models.RedditThingData) Item {
+	item := Item{
+		Source:     enums.SourceReddit,
+		Kind:       kind,
+		ID:         thing.ID,
+		Subreddit:  thing.Subreddit,
+		Author:     thing.Author,
+		CreatedUTC: int64(thing.CreatedUTC),
+	}
+	if kind == ItemKindComment {
+		item.Body = thing.Body
+		item.Permalink = buildArcticShiftCommentPermalink(thing.Subreddit, thing.LinkID, thing.ID)
+		item.ParentID = redditPostID(thing.LinkID)
+		item.ParentTitle = thing.LinkTitle
+	} else {
+		item.Title = thing.Title
+		item.Body = thing.Selftext
+		item.Permalink = buildArcticShiftPostPermalink(thing.Subreddit, thing.ID)
+		item.RedditPost = &RedditPostMeta{
+			Score:       thing.Score,
+			NumComments: thing.NumComments,
+			Flair:       thing.LinkFlairText,
+			AuthorFlair: thing.AuthorFlairText,
+			NSFW:        thing.Over18,
+			IsSelf:      thing.IsSelf,
+			URL:         thing.URL,
+			Domain:      thing.Domain,
+		}
+	}
+	return item
+}
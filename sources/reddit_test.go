@@ -0,0 +1,134 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedditSourceFetch(t *testing.T) {
+	// pages holds the newest-first listing pages, keyed by the after token that requests them.
+	pages := map[string]models.RedditListingData{
+		"": {After: "t3_b", Children: []models.RedditThing{
+			{Data: models.RedditThingData{ID: "d", CreatedUTC: 400}},
+			{Data: models.RedditThingData{ID: "c", CreatedUTC: 300}},
+		}},
+		"t3_b": {After: "t3_a", Children: []models.RedditThing{
+			{Data: models.RedditThingData{ID: "b", CreatedUTC: 200}},
+			{Data: models.RedditThingData{ID: "a", CreatedUTC: 100}},
+		}},
+	}
+	newSource := func(t *testing.T, failAfter string) *RedditSource {
+		return newTestRedditSource(t, pages, failAfter)
+	}
+
+	t.Run("it advances the cursor once the walk reaches it", func(t *testing.T) {
+		items, cursor, err := newSource(t, "").Fetch(context.Background(), 150)
+
+		require.NoError(t, err)
+		assert.Len(t, items, 3)
+		assert.Equal(t, int64(400), cursor)
+	})
+
+	t.Run("it keeps the cursor when a page fails before reaching it", func(t *testing.T) {
+		items, cursor, err := newSource(t, "t3_b").Fetch(context.Background(), 150)
+
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, int64(150), cursor)
+	})
+
+	t.Run("it reads only the latest page without a cursor", func(t *testing.T) {
+		items, cursor, err := newSource(t, "t3_b").Fetch(context.Background(), 0)
+
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, int64(400), cursor)
+	})
+
+	t.Run("it reads the cursor's second again and skips the items already returned", func(t *testing.T) {
+		latest := map[string]models.RedditListingData{"": {Children: []models.RedditThing{
+			{Data: models.RedditThingData{ID: "d", CreatedUTC: 400}},
+			{Data: models.RedditThingData{ID: "c", CreatedUTC: 300}},
+		}}}
+		src := newTestRedditSource(t, latest, "")
+		_, cursor, err := src.Fetch(context.Background(), 300)
+		require.NoError(t, err)
+		latest[""] = models.RedditListingData{Children: []models.RedditThing{
+			{Data: models.RedditThingData{ID: "e", CreatedUTC: 400}},
+			{Data: models.RedditThingData{ID: "d", CreatedUTC: 400}},
+			{Data: models.RedditThingData{ID: "c", CreatedUTC: 300}},
+		}}
+
+		items, cursor, err := src.Fetch(context.Background(), cursor)
+
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "e", items[0].ID)
+		assert.Equal(t, int64(400), cursor)
+	})
+}
+
+func TestRedditSourceFetchResumesTruncatedWalk(t *testing.T) {
+	// Each page holds one item, so a poll reads redditMaxPagesPerPoll items.
+	pages := make(map[string]models.RedditListingData)
+	count := redditMaxPagesPerPoll + 2
+	after := ""
+	for i := count; i > 0; i-- {
+		next := fmt.Sprintf("t3_%d", i)
+		if i == 1 {
+			next = ""
+		}
+		pages[after] = models.RedditListingData{After: next, Children: []models.RedditThing{
+			{Data: models.RedditThingData{ID: strconv.Itoa(i), CreatedUTC: float64(100 * i)}},
+		}}
+		after = next
+	}
+	src := newTestRedditSource(t, pages, "")
+
+	first, cursor, err := src.Fetch(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Len(t, first, redditMaxPagesPerPoll)
+	assert.Equal(t, int64(50), cursor)
+
+	second, cursor, err := src.Fetch(context.Background(), cursor)
+	require.NoError(t, err)
+	assert.Len(t, second, count-redditMaxPagesPerPoll)
+	assert.Equal(t, int64(100*count), cursor)
+
+	seen := make(map[string]bool)
+	for _, item := range append(first, second...) {
+		seen[item.ID] = true
+	}
+	assert.Len(t, seen, count)
+}
+
+func newTestRedditSource(t *testing.T, pages map[string]models.RedditListingData, failAfter string) *RedditSource {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(models.RedditTokenResponse{AccessToken: "token", ExpiresIn: 3600})
+			return
+		}
+		after := r.URL.Query().Get("after")
+		if failAfter != "" && after == failAfter {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.RedditListing{Data: pages[after]})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewRedditClient("id", "secret", "feedgrep-test", monitor.NewSourceMonitor())
+	client.tokenURL = server.URL + "/token"
+	client.baseURL = server.URL
+	return NewRedditSource(client, ItemKindPost)
+}
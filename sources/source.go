@@ -0,0 +1,97 @@
+package sources
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/kova98/feedgrep.api/enums"
+)
+
+const (
+	ItemKindPost    = "post"
+	ItemKindComment = "comment"
+)
+
+// Source is an ingestion backend that produces normalized items for the shared matching pipeline.
+type Source interface {
+	// Name identifies the source stream in logs and persisted state.
+	Name() string
+	// Fetch returns the items created after cursor and the cursor to resume from.
+	Fetch(ctx context.Context, cursor int64) ([]Item, int64, error)
+}
+
+// StreamSource is an ingestion backend that pushes items over a long-lived connection instead of being polled.
+type StreamSource interface {
+	// Name identifies the source stream in logs and persisted state.
+	Name() string
+	// Stream delivers the events after cursor until the connection drops or ctx is cancelled.
+	// Sends on events must give up once ctx is done.
+	Stream(ctx context.Context, cursor int64, events chan<- StreamEvent) error
+}
+
+// StreamEvent is a single event received from a StreamSource.
+type StreamEvent struct {
+	// Item is nil for events that only advance the cursor.
+	Item   *Item
+	Cursor int64
+}
+
+// Item is a normalized piece of content fetched from a Source.
+type Item struct {
+	Source     enums.Source
+	Kind       string
+	ID         string
+	Title      string
+	Body       string
+	Subreddit  string
+	Instance   string
+	Labels     []string
+	Tags       []string
+	Author     string
+	Permalink  string
+	CreatedUTC int64
+
+	// ParentID is the id of the post a comment belongs to. ParentTitle and ParentBody are filled
+	// in when the parent post could be resolved.
+	ParentID    string
+	ParentTitle string
+	ParentBody  string
+
+	// RedditPost holds the metadata of Reddit posts. It is nil for every other item.
+	RedditPost *RedditPostMeta
+	// ParentPost holds the metadata of the post a Reddit comment belongs to, when it could be resolved.
+	// The post filters of a comment are applied to it.
+	ParentPost *RedditPostMeta
+
+	// Owner restricts matching to the subscriptions of a single user, e.g. for user-registered feeds.
+	Owner uuid.UUID
+
+	// MatchData builds the payload stored with a match. When nil, the item is stored as data.RedditData.
+	MatchData func(keyword string) any
+}
+
+// RedditPostMeta is the metadata a Reddit post carries beyond its text.
+type RedditPostMeta struct {
+	Score       int
+	NumComments int
+	Flair       string
+	AuthorFlair string
+	NSFW        bool
+	IsSelf      bool
+	URL         string
+	Domain      string
+}
+
+func (i Item) IsComment() bool {
+	return i.Kind == ItemKindComment
+}
+
+func newestCreatedUTC(items []Item) int64 {
+	var newest int64
+	for _, item := range items {
+		if item.CreatedUTC > newest {
+			newest = item.CreatedUTC
+		}
+	}
+	return newest
+}
@@ -0,0 +1,325 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/data"
+	"github.com/kova98/feedgrep.api/enums"
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	stackExchangeAPIURL        = "https://api.stackexchange.com/2.3"
+	stackExchangePageSize      = 100
+	stackExchangeMaxPages      = 5
+	stackExchangeQuotaSource   = string(enums.SourceStackExchange)
+	stackExchangeBodyFilter    = "withbody"
+	stackExchangeDefaultDomain = ".stackexchange.com"
+)
+
+// stackExchangeDomains lists the sites that are not hosted under stackexchange.com.
+var stackExchangeDomains = map[string]string{
+	"stackoverflow": "stackoverflow.com",
+	"serverfault":   "serverfault.com",
+	"superuser":     "superuser.com",
+	"askubuntu":     "askubuntu.com",
+	"mathoverflow":  "mathoverflow.net",
+	"stackapps":     "stackapps.com",
+}
+
+var errStackExchangeQuota = errors.New("stack exchange quota exhausted")
+
+// StackExchangeClient calls the Stack Exchange API and tracks the daily quota and the backoff
+// requests it reports. The quota is shared by every site polled with the same key.
+type StackExchangeClient struct {
+	client  *SourceClient
+	key     string
+	baseURL string
+	sm      *monitor.SourceMonitor
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func NewStackExchangeClient(key string, sourceMonitor *monitor.SourceMonitor) *StackExchangeClient {
+	return &StackExchangeClient{
+		client:  NewSourceClient(string(enums.SourceStackExchange), &http.Client{Timeout: 15 * time.Second}, sourceMonitor),
+		key:     key,
+		baseURL: stackExchangeAPIURL,
+		sm:      sourceMonitor,
+	}
+}
+
+func (c *StackExchangeClient) get(ctx context.Context, endpoint string, query neturl.Values, dest any) error {
+	c.mu.Lock()
+	blockedUntil := c.blockedUntil
+	c.mu.Unlock()
+	if wait := time.Until(blockedUntil); wait > 0 {
+		return fmt.Errorf("%w, retry in %s", errStackExchangeQuota, wait.Round(time.Second))
+	}
+
+	if c.key != "" {
+		query.Set("key", c.key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "feedgrep")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusTooManyRequests {
+			// Throttle violations are reported as 400 or 429; wait out a short penalty before retrying.
+			c.block(time.Now().Add(time.Minute))
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("decode %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// track records the quota and backoff reported in a response wrapper.
+func (c *StackExchangeClient) track(quotaRemaining, backoff int) {
+	c.sm.QuotaRemaining(stackExchangeQuotaSource, quotaRemaining)
+
+	switch {
+	case quotaRemaining <= 0:
+		// Quotas reset at midnight UTC.
+		c.block(time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour))
+	case backoff > 0:
+		c.block(time.Now().Add(time.Duration(backoff) * time.Second))
+	}
+}
+
+func (c *StackExchangeClient) block(until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until.After(c.blockedUntil) {
+		c.blockedUntil = until
+	}
+}
+
+const (
+	StackExchangeQuestions = "questions"
+	StackExchangeAnswers   = "answers"
+)
+
+// StackExchangeSource polls either new questions or new answers from a single Stack Exchange site.
+// Each endpoint has its own source and cursor, so one endpoint running ahead cannot skip items of the other.
+type StackExchangeSource struct {
+	client   *StackExchangeClient
+	site     string
+	endpoint string
+
+	// seen holds the ids already returned at the second of the latest cursor. fromdate only has a
+	// resolution of one second and is inclusive, so that second is read again on the next fetch.
+	seenAt int64
+	seen   []string
+}
+
+func NewStackExchangeSource(client *StackExchangeClient, site, endpoint string) *StackExchangeSource {
+	return &StackExchangeSource{
+		client:   client,
+		site:     strings.ToLower(strings.TrimSpace(site)),
+		endpoint: endpoint,
+	}
+}
+
+func (s *StackExchangeSource) Name() string {
+	return string(enums.SourceStackExchange) + "_" + s.site + "_" + s.endpoint
+}
+
+// Fetch returns the items created at or after cursor, leaving out the ones already returned at the
+// cursor's second. Without a cursor only the latest page is read.
+func (s *StackExchangeSource) Fetch(ctx context.Context, cursor int64) ([]Item, int64, error) {
+	if cursor != s.seenAt {
+		// The cursor was reloaded, so the items at its second rely on match deduplication.
+		s.seenAt, s.seen = cursor, nil
+	}
+
+	var items []Item
+	var err error
+	if s.endpoint == StackExchangeAnswers {
+		items, err = s.fetchAnswers(ctx, cursor)
+	} else {
+		items, err = s.fetchQuestions(ctx, cursor)
+	}
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	items = slices.DeleteFunc(items, func(item Item) bool {
+		return item.CreatedUTC < cursor || (item.CreatedUTC == cursor && slices.Contains(s.seen, item.ID))
+	})
+	for _, item := range items {
+		if item.CreatedUTC > s.seenAt {
+			s.seenAt, s.seen = item.CreatedUTC, nil
+		}
+		if item.CreatedUTC == s.seenAt {
+			s.seen = append(s.seen, item.ID)
+		}
+	}
+	return items, s.seenAt, nil
+}
+
+func (s *StackExchangeSource) fetchQuestions(ctx context.Context, cursor int64) ([]Item, error) {
+	items := make([]Item, 0)
+	for page := 1; page <= stackExchangeMaxPages; page++ {
+		var resp models.StackExchangeResponse[models.StackExchangeQuestion]
+		if err := s.client.get(ctx, "/questions", s.query(cursor, page), &resp); err != nil {
+			return nil, err
+		}
+		s.client.track(resp.QuotaRemaining, resp.Backoff)
+
+		for _, question := range resp.Items {
+			if question.QuestionID == 0 {
+				continue
+			}
+			items = append(items, s.questionItem(question))
+		}
+		if cursor <= 0 || !resp.HasMore || resp.Backoff > 0 {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (s *StackExchangeSource) fetchAnswers(ctx context.Context, cursor int64) ([]Item, error) {
+	items := make([]Item, 0)
+	for page := 1; page <= stackExchangeMaxPages; page++ {
+		var resp models.StackExchangeResponse[models.StackExchangeAnswer]
+		if err := s.client.get(ctx, "/answers", s.query(cursor, page), &resp); err != nil {
+			return nil, err
+		}
+		s.client.track(resp.QuotaRemaining, resp.Backoff)
+
+		for _, answer := range resp.Items {
+			if answer.AnswerID == 0 {
+				continue
+			}
+			items = append(items, s.answerItem(answer))
+		}
+		if cursor <= 0 || !resp.HasMore || resp.Backoff > 0 {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (s *StackExchangeSource) query(cursor int64, page int) neturl.Values {
+	query := neturl.Values{}
+	query.Set("site", s.site)
+	query.Set("filter", stackExchangeBodyFilter)
+	query.Set("pagesize", strconv.Itoa(stackExchangePageSize))
+	query.Set("page", strconv.Itoa(page))
+	query.Set("sort", "creation")
+	if cursor > 0 {
+		// fromdate is inclusive.
+		query.Set("fromdate", strconv.FormatInt(cursor, 10))
+		query.Set("order", "asc")
+	} else {
+		query.Set("order", "desc")
+	}
+	return query
+}
+
+func (s *StackExchangeSource) questionItem(question models.StackExchangeQuestion) Item {
+	permalink := question.Link
+	if permalink == "" {
+		permalink = fmt.Sprintf("https://%s/q/%d", s.domain(), question.QuestionID)
+	}
+	title := stripHTML(question.Title)
+	body := stripHTML(question.Body)
+	author := stripHTML(question.Owner.DisplayName)
+
+	stackExchangeData := data.StackExchangeData{
+		Site:       s.site,
+		QuestionID: question.QuestionID,
+		Title:      title,
+		Body:       body,
+		Author:     author,
+		Tags:       question.Tags,
+		Score:      question.Score,
+		Permalink:  permalink,
+	}
+
+	return Item{
+		Source:     enums.SourceStackExchange,
+		Kind:       ItemKindPost,
+		ID:         s.site + "/q/" + strconv.FormatInt(question.QuestionID, 10),
+		Title:      title,
+		Body:       body,
+		Author:     author,
+		Tags:       question.Tags,
+		Permalink:  permalink,
+		CreatedUTC: question.CreationDate,
+		MatchData:  stackExchangeMatchData(stackExchangeData),
+	}
+}
+
+// answerItem builds an item for an answer. Answers do not carry the question's title or tags.
+func (s *StackExchangeSource) answerItem(answer models.StackExchangeAnswer) Item {
+	permalink := fmt.Sprintf("https://%s/a/%d", s.domain(), answer.AnswerID)
+	body := stripHTML(answer.Body)
+	author := stripHTML(answer.Owner.DisplayName)
+
+	stackExchangeData := data.StackExchangeData{
+		Site:       s.site,
+		QuestionID: answer.QuestionID,
+		AnswerID:   answer.AnswerID,
+		Body:       body,
+		Author:     author,
+		Score:      answer.Score,
+		Permalink:  permalink,
+		IsAnswer:   true,
+	}
+
+	return Item{
+		Source:     enums.SourceStackExchange,
+		Kind:       ItemKindComment,
+		ID:         s.site + "/a/" + strconv.FormatInt(answer.AnswerID, 10),
+		Body:       body,
+		Author:     author,
+		Permalink:  permalink,
+		CreatedUTC: answer.CreationDate,
+		MatchData:  stackExchangeMatchData(stackExchangeData),
+	}
+}
+
+func (s *StackExchangeSource) domain() string {
+	if domain, ok := stackExchangeDomains[s.site]; ok {
+		return domain
+	}
+	if strings.Contains(s.site, ".") {
+		return s.site
+	}
+	return s.site + stackExchangeDefaultDomain
+}
+
+func stackExchangeMatchData(stackExchangeData data.StackExchangeData) func(keyword string) any {
+	return func(keyword string) any {
+		payload := stackExchangeData
+		payload.Keyword = keyword
+		return payload
+	}
+}
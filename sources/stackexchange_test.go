@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kova98/feedgrep.api/models"
+	"github.com/kova98/feedgrep.api/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackExchangeSourceFetch(t *testing.T) {
+	var questions []models.StackExchangeQuestion
+	var fromDates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/questions", r.URL.Path)
+		fromDates = append(fromDates, r.URL.Query().Get("fromdate"))
+		_ = json.NewEncoder(w).Encode(models.StackExchangeResponse[models.StackExchangeQuestion]{
+			Items:          questions,
+			QuotaRemaining: 100,
+		})
+	}))
+	defer server.Close()
+
+	client := NewStackExchangeClient("", monitor.NewSourceMonitor())
+	client.baseURL = server.URL
+	src := NewStackExchangeSource(client, "serverfault", StackExchangeQuestions)
+	ids := func(items []Item) []string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.ID)
+		}
+		return out
+	}
+
+	questions = []models.StackExchangeQuestion{
+		{QuestionID: 1, CreationDate: 100},
+		{QuestionID: 2, CreationDate: 101},
+	}
+	items, cursor, err := src.Fetch(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"serverfault/q/1", "serverfault/q/2"}, ids(items))
+	assert.Equal(t, int64(101), cursor)
+
+	t.Run("it reads the cursor's second again and skips the items already returned", func(t *testing.T) {
+		questions = []models.StackExchangeQuestion{
+			{QuestionID: 2, CreationDate: 101},
+			{QuestionID: 3, CreationDate: 101},
+		}
+
+		items, cursor, err := src.Fetch(context.Background(), 101)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"serverfault/q/3"}, ids(items))
+		assert.Equal(t, int64(101), cursor)
+		assert.Equal(t, []string{"100", "101"}, fromDates)
+	})
+
+	t.Run("it names the cursor after the endpoint", func(t *testing.T) {
+		assert.Equal(t, "stackexchange_serverfault_questions", src.Name())
+		assert.Equal(t, "stackexchange_serverfault_answers", NewStackExchangeSource(client, "ServerFault", StackExchangeAnswers).Name())
+	})
+}
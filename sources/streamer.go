@@ -0,0 +1,154 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kova98/feedgrep.api/config"
+	"github.com/kova98/feedgrep.api/data/repos"
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	streamBatchSize     = 500
+	streamFlushInterval = 1 * time.Second
+	streamMinBackoff    = 1 * time.Second
+	streamMaxBackoff    = 1 * time.Minute
+)
+
+// Streamer keeps a StreamSource connected and feeds its events through the shared pipeline in batches.
+// The cursor is persisted after every processed batch, and a dropped connection is re-established
+// with backoff from the last persisted cursor. Cursors are expected to be unix microseconds.
+type Streamer struct {
+	logger   *slog.Logger
+	src      StreamSource
+	pipeline *Pipeline
+	cursors  cursorStore
+	sm       *monitor.SourceMonitor
+
+	maxCatchUp    time.Duration
+	flushInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	cursor        int64
+}
+
+func NewStreamer(logger *slog.Logger, src StreamSource, pipeline *Pipeline, cursorRepo *repos.SourceCursorRepo, sourceMonitor *monitor.SourceMonitor) *Streamer {
+	return &Streamer{
+		logger:        logger,
+		src:           src,
+		pipeline:      pipeline,
+		cursors:       newCursorStore(logger, cursorRepo),
+		sm:            sourceMonitor,
+		maxCatchUp:    time.Duration(config.Config.MaxCatchUpMinutes) * time.Minute,
+		flushInterval: streamFlushInterval,
+		minBackoff:    streamMinBackoff,
+		maxBackoff:    streamMaxBackoff,
+	}
+}
+
+func (s *Streamer) StartStreaming(ctx context.Context) {
+	s.logger.Info("starting source streaming", "source", s.src.Name())
+
+	s.cursor, _ = s.cursors.load(s.src.Name(), time.Now().Add(-s.maxCatchUp).UnixMicro())
+
+	backoff := s.minBackoff
+	for {
+		delivered, err := s.stream(ctx)
+		if ctx.Err() != nil {
+			s.logger.Info("stopping source streaming", "source", s.src.Name())
+			return
+		}
+		if delivered {
+			backoff = s.minBackoff
+		}
+
+		s.sm.RequestError(s.src.Name())
+		s.logger.Info("stream disconnected, reconnecting",
+			"source", s.src.Name(),
+			"cursor", s.cursor,
+			"backoff", backoff.Seconds(),
+			"error", truncateError(err))
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("stopping source streaming", "source", s.src.Name())
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
+
+// stream runs a single connection until it drops and reports whether it delivered any events.
+func (s *Streamer) stream(ctx context.Context) (bool, error) {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan StreamEvent, streamBatchSize)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.src.Stream(connCtx, s.cursor, events)
+	}()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Item, 0, streamBatchSize)
+	pending := s.cursor
+	delivered := false
+
+	receive := func(event StreamEvent) {
+		delivered = true
+		if event.Item != nil {
+			batch = append(batch, *event.Item)
+		}
+		if event.Cursor > pending {
+			pending = event.Cursor
+		}
+	}
+	// flush processes the batch and advances the cursor. When the matches could not be stored the
+	// cursor stays where it was, so the connection is dropped and the batch is streamed again.
+	flush := func() error {
+		if len(batch) > 0 {
+			processingStart := time.Now()
+			if err := s.pipeline.Process(batch, nil); err != nil {
+				return fmt.Errorf("store matches: %w", err)
+			}
+			s.sm.Batch(s.src.Name(), int64(len(batch)), processingStart, newestCreatedUTC(batch))
+			batch = batch[:0]
+		}
+		if pending > s.cursor {
+			s.cursor = pending
+			s.cursors.save(s.src.Name(), pending)
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case event := <-events:
+			receive(event)
+			if len(batch) >= streamBatchSize {
+				if err := flush(); err != nil {
+					return delivered, err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return delivered, err
+			}
+		case err := <-done:
+			// The source has stopped sending, so whatever is buffered is all that is left.
+			for len(events) > 0 {
+				receive(<-events)
+			}
+			if flushErr := flush(); flushErr != nil {
+				return delivered, flushErr
+			}
+			return delivered, err
+		}
+	}
+}
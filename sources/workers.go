@@ -0,0 +1,285 @@
+package sources
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kova98/feedgrep.api/monitor"
+)
+
+const (
+	// matchChunkSize is the number of items a matcher worker evaluates at a time, so a single
+	// large page is spread across the workers.
+	matchChunkSize    = 50
+	matchWriteTries   = 3
+	matchWriteBackoff = 500 * time.Millisecond
+	// matchRetryDelay is how long the writer waits before retrying a write that kept failing.
+	matchRetryDelay = 5 * time.Second
+)
+
+// Batch is a unit of fetched items handed to the MatchPool.
+type Batch struct {
+	// Source identifies the cursor the batch belongs to. Batches of the same source are committed in submission order.
+	Source string
+	Items  []Item
+	// Observe is called from the matcher workers after every evaluation.
+	Observe EvaluationObserver
+	// Commit is called once the matches of this batch and of every earlier batch of the same source are stored.
+	Commit func()
+}
+
+// MatchPool decouples fetching from matching. Fetchers submit batches into a bounded queue,
+// matcher workers evaluate them concurrently and a single writer stores the matches in bulk.
+// A full queue blocks Submit, which slows the fetchers down instead of buffering without bound.
+type MatchPool struct {
+	logger    *slog.Logger
+	pipeline  *Pipeline
+	pm        *monitor.PipelineMonitor
+	workers   int
+	writeSize int
+
+	jobs    chan matchJob
+	results chan matchResult
+
+	mu      sync.Mutex
+	pending map[string][]*pendingBatch
+}
+
+type pendingBatch struct {
+	batch     Batch
+	remaining int
+	done      bool
+}
+
+type matchJob struct {
+	batch *pendingBatch
+	items []Item
+}
+
+type matchResult struct {
+	batch   *pendingBatch
+	matches MatchSet
+}
+
+func NewMatchPool(logger *slog.Logger, pipeline *Pipeline, pipelineMonitor *monitor.PipelineMonitor, workers, queueSize, writeSize int) *MatchPool {
+	workers = max(workers, 1)
+	queueSize = max(queueSize, 1)
+	pipelineMonitor.QueueCapacity(queueSize)
+
+	return &MatchPool{
+		logger:    logger,
+		pipeline:  pipeline,
+		pm:        pipelineMonitor,
+		workers:   workers,
+		writeSize: max(writeSize, 1),
+		jobs:      make(chan matchJob, queueSize),
+		results:   make(chan matchResult, queueSize),
+		pending:   make(map[string][]*pendingBatch),
+	}
+}
+
+// Start runs the matcher workers and the writer until ctx is cancelled.
+// The pool can be started again after it stopped; work left over from the previous run is discarded.
+func (p *MatchPool) Start(ctx context.Context) {
+	p.logger.Info("starting match pool", "workers", p.workers, "queue_size", cap(p.jobs), "write_size", p.writeSize)
+	p.reset()
+
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	p.write(ctx)
+	wg.Wait()
+}
+
+// reset drops queued work and uncommitted batches. Their cursors were never saved, so the
+// items are fetched again from the stored cursors.
+func (p *MatchPool) reset() {
+	for {
+		select {
+		case <-p.jobs:
+		case <-p.results:
+		default:
+			p.mu.Lock()
+			for source := range p.pending {
+				p.pm.PendingBatches(source, 0)
+			}
+			clear(p.pending)
+			p.mu.Unlock()
+			p.pm.QueueDepth(0)
+			return
+		}
+	}
+}
+
+// Submit queues the batch for matching, blocking while the queue is full.
+// A batch without items still flows through the pool so its Commit keeps its place in the order.
+func (p *MatchPool) Submit(ctx context.Context, batch Batch) error {
+	chunks := make([][]Item, 0, len(batch.Items)/matchChunkSize+1)
+	for start := 0; start < len(batch.Items); start += matchChunkSize {
+		chunks = append(chunks, batch.Items[start:min(start+matchChunkSize, len(batch.Items))])
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, nil)
+	}
+
+	pb := &pendingBatch{batch: batch, remaining: len(chunks)}
+	p.mu.Lock()
+	p.pending[batch.Source] = append(p.pending[batch.Source], pb)
+	p.pm.PendingBatches(batch.Source, len(p.pending[batch.Source]))
+	p.mu.Unlock()
+
+	enqueueStart := time.Now()
+	defer p.pm.EnqueueWait(batch.Source, enqueueStart)
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case p.jobs <- matchJob{batch: pb, items: chunk}:
+			p.pm.QueueDepth(len(p.jobs))
+		}
+	}
+	return nil
+}
+
+func (p *MatchPool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			p.pm.QueueDepth(len(p.jobs))
+			p.pm.WorkerBusy()
+			var matches MatchSet
+			if len(job.items) > 0 {
+				matches = p.pipeline.Match(job.items, job.batch.batch.Observe)
+			}
+			p.pm.WorkerIdle()
+
+			select {
+			case <-ctx.Done():
+				return
+			case p.results <- matchResult{batch: job.batch, matches: matches}:
+			}
+		}
+	}
+}
+
+// write collects results until the write size is reached or no more results are waiting,
+// stores the matches and then commits the batches that are complete. When the write fails the
+// matches are kept and no batch is committed, so no cursor moves past matches that were not
+// stored; they are written again together with the next results.
+func (p *MatchPool) write(ctx context.Context) {
+	var matches MatchSet
+	completed := make([]*pendingBatch, 0, 8)
+	var retry <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case result := <-p.results:
+			matches, completed = collectResult(matches, completed, result)
+		case <-retry:
+		}
+
+	drain:
+		for matches.Len() < p.writeSize {
+			select {
+			case result := <-p.results:
+				matches, completed = collectResult(matches, completed, result)
+			default:
+				break drain
+			}
+		}
+
+		if err := p.store(ctx, matches); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Error("failed to store matches, holding back commits", "matches", matches.Len(), "batches", len(completed), "error", err)
+			retry = time.After(matchRetryDelay)
+			continue
+		}
+		retry = nil
+		p.commit(completed)
+		matches = MatchSet{}
+		completed = completed[:0]
+	}
+}
+
+func collectResult(matches MatchSet, completed []*pendingBatch, result matchResult) (MatchSet, []*pendingBatch) {
+	matches.Matches = append(matches.Matches, result.matches.Matches...)
+	matches.Pending = append(matches.Pending, result.matches.Pending...)
+	matches.Items = append(matches.Items, result.matches.Items...)
+	result.batch.remaining--
+	if result.batch.remaining == 0 {
+		completed = append(completed, result.batch)
+	}
+	return matches, completed
+}
+
+// store writes the matches, retrying a few times before it gives up and returns the error.
+func (p *MatchPool) store(ctx context.Context, matches MatchSet) error {
+	if matches.Len() == 0 && len(matches.Items) == 0 {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		writeStart := time.Now()
+		err := p.pipeline.Persist(matches)
+		if err == nil {
+			p.pm.Write(matches.Len(), writeStart)
+			return nil
+		}
+		p.pm.WriteError()
+		if attempt >= matchWriteTries {
+			return err
+		}
+		p.logger.Warn("retrying match write", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * matchWriteBackoff):
+		}
+	}
+}
+
+// commit marks the batches as stored and runs the Commit of every batch at the head of its
+// source's queue that is done, so cursors only advance in the order the batches were fetched.
+func (p *MatchPool) commit(completed []*pendingBatch) {
+	if len(completed) == 0 {
+		return
+	}
+
+	ready := make([]*pendingBatch, 0, len(completed))
+	p.mu.Lock()
+	for _, pb := range completed {
+		pb.done = true
+	}
+	for _, pb := range completed {
+		source := pb.batch.Source
+		queue := p.pending[source]
+		for len(queue) > 0 && queue[0].done {
+			ready = append(ready, queue[0])
+			queue = queue[1:]
+		}
+		p.pending[source] = queue
+		p.pm.PendingBatches(source, len(queue))
+	}
+	p.mu.Unlock()
+
+	// Commits run only on the writer goroutine, so their order is preserved.
+	for _, pb := range ready {
+		if pb.batch.Commit != nil {
+			pb.batch.Commit()
+		}
+	}
+}